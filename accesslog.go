@@ -0,0 +1,312 @@
+package ginx
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedactedValue 是访问日志中被脱敏字段的占位值.
+const RedactedValue = "[REDACTED]"
+
+type accessLogConfig struct {
+	bodySampleRate float64
+}
+
+// AccessLogOption 配置 AccessLog.
+type AccessLogOption func(*accessLogConfig)
+
+// AccessLogBodies 按 rate (0~1) 采样记录绑定后的 Req 与返回的 Rsp; 默认 0 不记录.
+// 带 `log:"redact"` 或 `sensitive:"true"` tag 的字段输出为 RedactedValue.
+func AccessLogBodies(rate float64) AccessLogOption {
+	return func(c *accessLogConfig) { c.bodySampleRate = rate }
+}
+
+// AccessLog 返回基于 log/slog 的访问日志 Observer, 每次请求在响应写出后输出一条记录,
+// 包含路由、状态码、业务 code、耗时与客户端 IP, 开启 WithRequestID 时还包含 request_id.
+//
+// 日志级别: 成功为 Info; 绑定/校验失败与 ErrWrap 4xx 为 Warn;
+// 普通 error、5xx 与 panic 为 Error. logger 为 nil 时使用 slog.Default().
+func AccessLog(logger *slog.Logger, opts ...AccessLogOption) Observer {
+	cfg := accessLogConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(ctx context.Context, info RegisterInfo) (context.Context, func(Outcome)) {
+		start := time.Now()
+		route := info.Path
+		if gc, ok := GinContext(ctx); ok && gc.FullPath() != "" {
+			route = gc.FullPath()
+		}
+		clientIP := ClientIP(ctx)
+		sampled := cfg.bodySampleRate > 0 && (cfg.bodySampleRate >= 1 || rand.Float64() < cfg.bodySampleRate)
+
+		return nil, func(out Outcome) {
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+			level := accessLogLevel(out)
+			if !l.Enabled(ctx, level) {
+				return
+			}
+			attrs := make([]slog.Attr, 0, 12)
+			attrs = append(attrs,
+				slog.String("method", info.Method),
+				slog.String("route", route),
+				slog.Int("status", out.Status),
+				slog.Int("code", out.Code),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("client_ip", clientIP),
+			)
+			if info.OperationID != "" {
+				attrs = append(attrs, slog.String("operation_id", info.OperationID))
+			}
+			if id := RequestID(ctx); id != "" {
+				attrs = append(attrs, slog.String("request_id", id))
+			}
+			if out.Err != nil {
+				attrs = append(attrs,
+					slog.String("stage", string(out.Stage)),
+					slog.String("error", out.Err.Error()),
+				)
+			}
+			if out.Events > 0 {
+				attrs = append(attrs, slog.Int("events", out.Events))
+			}
+			if sampled {
+				if out.Req != nil {
+					attrs = append(attrs, slog.Any("req", Redact(out.Req)))
+				}
+				if out.Rsp != nil {
+					attrs = append(attrs, slog.Any("rsp", Redact(out.Rsp)))
+				}
+			}
+			l.LogAttrs(ctx, level, "ginx access", attrs...)
+		}
+	}
+}
+
+func accessLogLevel(out Outcome) slog.Level {
+	switch {
+	case out.Status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case out.Err == nil:
+		return slog.LevelInfo
+	case out.Stage == StageBinding || out.Stage == StageValidation:
+		return slog.LevelWarn
+	}
+	var ew *ErrWrap
+	if errors.As(out.Err, &ew) {
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+// Redact 返回 v 的可日志化副本: struct 按 json 字段名展开为 map, 带
+// `log:"redact"` 或 `sensitive:"true"` tag 的字段替换为 RedactedValue.
+// 不含脱敏字段的类型原样返回. 脱敏元数据按类型计算一次并缓存.
+func Redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(v))
+}
+
+// redactPlan 是某个类型的脱敏元数据. sensitive 为 false 时该类型可原样输出.
+type redactPlan struct {
+	kind        reflect.Kind
+	fields      []redactField // struct
+	elem        *redactPlan   // slice / array / map
+	passthrough bool          // 自定义 JSON / Text 序列化, 不展开
+	sensitive   bool
+}
+
+type redactField struct {
+	index  []int
+	name   string
+	redact bool
+	plan   *redactPlan
+}
+
+var redactCache sync.Map // reflect.Type -> *redactPlan
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+func redactPlanOf(t reflect.Type) *redactPlan {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if cached, ok := redactCache.Load(t); ok {
+		return cached.(*redactPlan)
+	}
+	building := map[reflect.Type]*redactPlan{}
+	plan := buildRedactPlan(t, building)
+
+	// 递归类型在构建过程中可能读到尚未完成的 sensitive, 这里迭代到不动点.
+	for changed := true; changed; {
+		changed = false
+		for _, p := range building {
+			if !p.sensitive && p.hasSensitiveChild() {
+				p.sensitive = true
+				changed = true
+			}
+		}
+	}
+	for bt, p := range building {
+		redactCache.LoadOrStore(bt, p)
+	}
+	return plan
+}
+
+func buildRedactPlan(t reflect.Type, building map[reflect.Type]*redactPlan) *redactPlan {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if cached, ok := redactCache.Load(t); ok {
+		return cached.(*redactPlan)
+	}
+	if p, ok := building[t]; ok {
+		return p
+	}
+	p := &redactPlan{kind: t.Kind()}
+	building[t] = p
+
+	pt := reflect.PointerTo(t)
+	if t.Implements(jsonMarshalerType) || pt.Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || pt.Implements(textMarshalerType) {
+		p.passthrough = true
+		return p
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		// 静态类型未知, 渲染时按动态类型再判断.
+		p.sensitive = true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		p.elem = buildRedactPlan(t.Elem(), building)
+	case reflect.Struct:
+		p.fields = redactFields(t, nil, building)
+	}
+	return p
+}
+
+func redactFields(t reflect.Type, prefix []int, building map[reflect.Type]*redactPlan) []redactField {
+	var fields []redactField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		index := append(append([]int(nil), prefix...), i)
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		// 与 encoding/json 一致, 无 json 名的嵌入 struct 字段提升到外层.
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && f.Type.Kind() != reflect.Pointer {
+			fields = append(fields, redactFields(ft, index, building)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, redactField{
+			index:  index,
+			name:   name,
+			redact: f.Tag.Get("log") == "redact" || f.Tag.Get("sensitive") == "true",
+			plan:   buildRedactPlan(ft, building),
+		})
+	}
+	return fields
+}
+
+func (p *redactPlan) hasSensitiveChild() bool {
+	if p.passthrough {
+		return false
+	}
+	if p.elem != nil && p.elem.sensitive {
+		return true
+	}
+	for _, f := range p.fields {
+		if f.redact || f.plan.sensitive {
+			return true
+		}
+	}
+	return false
+}
+
+func redactValue(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	p := redactPlanOf(v.Type())
+	if !p.sensitive || p.passthrough || !v.CanInterface() {
+		if v.CanInterface() {
+			return v.Interface()
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any, len(p.fields))
+		for _, f := range p.fields {
+			if f.redact {
+				m[f.name] = RedactedValue
+				continue
+			}
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				continue
+			}
+			m[f.name] = redactValue(fv)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = redactValue(iter.Value())
+		}
+		return out
+	}
+	return v.Interface()
+}
@@ -0,0 +1,180 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type loginReq struct {
+	User     string `json:"user" binding:"required"`
+	Password string `json:"password" log:"redact"`
+	Meta     *loginMeta
+}
+
+type loginMeta struct {
+	Device string `json:"device"`
+	Token  string `json:"token" sensitive:"true"`
+}
+
+type loginRsp struct {
+	Session string    `json:"session" log:"redact"`
+	Expires time.Time `json:"expires"`
+	Roles   []string  `json:"roles"`
+}
+
+type plainRsp struct {
+	Message string `json:"message"`
+}
+
+type redactNode struct {
+	Next   *redactNode `json:"next"`
+	Secret string      `json:"secret" log:"redact"`
+}
+
+type redactHolder struct {
+	Node  redactWrapper  `json:"node"`
+	Items []loginMeta    `json:"items"`
+	ByKey map[string]any `json:"by_key"`
+}
+
+type redactWrapper struct {
+	Inner *redactHolder `json:"inner"`
+	Value string        `json:"value"`
+	loginMeta
+}
+
+func TestRedact(t *testing.T) {
+	got := Redact(&loginReq{User: "bob", Password: "pw", Meta: &loginMeta{Device: "ios", Token: "tk"}})
+	want := map[string]any{
+		"user":     "bob",
+		"password": RedactedValue,
+		"Meta":     map[string]any{"device": "ios", "token": RedactedValue},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Redact = %#v, want %#v", got, want)
+	}
+
+	plain := &plainRsp{Message: "hi"}
+	if got := Redact(plain); got != any(*plain) {
+		t.Fatalf("types without sensitive fields should pass through, got %#v", got)
+	}
+
+	node := Redact(redactNode{Secret: "a", Next: &redactNode{Secret: "b"}})
+	wantNode := map[string]any{
+		"secret": RedactedValue,
+		"next":   map[string]any{"secret": RedactedValue, "next": nil},
+	}
+	if !reflect.DeepEqual(node, wantNode) {
+		t.Fatalf("recursive Redact = %#v, want %#v", node, wantNode)
+	}
+
+	holder := Redact(redactHolder{
+		Node:  redactWrapper{Value: "v", loginMeta: loginMeta{Device: "d", Token: "t"}},
+		Items: []loginMeta{{Token: "x"}},
+		ByKey: map[string]any{"meta": loginMeta{Token: "y"}},
+	}).(map[string]any)
+	node2 := holder["node"].(map[string]any)
+	if node2["token"] != RedactedValue || node2["device"] != "d" || node2["value"] != "v" {
+		t.Fatalf("embedded struct not inlined/redacted: %#v", node2)
+	}
+	if holder["items"].([]any)[0].(map[string]any)["token"] != RedactedValue {
+		t.Fatalf("slice element not redacted: %#v", holder["items"])
+	}
+	if holder["by_key"].(map[string]any)["meta"].(map[string]any)["token"] != RedactedValue {
+		t.Fatalf("interface map value not redacted: %#v", holder["by_key"])
+	}
+
+	if _, ok := redactCache.Load(reflect.TypeFor[loginReq]()); !ok {
+		t.Fatal("redact plan should be cached per type")
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	e := New(WithObserver(AccessLog(logger, AccessLogBodies(1))))
+	r := gin.New()
+	g := e.Group(r, "/api")
+	POST(g, "/login", func(ctx context.Context, req *loginReq) (*loginRsp, error) {
+		switch req.User {
+		case "locked":
+			return nil, Error(4031, "locked").Status(http.StatusForbidden)
+		case "boom":
+			return nil, errors.New("db down")
+		}
+		return &loginRsp{Session: "s3cr3t", Roles: []string{"admin"}}, nil
+	}, OperationID("login"))
+
+	tests := []struct {
+		name  string
+		body  string
+		level string
+		code  float64
+		stage string
+	}{
+		{"success", `{"user":"bob","password":"hunter2"}`, "INFO", 0, ""},
+		{"validation", `{"password":"hunter2"}`, "WARN", 1, "validation"},
+		{"business error", `{"user":"locked","password":"hunter2"}`, "WARN", 4031, "handler"},
+		{"internal error", `{"user":"boom","password":"hunter2"}`, "ERROR", 2, "handler"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "10.0.0.7:1234"
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if strings.Contains(buf.String(), "hunter2") || strings.Contains(buf.String(), "s3cr3t") {
+				t.Fatalf("sensitive value leaked: %s", buf.String())
+			}
+			var rec map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+				t.Fatalf("decode log %q: %v", buf.String(), err)
+			}
+			if rec["level"] != tt.level || rec["code"] != tt.code {
+				t.Fatalf("level/code = %v/%v, want %s/%v: %s", rec["level"], rec["code"], tt.level, tt.code, buf.String())
+			}
+			if rec["route"] != "/api/login" || rec["operation_id"] != "login" || rec["client_ip"] != "10.0.0.7" {
+				t.Fatalf("unexpected route fields: %s", buf.String())
+			}
+			if rec["status"] != float64(w.Code) {
+				t.Fatalf("status = %v, recorder %d", rec["status"], w.Code)
+			}
+			if stage, _ := rec["stage"].(string); stage != tt.stage {
+				t.Fatalf("stage = %q, want %q", stage, tt.stage)
+			}
+			if _, ok := rec["latency"]; !ok {
+				t.Fatal("missing latency")
+			}
+			if reqBody, _ := rec["req"].(map[string]any); reqBody["password"] != RedactedValue {
+				t.Fatalf("req body not redacted: %v", rec["req"])
+			}
+		})
+	}
+}
+
+func TestAccessLogBodiesDisabledByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	e := New(WithObserver(AccessLog(slog.New(slog.NewJSONHandler(&buf, nil)))))
+	r := gin.New()
+	GET(e.Wrap(r), "/ok", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{Message: "hi"}, nil
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if strings.Contains(buf.String(), `"rsp"`) || !strings.Contains(buf.String(), `"status":200`) {
+		t.Fatalf("unexpected log: %s", buf.String())
+	}
+}
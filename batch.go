@@ -0,0 +1,320 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchItem 是批量请求中的一个子请求. Method 为空时为 GET; Path 可带 query,
+// 与 Query 合并. Body 原样作为子请求 body, 未指定 Content-Type 时按 JSON 发送.
+type BatchItem struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Query   url.Values        `json:"query,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchResult 是子请求的响应. 合法 JSON body 原样嵌入, 其它内容编码为 JSON 字符串.
+type BatchResult struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// BatchOption 配置 RegisterBatch.
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	handler     http.Handler
+	allow       []batchRoute
+	maxItems    int
+	concurrency int
+	itemTimeout time.Duration
+	multiStatus bool
+}
+
+type batchRoute struct {
+	method string
+	path   string
+}
+
+// BatchAllow 添加允许的路由, 格式为 "METHOD /path/template", 模板语法与 gin 相同
+// (":id" 匹配一段, "*rest" 匹配剩余部分); METHOD 为 "*" 表示任意方法.
+func BatchAllow(routes ...string) BatchOption {
+	return func(c *batchConfig) {
+		for _, r := range routes {
+			method, p, ok := strings.Cut(strings.TrimSpace(r), " ")
+			if !ok || !strings.HasPrefix(strings.TrimSpace(p), "/") {
+				panic(fmt.Sprintf("ginx: invalid batch route %q", r))
+			}
+			c.allow = append(c.allow, batchRoute{method: strings.ToUpper(method), path: strings.TrimSpace(p)})
+		}
+	}
+}
+
+// BatchMaxItems 设置单次批量请求的最大子请求数, 默认 20.
+func BatchMaxItems(n int) BatchOption {
+	return func(c *batchConfig) { c.maxItems = n }
+}
+
+// BatchConcurrency 设置子请求并发数, 默认 1 即按顺序执行.
+func BatchConcurrency(n int) BatchOption {
+	return func(c *batchConfig) { c.concurrency = n }
+}
+
+// BatchItemTimeout 为每个子请求设置超时, 到期后子请求 ctx 以 ErrTimeout 取消.
+func BatchItemTimeout(d time.Duration) BatchOption {
+	return func(c *batchConfig) { c.itemTimeout = d }
+}
+
+// BatchMultiStatus 让批量响应使用 207 Multi-Status 而不是 200.
+func BatchMultiStatus() BatchOption {
+	return func(c *batchConfig) { c.multiStatus = true }
+}
+
+// BatchHandler 指定分发子请求的 http.Handler. r 为 *gin.Engine (或包装它的 Router)
+// 时默认使用该 engine, 挂在 RouterGroup 上时必须指定.
+func BatchHandler(h http.Handler) BatchOption {
+	return func(c *batchConfig) { c.handler = h }
+}
+
+// RegisterBatch 注册批量端点: POST path 接收 BatchItem 数组, 在进程内经同一个 gin
+// engine 分发到已注册路由, 按原顺序返回 BatchResult 数组. 子请求继承外层请求头
+// (Accept-Encoding 等除外), 中间件、拦截器与 Observer 照常执行.
+// 只有匹配 BatchAllow 的子请求会被分发, 其它返回 403; 未配置 BatchAllow 时 panic.
+func RegisterBatch(r gin.IRoutes, path string, opts ...BatchOption) {
+	router, engine := engineOf(r)
+	bc := batchConfig{maxItems: 20, concurrency: 1}
+	for _, opt := range opts {
+		opt(&bc)
+	}
+	if bc.handler == nil {
+		ge, ok := router.(*gin.Engine)
+		if !ok {
+			panic("ginx: RegisterBatch on a route group requires BatchHandler")
+		}
+		bc.handler = ge
+	}
+	if len(bc.allow) == 0 {
+		panic("ginx: RegisterBatch requires BatchAllow")
+	}
+	cfg := engine.resolveRoute(nil)
+	router.Handle(http.MethodPost, path, func(gc *gin.Context) {
+		if cfg.compression != nil {
+			if cw := beginCompression(ginExchangeOf(gc), cfg.compression); cw != nil {
+				defer cw.finish(ginExchangeOf(gc))
+			}
+		}
+		serveBatch(gc, cfg, &bc)
+	})
+}
+
+func serveBatch(gc *gin.Context, cfg resolved, bc *batchConfig) {
+	fail := func(status int, msg string) {
+		x := ginExchangeOf(gc)
+		renderJSON(x, cfg, status, successBody{Code: cfg.invalidArgCode, Msg: msg, RequestID: RequestID(requestContext(x))})
+		gc.Abort()
+	}
+	if err := limitBody(ginExchangeOf(gc), cfg.limits); err != nil {
+		s, msg := limitError(err)
+		fail(s, msg)
+		return
+	}
+	var items []BatchItem
+	if err := cfg.jsonCodec().NewDecoder(gc.Request.Body).Decode(&items); err != nil {
+		if s, msg := limitError(err); s > 0 {
+			fail(s, msg)
+			return
+		}
+		fail(http.StatusBadRequest, "batch body must be a JSON array: "+err.Error())
+		return
+	}
+	if bc.maxItems > 0 && len(items) > bc.maxItems {
+		fail(http.StatusRequestEntityTooLarge, fmt.Sprintf("batch exceeds %d items", bc.maxItems))
+		return
+	}
+
+	results := make([]BatchResult, len(items))
+	workers := max(bc.concurrency, 1)
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range items {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i] = dispatchBatchItem(gc, cfg, bc, &items[i])
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if bc.multiStatus {
+		status = http.StatusMultiStatus
+	}
+	renderJSON(ginExchangeOf(gc), cfg, status, results)
+}
+
+// batchSkipHeaders 是不从外层请求继承的请求头.
+var batchSkipHeaders = []string{"Accept-Encoding", "Content-Length", "Content-Type", "Content-Encoding", "Idempotency-Key"}
+
+// dispatchBatchItem 在进程内执行一个子请求. 子请求在独立 goroutine 中运行, 没有 net/http 的
+// panic 保护, 因此这里恢复 panic 并转换为 500 结果, 避免整个进程退出.
+func dispatchBatchItem(gc *gin.Context, cfg resolved, bc *batchConfig, item *BatchItem) (res BatchResult) {
+	reject := func(status int, msg string) BatchResult {
+		return batchError(cfg.jsonCodec(), status, cfg.invalidArgCode, msg)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			fmt.Fprintf(gin.DefaultErrorWriter, "[ginx] panic recovered in batch item %s %s: %v\n%s", item.Method, item.Path, p, debug.Stack())
+			res = batchError(cfg.jsonCodec(), http.StatusInternalServerError, cfg.internalErrorCode, internalMessage(cfg, fmt.Errorf("panic: %v", p)))
+		}
+	}()
+	method := strings.ToUpper(item.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	u, err := url.Parse(item.Path)
+	if err != nil || !strings.HasPrefix(u.Path, "/") || u.Host != "" || path.Clean(u.Path) != u.Path {
+		return reject(http.StatusBadRequest, fmt.Sprintf("invalid batch path %q", item.Path))
+	}
+	if u.Path == gc.FullPath() || !bc.allowed(method, u.Path) {
+		return reject(http.StatusForbidden, fmt.Sprintf("route not allowed: %s %s", method, u.Path))
+	}
+	if len(item.Query) > 0 {
+		q := u.Query()
+		for k, vs := range item.Query {
+			for _, v := range vs {
+				q.Add(k, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	ctx := gc.Request.Context()
+	if bc.itemTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, bc.itemTimeout, ErrTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.RequestURI(), bytes.NewReader(item.Body))
+	if err != nil {
+		return reject(http.StatusBadRequest, err.Error())
+	}
+	req.RemoteAddr = gc.Request.RemoteAddr
+	req.Host = gc.Request.Host
+	for k, vs := range gc.Request.Header {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+	for _, k := range batchSkipHeaders {
+		req.Header.Del(k)
+	}
+	if len(item.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range item.Headers {
+		req.Header.Set(k, v)
+	}
+
+	w := &batchRecorder{header: make(http.Header)}
+	bc.handler.ServeHTTP(w, req)
+	return w.result(cfg.jsonCodec())
+}
+
+// batchError 构造以 ginx 错误响应体为 body 的子请求结果.
+func batchError(codec Codec, status, code int, msg string) BatchResult {
+	body, _ := codec.Marshal(successBody{Code: code, Msg: msg})
+	return BatchResult{Status: status, Headers: map[string]string{"Content-Type": "application/json; charset=utf-8"}, Body: body}
+}
+
+func (c *batchConfig) allowed(method, p string) bool {
+	for _, r := range c.allow {
+		if (r.method == "*" || r.method == method) && matchRoute(r.path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRoute 按 gin 路由模板语法匹配路径.
+func matchRoute(pattern, p string) bool {
+	ps := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	vs := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, seg := range ps {
+		if strings.HasPrefix(seg, "*") {
+			return true
+		}
+		if i >= len(vs) {
+			return false
+		}
+		if strings.HasPrefix(seg, ":") {
+			if vs[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != vs[i] {
+			return false
+		}
+	}
+	return len(ps) == len(vs)
+}
+
+// batchRecorder 在内存中记录子请求的响应.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *batchRecorder) Header() http.Header { return w.header }
+
+func (w *batchRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *batchRecorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// Flush 为流式路由提供 http.Flusher, 内容仍在子请求结束后一次性返回.
+func (w *batchRecorder) Flush() {}
+
+func (w *batchRecorder) result(codec Codec) BatchResult {
+	res := BatchResult{Status: w.status}
+	if res.Status == 0 {
+		res.Status = http.StatusOK
+	}
+	if len(w.header) > 0 {
+		res.Headers = make(map[string]string, len(w.header))
+		for k, vs := range w.header {
+			res.Headers[k] = strings.Join(vs, ", ")
+		}
+	}
+	switch b := w.body.Bytes(); {
+	case len(b) == 0:
+	case json.Valid(b):
+		res.Body = append(json.RawMessage(nil), b...)
+	default:
+		res.Body, _ = codec.Marshal(string(b))
+	}
+	return res
+}
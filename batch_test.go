@@ -0,0 +1,200 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type batchUserReq struct {
+	ID     int    `uri:"id" binding:"required"`
+	Fields string `form:"fields"`
+	Caller string `header:"X-Caller"`
+}
+
+type batchCreateReq struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func batchPost(r http.Handler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Caller", "web")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRegisterBatch(t *testing.T) {
+	var inflight, peak atomic.Int32
+	r := gin.New()
+	g := New().Wrap(r)
+	GET(g, "/users/:id", func(ctx context.Context, req *batchUserReq) (*AnyMap, error) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return &AnyMap{"id": req.ID, "fields": req.Fields, "caller": req.Caller}, nil
+	})
+	POST(g, "/users", func(ctx context.Context, req *batchCreateReq) (*AnyMap, error) {
+		return &AnyMap{"name": req.Name}, nil
+	}, SuccessStatus(http.StatusCreated))
+	GET(g, "/slow", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return nil, waitOrDone(ctx, time.Second)
+	})
+	GET(g, "/admin", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{Message: "secret"}, nil
+	})
+	r.GET("/text", func(gc *gin.Context) { gc.String(http.StatusOK, "plain") })
+	RegisterBatch(g, "/batch",
+		BatchAllow("GET /users/:id", "POST /users", "GET /slow", "* /text"),
+		BatchMaxItems(5), BatchConcurrency(2), BatchItemTimeout(20*time.Millisecond))
+
+	w := batchPost(r, `[
+		{"path":"/users/1?fields=a","query":{"fields":["b"]}},
+		{"method":"POST","path":"/users","body":{"name":"ann"}},
+		{"method":"POST","path":"/users","body":{}},
+		{"path":"/users/2","headers":{"X-Caller":"item"}},
+		{"path":"/slow"}
+	]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("batch = %d %s", w.Code, w.Body.String())
+	}
+	var results []BatchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil || len(results) != 5 {
+		t.Fatalf("results = %s (%v)", w.Body.String(), err)
+	}
+	want := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusOK, `"caller":"web","fields":"a","id":1`},
+		{http.StatusCreated, `"name":"ann"`},
+		{http.StatusBadRequest, `"msg":"name is required"`},
+		{http.StatusOK, `"caller":"item"`},
+		{http.StatusGatewayTimeout, `"code":3`},
+	}
+	for i, tt := range want {
+		if results[i].Status != tt.status || !strings.Contains(string(results[i].Body), tt.body) {
+			t.Fatalf("item %d = %d %s, want %d %s", i, results[i].Status, results[i].Body, tt.status, tt.body)
+		}
+	}
+	if results[0].Headers["Content-Type"] == "" {
+		t.Fatalf("item headers = %v", results[0].Headers)
+	}
+	if peak.Load() > 2 {
+		t.Fatalf("concurrency peak = %d", peak.Load())
+	}
+
+	w = batchPost(r, `[{"path":"/admin"},{"method":"DELETE","path":"/users/1"},{"path":"/users/../admin"},{"path":"/batch"},{"method":"PUT","path":"/text"}]`)
+	results = nil
+	_ = json.Unmarshal(w.Body.Bytes(), &results)
+	for i, status := range []int{http.StatusForbidden, http.StatusForbidden, http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound} {
+		if results[i].Status != status {
+			t.Fatalf("item %d = %d %s, want %d", i, results[i].Status, results[i].Body, status)
+		}
+	}
+	if w := batchPost(r, `[{"path":"/text"}]`); !strings.Contains(w.Body.String(), `"body":"plain"`) {
+		t.Fatalf("text item = %s", w.Body.String())
+	}
+
+	for body, status := range map[string]int{
+		`{"path":"/text"}`:              http.StatusBadRequest,
+		strings.Repeat(`{},`, 6) + `{}`: http.StatusBadRequest,
+		`[` + strings.Repeat(`{"path":"/text"},`, 5) + `{"path":"/text"}]`: http.StatusRequestEntityTooLarge,
+	} {
+		if w := batchPost(r, body); w.Code != status {
+			t.Fatalf("%s = %d %s, want %d", body, w.Code, w.Body.String(), status)
+		}
+	}
+
+	multi := gin.New()
+	GET(multi, "/ping", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) { return &simpleRsp{Message: "pong"}, nil })
+	RegisterBatch(multi, "/batch", BatchAllow("GET /ping"), BatchMultiStatus())
+	if w := batchPost(multi, `[{"path":"/ping"}]`); w.Code != http.StatusMultiStatus || !strings.Contains(w.Body.String(), `"pong"`) {
+		t.Fatalf("multi-status = %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBatchItemPanic(t *testing.T) {
+	r := gin.New()
+	g := New(WithExposeInternalError(false)).Wrap(r)
+	GET(g, "/boom", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) { panic("boom") })
+	GET(g, "/ok", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) { return &simpleRsp{Message: "ok"}, nil })
+	RegisterBatch(g, "/batch", BatchAllow("GET /boom", "GET /ok"))
+
+	w := batchPost(r, `[{"path":"/boom"},{"path":"/ok"}]`)
+	var results []BatchResult
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("%d %s", w.Code, w.Body.String())
+	}
+	if len(results) != 2 || results[0].Status != http.StatusInternalServerError || strings.Contains(string(results[0].Body), "boom") || results[1].Status != http.StatusOK {
+		t.Fatalf("results = %s", w.Body.String())
+	}
+}
+
+func TestBatchCodec(t *testing.T) {
+	codec := &countingCodec{}
+	r := gin.New()
+	r.GET("/text", func(gc *gin.Context) { gc.String(http.StatusOK, "hi") })
+	RegisterBatch(New(WithCodec(codec)).Wrap(r), "/batch", BatchAllow("GET /text"))
+
+	w := batchPost(r, `[{"path":"/text"},{"path":"/other"}]`)
+	if !strings.Contains(w.Body.String(), `"body":"hi"`) || !strings.Contains(w.Body.String(), `"status":403`) {
+		t.Fatalf("%d %s", w.Code, w.Body.String())
+	}
+	// 文本 body + 拒绝结果 + 外层响应.
+	if n := codec.marshal.Load(); n != 3 {
+		t.Fatalf("codec marshal calls = %d", n)
+	}
+}
+
+func TestRegisterBatchPanics(t *testing.T) {
+	for name, register := range map[string]func(){
+		"no allowlist": func() { RegisterBatch(gin.New(), "/batch") },
+		"group":        func() { RegisterBatch(gin.New().Group("/api"), "/batch", BatchAllow("GET /x")) },
+		"bad route":    func() { RegisterBatch(gin.New(), "/batch", BatchAllow("/x")) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: expected panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+	g := gin.New()
+	RegisterBatch(g.Group("/api"), "/batch", BatchAllow("GET /x"), BatchHandler(g))
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/users/:id", "/users/1", true},
+		{"/users/:id", "/users", false},
+		{"/users/:id", "/users/1/posts", false},
+		{"/files/*rest", "/files/a/b", true},
+		{"/users", "/users", true},
+		{"/users", "/orders", false},
+	}
+	for _, tt := range tests {
+		if got := matchRoute(tt.pattern, tt.path); got != tt.want {
+			t.Fatalf("matchRoute(%q, %q) = %v", tt.pattern, tt.path, got)
+		}
+	}
+}
@@ -0,0 +1,607 @@
+package ginx
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindProgram 是按类型预编译的 header / cookie / uri / form 字段赋值程序.
+// 语义与 gin 的 MapFormWithTag 完全一致 (包括未打 tag 时以字段名为 key、
+// default= 选项、collection_format、time_format 等), 但字段偏移、key 与转换器
+// 在注册时一次算好. 多个来源编译进同一个程序, 每条指令记录自己的来源,
+// 每次请求只做一次线性遍历, 不再反射遍历结构体.
+// 含 gin 特殊处理的字段 (指针或切片 BindUnmarshaler、parser= 选项、指针结构体、文件等) 时
+// 不编译, 回退到 gin binder.
+type bindProgram struct {
+	ops []bindOp
+	// explicit 用于自定义来源: 只编译打了 tag 的字段, 来源缺失时清零字段.
+	explicit bool
+}
+
+type bindKind uint8
+
+const (
+	kindString bindKind = iota
+	kindBool
+	kindInt
+	kindInt8
+	kindInt16
+	kindInt32
+	kindInt64
+	kindUint
+	kindUint8
+	kindUint16
+	kindUint32
+	kindUint64
+	kindFloat32
+	kindFloat64
+	kindDuration
+	kindTime
+	kindJSON        // 结构体 / map, 按 JSON 解码
+	kindUnmarshaler // 实现 binding.BindUnmarshaler 的非指针字段, 如 Optional
+)
+
+type bindOp struct {
+	offset       uintptr
+	src          uint8 // 来源在 run 的 srcs 中的下标
+	key          string
+	kind         bindKind
+	ptr          bool // 字段为 *T
+	slice        bool // 字段为 []T
+	hasDefault   bool
+	defaultValue string
+	sep          string       // collection_format 分隔符, 空表示 multi
+	typ          reflect.Type // 字段 (slice 时为元素) 类型, 仅 kindJSON 与 slice 分配使用
+	elemSize     uintptr
+	children     int          // kindJSON 结构体未赋值时继续执行的子字段 op 数量
+	zero         reflect.Type // explicit 程序中的字段类型, 来源缺失时清零
+
+	timeLayout string
+	timeUnit   string // unix / unixmilli / unixmicro / unixnano
+	timeLoc    *time.Location
+}
+
+// bindSource 是一次请求中某个来源的取值.
+type bindSource interface {
+	values(key string) ([]string, bool)
+}
+
+type mapSource map[string][]string
+
+func (m mapSource) values(key string) ([]string, bool) {
+	vs, ok := m[key]
+	return vs, ok
+}
+
+type paramsSource gin.Params
+
+func (p *paramsSource) values(key string) ([]string, bool) {
+	for _, param := range *p {
+		if param.Key == key {
+			return []string{param.Value}, true
+		}
+	}
+	return nil, false
+}
+
+type querySource struct{ gc *gin.Context }
+
+func (q querySource) values(key string) ([]string, bool) { return q.gc.GetQueryArray(key) }
+
+// exchangeSource 通过 exchange 取路径参数或 query, 用于非 gin 请求.
+type exchangeSource struct {
+	x    exchange
+	path bool
+}
+
+func (s exchangeSource) values(key string) ([]string, bool) {
+	if s.path {
+		if v, ok := s.x.Param(key); ok {
+			return []string{v}, true
+		}
+		return nil, false
+	}
+	return s.x.Query(key)
+}
+
+// uriSource 返回路径参数来源; gin 请求直接读取 gin.Params 以避免分配.
+func uriSource(x exchange) bindSource {
+	if gx, ok := x.(ginExchange); ok {
+		return (*paramsSource)(&gx.gc.Params)
+	}
+	return exchangeSource{x: x, path: true}
+}
+
+// formSource 返回 query 来源.
+func formSource(x exchange) bindSource {
+	if gx, ok := x.(ginExchange); ok {
+		return querySource{gx.gin()}
+	}
+	return exchangeSource{x: x}
+}
+
+var (
+	bindUnmarshalerType = reflect.TypeFor[binding.BindUnmarshaler]()
+	timeType            = reflect.TypeFor[time.Time]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	fileHeaderType      = reflect.TypeFor[multipart.FileHeader]()
+)
+
+// structPointer 在 Req 为结构体时返回 req 的地址, 否则返回 nil.
+func structPointer[Req any](req *Req) unsafe.Pointer {
+	if reflect.TypeFor[Req]().Kind() != reflect.Struct {
+		return nil
+	}
+	return unsafe.Pointer(req)
+}
+
+// compileBindProgram 按 tags 的顺序把各来源编译进同一个赋值程序, 第 i 个 tag 的指令
+// 从 run 的 srcs[i] 取值; 任一来源不支持时返回 nil.
+func compileBindProgram(t reflect.Type, tags ...string) *bindProgram {
+	p := &bindProgram{}
+	for i, tag := range tags {
+		if !p.compileStruct(t, 0, tag, uint8(i)) {
+			return nil
+		}
+	}
+	return p
+}
+
+func (p *bindProgram) compileStruct(t reflect.Type, base uintptr, tag string, src uint8) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if f.Tag.Get(tag) == "-" {
+			continue
+		}
+		if f.Anonymous {
+			switch {
+			case f.Type.Kind() == reflect.Struct:
+				if !p.compileStruct(f.Type, base+f.Offset, tag, src) {
+					return false
+				}
+				continue
+			case f.Type.Kind() == reflect.Pointer, f.PkgPath != "":
+				return false
+			}
+		}
+		if !p.compileField(f, base+f.Offset, tag, src) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *bindProgram) compileField(f reflect.StructField, offset uintptr, tag string, src uint8) bool {
+	if _, ok := f.Tag.Lookup(tag); p.explicit && !ok {
+		return true
+	}
+	name, opts, _ := strings.Cut(f.Tag.Get(tag), ",")
+	if name == "" {
+		name = f.Name
+	}
+	if tag == "header" {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+	}
+	op := bindOp{offset: offset, src: src, key: name}
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		k, v, _ := strings.Cut(opt, "=")
+		switch k {
+		case "default":
+			op.hasDefault = true
+			op.defaultValue = v
+		case "parser":
+			return false
+		}
+	}
+
+	ft := f.Type
+	switch ft.Kind() {
+	case reflect.Pointer:
+		op.ptr = true
+		ft = ft.Elem()
+		if ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Struct && ft != timeType {
+			return false
+		}
+	case reflect.Slice:
+		op.slice = true
+		switch cf := f.Tag.Get("collection_format"); cf {
+		case "", "multi":
+		case "csv":
+			op.sep = ","
+		case "ssv":
+			op.sep = " "
+		case "tsv":
+			op.sep = "\t"
+		case "pipes":
+			op.sep = "|"
+		default:
+			return false
+		}
+		if op.hasDefault && (op.sep == "" || op.sep == ",") {
+			op.defaultValue = strings.ReplaceAll(op.defaultValue, ";", ",")
+		}
+		if isBindUnmarshaler(ft) {
+			return false
+		}
+		ft = ft.Elem()
+		op.elemSize = ft.Size()
+		if ft.Kind() == reflect.Pointer {
+			return false
+		}
+	case reflect.Array, reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128, reflect.Uintptr:
+		return false
+	}
+	if p.explicit {
+		// 来源缺失时回到 default tag 的值, 没有 default 时清零, 不保留 query / body 写入的值.
+		switch def, ok := f.Tag.Lookup("default"); {
+		case op.hasDefault:
+		case !ok || op.slice: // 切片的 default tag 为 JSON 格式, 与 default= 选项不兼容
+			op.zero = f.Type
+		default:
+			op.hasDefault, op.defaultValue = true, def
+		}
+	}
+	op.typ = ft
+	if isBindUnmarshaler(ft) {
+		if op.ptr || op.slice {
+			return false
+		}
+		op.kind = kindUnmarshaler
+		p.ops = append(p.ops, op)
+		return true
+	}
+	kind, ok := bindKindOf(ft)
+	if !ok {
+		return false
+	}
+	op.kind = kind
+	if kind == kindTime {
+		op.timeLayout = f.Tag.Get("time_format")
+		if op.timeLayout == "" {
+			op.timeLayout = time.RFC3339
+		}
+		switch tf := strings.ToLower(op.timeLayout); tf {
+		case "unix", "unixmilli", "unixmicro", "unixnano":
+			op.timeUnit = tf
+		}
+		if isUTC, _ := strconv.ParseBool(f.Tag.Get("time_utc")); isUTC {
+			op.timeLoc = time.UTC
+		}
+		if locTag := f.Tag.Get("time_location"); locTag != "" {
+			loc, err := time.LoadLocation(locTag)
+			if err != nil {
+				return false
+			}
+			op.timeLoc = loc
+		}
+	}
+	idx := len(p.ops)
+	p.ops = append(p.ops, op)
+	// 与 gin 一致: 非匿名结构体字段整体未命中时, 继续按其子字段取值.
+	if kind == kindJSON && !op.ptr && !op.slice && ft.Kind() == reflect.Struct {
+		if !p.compileStruct(ft, offset, tag, src) {
+			return false
+		}
+		p.ops[idx].children = len(p.ops) - idx - 1
+	}
+	return true
+}
+
+func isBindUnmarshaler(t reflect.Type) bool {
+	return t.Implements(bindUnmarshalerType) || reflect.PointerTo(t).Implements(bindUnmarshalerType)
+}
+
+func bindKindOf(t reflect.Type) (bindKind, bool) {
+	switch t {
+	case timeType:
+		return kindTime, true
+	case durationType:
+		return kindDuration, true
+	case fileHeaderType:
+		return 0, false
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return kindString, true
+	case reflect.Bool:
+		return kindBool, true
+	case reflect.Int:
+		return kindInt, true
+	case reflect.Int8:
+		return kindInt8, true
+	case reflect.Int16:
+		return kindInt16, true
+	case reflect.Int32:
+		return kindInt32, true
+	case reflect.Int64:
+		return kindInt64, true
+	case reflect.Uint:
+		return kindUint, true
+	case reflect.Uint8:
+		return kindUint8, true
+	case reflect.Uint16:
+		return kindUint16, true
+	case reflect.Uint32:
+		return kindUint32, true
+	case reflect.Uint64:
+		return kindUint64, true
+	case reflect.Float32:
+		return kindFloat32, true
+	case reflect.Float64:
+		return kindFloat64, true
+	case reflect.Struct, reflect.Map:
+		return kindJSON, true
+	}
+	return 0, false
+}
+
+// run 按程序把各来源中的值写入 obj 指向的结构体, 指令按 op.src 从 srcs 取值.
+func (p *bindProgram) run(obj unsafe.Pointer, srcs ...bindSource) error {
+	for i := 0; i < len(p.ops); i++ {
+		op := &p.ops[i]
+		vs, ok := srcs[op.src].values(op.key)
+		field := unsafe.Add(obj, op.offset)
+		if !ok && !op.hasDefault {
+			if op.zero != nil {
+				reflect.NewAt(op.zero, field).Elem().SetZero()
+			}
+			continue
+		}
+		if op.slice {
+			if err := op.setSlice(field, vs); err != nil {
+				return err
+			}
+			continue
+		}
+		val := op.defaultValue
+		if ok && len(vs) > 0 && vs[0] != "" {
+			val = vs[0]
+		}
+		if op.ptr {
+			if err := op.setPointer(field, val); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := op.set(field, val); err != nil {
+			return err
+		}
+		i += op.children
+	}
+	return nil
+}
+
+type sliceHeader struct {
+	data unsafe.Pointer
+	len  int
+	cap  int
+}
+
+func (op *bindOp) setSlice(field unsafe.Pointer, vs []string) error {
+	if len(vs) == 0 {
+		if !op.hasDefault {
+			return nil
+		}
+		if op.sep == "" {
+			vs = strings.Split(op.defaultValue, ",")
+		} else {
+			vs = []string{op.defaultValue}
+		}
+	}
+	if op.sep != "" {
+		split := make([]string, 0, len(vs))
+		for _, v := range vs {
+			split = append(split, strings.Split(v, op.sep)...)
+		}
+		vs = split
+	}
+	data := op.makeSlice(len(vs))
+	for i, v := range vs {
+		if err := op.set(unsafe.Add(data, uintptr(i)*op.elemSize), v); err != nil {
+			return err
+		}
+	}
+	*(*sliceHeader)(field) = sliceHeader{data: data, len: len(vs), cap: len(vs)}
+	return nil
+}
+
+// makeSlice 按元素类型分配 n 个元素的底层数组; 具名类型与其底层类型内存布局一致.
+func (op *bindOp) makeSlice(n int) unsafe.Pointer {
+	switch op.kind {
+	case kindString:
+		return makeData[string](n)
+	case kindBool:
+		return makeData[bool](n)
+	case kindInt:
+		return makeData[int](n)
+	case kindInt8:
+		return makeData[int8](n)
+	case kindInt16:
+		return makeData[int16](n)
+	case kindInt32:
+		return makeData[int32](n)
+	case kindInt64, kindDuration:
+		return makeData[int64](n)
+	case kindUint:
+		return makeData[uint](n)
+	case kindUint8:
+		return makeData[uint8](n)
+	case kindUint16:
+		return makeData[uint16](n)
+	case kindUint32:
+		return makeData[uint32](n)
+	case kindUint64:
+		return makeData[uint64](n)
+	case kindFloat32:
+		return makeData[float32](n)
+	case kindFloat64:
+		return makeData[float64](n)
+	case kindTime:
+		return makeData[time.Time](n)
+	}
+	return reflect.MakeSlice(reflect.SliceOf(op.typ), n, n).UnsafePointer()
+}
+
+func makeData[T any](n int) unsafe.Pointer {
+	return unsafe.Pointer(unsafe.SliceData(make([]T, n)))
+}
+
+func (op *bindOp) setPointer(field unsafe.Pointer, val string) error {
+	pp := (*unsafe.Pointer)(field)
+	if *pp != nil {
+		return op.set(*pp, val)
+	}
+	elem := reflect.New(op.typ).UnsafePointer()
+	if err := op.set(elem, val); err != nil {
+		return err
+	}
+	*pp = elem
+	return nil
+}
+
+// set 对应 gin 的 setWithProperType.
+func (op *bindOp) set(p unsafe.Pointer, val string) error {
+	if op.kind == kindUnmarshaler {
+		return reflect.NewAt(op.typ, p).Interface().(binding.BindUnmarshaler).UnmarshalParam(val)
+	}
+	if op.kind != kindString {
+		val = strings.TrimSpace(val)
+	}
+	switch op.kind {
+	case kindString:
+		*(*string)(p) = val
+	case kindBool:
+		if val == "" {
+			val = "false"
+		}
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		*(*bool)(p) = b
+	case kindInt, kindInt8, kindInt16, kindInt32, kindInt64:
+		if val == "" {
+			val = "0"
+		}
+		n, err := strconv.ParseInt(val, 10, intBits[op.kind])
+		if err != nil {
+			return err
+		}
+		switch op.kind {
+		case kindInt:
+			*(*int)(p) = int(n)
+		case kindInt8:
+			*(*int8)(p) = int8(n)
+		case kindInt16:
+			*(*int16)(p) = int16(n)
+		case kindInt32:
+			*(*int32)(p) = int32(n)
+		default:
+			*(*int64)(p) = n
+		}
+	case kindUint, kindUint8, kindUint16, kindUint32, kindUint64:
+		if val == "" {
+			val = "0"
+		}
+		n, err := strconv.ParseUint(val, 10, intBits[op.kind])
+		if err != nil {
+			return err
+		}
+		switch op.kind {
+		case kindUint:
+			*(*uint)(p) = uint(n)
+		case kindUint8:
+			*(*uint8)(p) = uint8(n)
+		case kindUint16:
+			*(*uint16)(p) = uint16(n)
+		case kindUint32:
+			*(*uint32)(p) = uint32(n)
+		default:
+			*(*uint64)(p) = n
+		}
+	case kindFloat32, kindFloat64:
+		if val == "" {
+			val = "0.0"
+		}
+		bits := 64
+		if op.kind == kindFloat32 {
+			bits = 32
+		}
+		f, err := strconv.ParseFloat(val, bits)
+		if err != nil {
+			return err
+		}
+		if op.kind == kindFloat32 {
+			*(*float32)(p) = float32(f)
+		} else {
+			*(*float64)(p) = f
+		}
+	case kindDuration:
+		if val == "" {
+			val = "0"
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		*(*time.Duration)(p) = d
+	case kindTime:
+		return op.setTime((*time.Time)(p), val)
+	case kindJSON:
+		return json.Unmarshal([]byte(val), reflect.NewAt(op.typ, p).Interface())
+	}
+	return nil
+}
+
+var intBits = [...]int{
+	kindInt: 0, kindInt8: 8, kindInt16: 16, kindInt32: 32, kindInt64: 64,
+	kindUint: 0, kindUint8: 8, kindUint16: 16, kindUint32: 32, kindUint64: 64,
+}
+
+func (op *bindOp) setTime(t *time.Time, val string) error {
+	if val == "" {
+		*t = time.Time{}
+		return nil
+	}
+	if op.timeUnit != "" {
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		switch op.timeUnit {
+		case "unix":
+			*t = time.Unix(n, 0)
+		case "unixmilli":
+			*t = time.UnixMilli(n)
+		case "unixmicro":
+			*t = time.UnixMicro(n)
+		default:
+			*t = time.Unix(0, n)
+		}
+		return nil
+	}
+	loc := op.timeLoc
+	if loc == nil {
+		loc = time.Local
+	}
+	parsed, err := time.ParseInLocation(op.timeLayout, val, loc)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
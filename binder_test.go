@@ -0,0 +1,253 @@
+package ginx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type binderDuration time.Duration
+
+type binderEmbedded struct {
+	Page int    `form:"page,default=1"`
+	Sort string `form:"sort"`
+}
+
+type binderNested struct {
+	City    string `form:"city"`
+	Country string `form:"country,default=cn"`
+}
+
+type binderFormReq struct {
+	binderEmbedded
+	S        string            `form:"s"`
+	Untagged string            // 以字段名为 key
+	Skip     string            `form:"-"`
+	B        bool              `form:"b"`
+	I        int               `form:"i"`
+	I8       int8              `form:"i8"`
+	I16      int16             `form:"i16"`
+	I32      int32             `form:"i32"`
+	I64      int64             `form:"i64"`
+	U        uint              `form:"u"`
+	U8       uint8             `form:"u8"`
+	U16      uint16            `form:"u16"`
+	U32      uint32            `form:"u32"`
+	U64      uint64            `form:"u64"`
+	F32      float32           `form:"f32"`
+	F64      float64           `form:"f64"`
+	D        time.Duration     `form:"d"`
+	ND       binderDuration    `form:"nd"`
+	T        time.Time         `form:"t"`
+	TUnix    time.Time         `form:"tu" time_format:"unix"`
+	TMilli   time.Time         `form:"tm" time_format:"unixmilli"`
+	TDate    time.Time         `form:"td" time_format:"2006-01-02" time_utc:"1"`
+	TLoc     time.Time         `form:"tl" time_format:"2006-01-02 15:04" time_location:"Asia/Shanghai"`
+	Def      string            `form:"def,default=x"`
+	DefInt   int               `form:"defi,default=7"`
+	PS       *string           `form:"ps"`
+	PI       *int              `form:"pi"`
+	PT       *time.Time        `form:"pt"`
+	Tags     []string          `form:"tag"`
+	Ints     []int             `form:"n"`
+	CSV      []string          `form:"csv" collection_format:"csv"`
+	SSV      []int             `form:"ssv" collection_format:"ssv"`
+	Pipes    []string          `form:"pipes" collection_format:"pipes"`
+	DefTags  []string          `form:"dt,default=a;b"`
+	DefCSV   []int             `form:"dc,default=1;2" collection_format:"csv"`
+	Times    []time.Time       `form:"ts" time_format:"unix"`
+	Nested   binderNested      `form:"nested"`
+	Map      map[string]string `form:"map"`
+	Objs     []binderNested    `form:"objs"`
+	Custom   binderUnmarshaler `form:"custom"`
+	Opt      Optional[int]     `form:"opt"`
+	OptS     Optional[string]  `form:"opts"`
+	OptDef   Optional[int]     `form:"optd,default=2"`
+}
+
+type binderHeaderReq struct {
+	Token   string   `header:"x-token"`
+	Trace   *string  `header:"X-Trace"`
+	Langs   []string `header:"accept-language"`
+	Retries int      `header:"X-Retries,default=3"`
+	Ignored string   `header:"-"`
+}
+
+type binderUnmarshaler struct{ v string }
+
+func (u *binderUnmarshaler) UnmarshalParam(s string) error { u.v = s; return nil }
+
+func TestCompileBindProgramFallback(t *testing.T) {
+	tests := []any{
+		struct {
+			U *binderUnmarshaler `form:"u"`
+		}{},
+		struct {
+			U []binderUnmarshaler `form:"u"`
+		}{},
+		struct {
+			P string `form:"p,parser=encoding.TextUnmarshaler"`
+		}{},
+		struct {
+			A [2]int `form:"a"`
+		}{},
+		struct {
+			C []string `form:"c" collection_format:"bad"`
+		}{},
+		struct {
+			P *binderNested `form:"p"`
+		}{},
+		struct {
+			*binderEmbedded
+		}{},
+		struct {
+			T time.Time `form:"t" time_location:"Nowhere/Invalid"`
+		}{},
+		struct {
+			X any `form:"x"`
+		}{},
+	}
+	for i, v := range tests {
+		if p := compileBindProgram(reflect.TypeOf(v), "form"); p != nil {
+			t.Errorf("case %d (%T): expected fallback", i, v)
+		}
+	}
+	if p := compileBindProgram(reflect.TypeFor[binderFormReq](), "form"); p == nil {
+		t.Fatal("binderFormReq should compile")
+	}
+}
+
+func TestBindProgramMatchesGin(t *testing.T) {
+	tests := []url.Values{
+		{},
+		{"s": {" spaced "}, "Untagged": {"u"}, "Skip": {"no"}, "-": {"no"}, "b": {"true"}, "i": {" -3 "}, "i8": {"127"},
+			"i16": {"-2"}, "i32": {"9"}, "i64": {"1"}, "u": {"1"}, "u8": {"255"}, "u16": {"2"}, "u32": {"3"}, "u64": {"4"},
+			"f32": {"1.5"}, "f64": {"-2.25"}, "d": {"1m30s"}, "nd": {"12"}},
+		{"s": {""}, "b": {""}, "i": {""}, "u": {""}, "f64": {""}, "d": {""}, "t": {""}, "def": {""}, "defi": {""}},
+		{"t": {"2026-01-02T03:04:05+08:00"}, "tu": {"1700000000"}, "tm": {"1700000000123"}, "td": {"2026-03-04"}, "tl": {"2026-03-04 05:06"}},
+		{"ps": {"p"}, "pi": {"5"}, "pt": {"2026-01-02T03:04:05Z"}},
+		{"tag": {"a", "b"}, "n": {"1", "2"}, "csv": {"a,b", "c"}, "ssv": {"1 2"}, "pipes": {"x|y"}, "ts": {"1", "2"}},
+		{"tag": {}, "dt": {}, "dc": {}},
+		{"page": {"3"}, "sort": {"-id"}},
+		{"nested": {`{"city":"sh"}`}},
+		{"city": {"bj"}},
+		{"map": {`{"k":"v"}`}, "objs": {`{"city":"a"}`, `{"country":"b"}`}},
+		{"i": {"x"}},
+		{"i8": {"128"}},
+		{"u": {"-1"}},
+		{"b": {"yes"}},
+		{"f32": {"1e400"}},
+		{"d": {"5"}},
+		{"t": {"yesterday"}},
+		{"tu": {"soon"}},
+		{"pi": {"x"}},
+		{"n": {"1", "x"}},
+		{"nested": {"{"}},
+		{"objs": {"x"}},
+		{"custom": {" raw "}, "opt": {" 5 "}, "opts": {""}, "optd": {"3"}},
+		{"opt": {""}, "opts": {"s"}},
+		{"opt": {"x"}},
+	}
+	for _, form := range tests {
+		var want, got binderFormReq
+		wantErr := binding.MapFormWithTag(&want, form, "form")
+		p := compileBindProgram(reflect.TypeFor[binderFormReq](), "form")
+		gotErr := p.run(unsafe.Pointer(&got), mapSource(form))
+		if fmt.Sprint(wantErr) != fmt.Sprint(gotErr) || !reflect.DeepEqual(want, got) {
+			t.Errorf("form %v:\ngin      = %+v (%v)\ncompiled = %+v (%v)", form, want, wantErr, got, gotErr)
+		}
+	}
+
+	// 已有指针字段原地赋值.
+	s := "old"
+	want, got := binderFormReq{PS: new(string)}, binderFormReq{PS: &s}
+	*want.PS = "old"
+	form := url.Values{"ps": {"new"}}
+	_ = binding.MapFormWithTag(&want, form, "form")
+	_ = compileBindProgram(reflect.TypeFor[binderFormReq](), "form").run(unsafe.Pointer(&got), mapSource(form))
+	if *got.PS != *want.PS || got.PS != &s {
+		t.Fatalf("pointer reuse: got %v want %v", *got.PS, *want.PS)
+	}
+}
+
+func TestBindProgramHeaderMatchesGin(t *testing.T) {
+	for _, h := range []http.Header{
+		{},
+		{"X-Token": {"t"}, "X-Trace": {"tr"}, "Accept-Language": {"en", "zh"}, "X-Retries": {"5"}, "Ignored": {"no"}},
+		{"X-Retries": {"many"}},
+	} {
+		var want, got binderHeaderReq
+		wantErr := binding.Header.Bind(&http.Request{Header: h}, &want)
+		p := compileBindProgram(reflect.TypeFor[binderHeaderReq](), "header")
+		gotErr := p.run(unsafe.Pointer(&got), mapSource(h))
+		if fmt.Sprint(wantErr) != fmt.Sprint(gotErr) || !reflect.DeepEqual(want, got) {
+			t.Errorf("header %v:\ngin      = %+v (%v)\ncompiled = %+v (%v)", h, want, wantErr, got, gotErr)
+		}
+	}
+}
+
+type binderMergedReq struct {
+	ID      int    `uri:"id"`
+	Token   string `header:"X-Token"`
+	Session string `cookie:"session"`
+	Page    int    `form:"page,default=1"`
+	Name    string // 未打 tag, 各来源都以字段名取值, 后绑定的来源覆盖前者
+}
+
+func TestBindProgramMergesSources(t *testing.T) {
+	compiled := buildBindingPlan(reflect.TypeFor[binderMergedReq]())
+	if compiled.prog == nil {
+		t.Fatal("binderMergedReq should compile")
+	}
+	if op := compiled.prog.ops[0]; op.src != srcHeader {
+		t.Fatalf("first op source = %d, want header", op.src)
+	}
+	reflective := *compiled
+	reflective.prog = nil
+
+	engine := gin.New()
+	bind := func(plan *bindingPlan, target string, header http.Header, params gin.Params) (binderMergedReq, error) {
+		gc := gin.CreateTestContextOnly(nil, engine)
+		gc.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		gc.Request.Header = header
+		gc.Params = params
+		var r binderMergedReq
+		err := bindRequest(ginExchangeOf(gc), resolved{}, plan, &r)
+		return r, err
+	}
+	for _, tt := range []struct {
+		target string
+		header http.Header
+		params gin.Params
+	}{
+		{"/u/1", http.Header{}, gin.Params{{Key: "id", Value: "1"}}},
+		{"/u/1?page=3&Name=q", http.Header{"X-Token": {"t"}, "Name": {"h"}, "Cookie": {"session=s%201"}},
+			gin.Params{{Key: "id", Value: "1"}, {Key: "Name", Value: "p"}}},
+		{"/u/1?Name=q", http.Header{"Name": {"h"}}, nil},
+		{"/u/x", http.Header{}, gin.Params{{Key: "id", Value: "x"}}},
+		{"/u/1?page=x", http.Header{}, gin.Params{{Key: "id", Value: "1"}}},
+	} {
+		got, gotErr := bind(compiled, tt.target, tt.header, tt.params)
+		want, wantErr := bind(&reflective, tt.target, tt.header, tt.params)
+		if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) || got != want {
+			t.Errorf("%s %v:\ncompiled = %+v (%v)\ngin      = %+v (%v)", tt.target, tt.header, got, gotErr, want, wantErr)
+		}
+	}
+
+	// 任一来源无法编译时整个 Req 回退到 gin binder, 保持来源顺序.
+	type fallbackReq struct {
+		Token  string        `header:"X-Token"`
+		Nested *binderNested `form:"nested"`
+	}
+	if buildBindingPlan(reflect.TypeFor[fallbackReq]()).prog != nil {
+		t.Fatal("fallbackReq should not compile")
+	}
+}
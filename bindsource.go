@@ -0,0 +1,154 @@
+package ginx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BindingSource 按 key 取自定义 tag 来源的值, 第二个返回值为 false 表示该 key 不存在.
+type BindingSource func(gc *gin.Context, key string) ([]string, bool)
+
+// reservedBindingTags 是 ginx / gin 自身使用的 tag, 不能注册为自定义来源.
+var reservedBindingTags = map[string]bool{
+	"json": true, "form": true, "uri": true, "header": true, "cookie": true,
+	"binding": true, "default": true, "collection_format": true,
+	"time_format": true, "time_utc": true, "time_location": true,
+}
+
+// RegisterBindingSource 注册自定义 tag 绑定来源, 例如从 gc.Get 读取的 `ctx:"user_id"`、
+// 鉴权中间件写入的 JWT claims `jwt:"sub"`. 打了该 tag 的字段与内置来源一样支持类型转换、
+// default= 选项、default tag 与 binding 校验, 校验错误中的字段名也取该 tag.
+//
+// 自定义来源在 query / body 之后绑定并以其为准: 来源中没有该 key 时字段取 default= 选项或 default tag
+// 的值, 都没有时清零, 客户端无法通过同名 query 或 JSON 字段伪造. 只对此后注册的路由生效, 应在注册路由前调用;
+// tag 为空、与内置 tag 重名或 src 为 nil 时 panic.
+func (e *Engine) RegisterBindingSource(tag string, src BindingSource) {
+	if tag == "" || reservedBindingTags[tag] {
+		panic(fmt.Sprintf("ginx: binding source tag %q is reserved", tag))
+	}
+	if src == nil {
+		panic("ginx: binding source must not be nil")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	sources := make(map[string]BindingSource, len(e.bindingSources)+1)
+	for k, v := range e.bindingSources {
+		sources[k] = v
+	}
+	sources[tag] = src
+	e.bindingSources = sources
+}
+
+type customSource struct {
+	gc  *gin.Context
+	src BindingSource
+}
+
+func (s customSource) values(key string) ([]string, bool) { return s.src(s.gc, key) }
+
+// routePlan 返回路由使用的 bindingPlan: 在按类型缓存的 plan 上叠加 Engine 的自定义来源,
+// 并为 Engine 私有 validator 注册 Optional 类型.
+func routePlan(cfg resolved, t reflect.Type) *bindingPlan {
+	plan := buildBindingPlan(t)
+	if len(cfg.bindingSources) > 0 && !plan.isEmpty {
+		plan = withBindingSources(plan, t, cfg.bindingSources)
+	}
+	cfg.validator.prepare(plan)
+	return plan
+}
+
+// withBindingSources 为 t 中用到的自定义 tag 编译赋值程序, 返回 plan 的副本;
+// t 未使用任何自定义 tag 时原样返回 plan.
+func withBindingSources(plan *bindingPlan, t reflect.Type, sources map[string]BindingSource) *bindingPlan {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	tags := make([]string, 0, len(sources))
+	for tag := range sources {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	// 用到的来源编译进同一个程序, 第 i 个来源的指令从 plan.sources[i] 取值.
+	var used []BindingSource
+	p := &bindProgram{explicit: true}
+	names := make(map[string]string)
+	for _, tag := range tags {
+		if !collectTagNames(t, tag, names, map[reflect.Type]struct{}{}) {
+			continue
+		}
+		if !p.compileStruct(t, 0, tag, uint8(len(used))) {
+			panic(fmt.Sprintf("ginx: %s has fields that cannot be bound from %q", t, tag))
+		}
+		used = append(used, sources[tag])
+	}
+	if len(used) == 0 {
+		return plan
+	}
+	cp := *plan
+	cp.sources = used
+	cp.sourceProg = p
+	cp.fieldNameMap = make(map[string]string, len(plan.fieldNameMap)+len(names))
+	for k, v := range plan.fieldNameMap {
+		cp.fieldNameMap[k] = v
+	}
+	for k, v := range names {
+		if _, ok := cp.fieldNameMap[k]; !ok {
+			cp.fieldNameMap[k] = v
+		}
+	}
+	return &cp
+}
+
+// collectTagNames 把 t (含嵌入结构体) 中打了 tag 的字段名写入 names (已有的不覆盖), 返回是否找到.
+func collectTagNames(t reflect.Type, tag string, names map[string]string, seen map[reflect.Type]struct{}) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if _, ok := seen[t]; ok {
+		return false
+	}
+	seen[t] = struct{}{}
+	found := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		if v, ok := f.Tag.Lookup(tag); ok && v != "-" {
+			found = true
+			if name, _, _ := strings.Cut(v, ","); name != "" {
+				if _, ok := names[f.Name]; !ok {
+					names[f.Name] = name
+				}
+			}
+		}
+		if f.Anonymous && collectTagNames(f.Type, tag, names, seen) {
+			found = true
+		}
+	}
+	return found
+}
+
+// bindSources 执行 plan 上的自定义来源程序. BindingSource 以 *gin.Context 取值,
+// 因此只用于 gin 路由, NewHTTPHandler 在注册时拒绝带自定义来源的 Req.
+func bindSources(x exchange, plan *bindingPlan, obj unsafe.Pointer) error {
+	gx, ok := x.(ginExchange)
+	if !ok {
+		return errors.New("ginx: custom binding sources require a gin route")
+	}
+	srcs := make([]bindSource, len(plan.sources))
+	for i, src := range plan.sources {
+		srcs[i] = customSource{gc: gx.gin(), src: src}
+	}
+	return plan.sourceProg.run(obj, srcs...)
+}
@@ -0,0 +1,100 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sourceReq struct {
+	UserID int64    `ctx:"user_id" binding:"required"`
+	Tenant string   `ctx:"tenant" default:"acme"`
+	Role   string   `jwt:"role,default=guest"`
+	Scopes []string `jwt:"scope" collection_format:"ssv"`
+	Name   string   `json:"name"`
+}
+
+func TestRegisterBindingSource(t *testing.T) {
+	e := New(WithInvalidArgCode(4000))
+	e.RegisterBindingSource("ctx", func(gc *gin.Context, key string) ([]string, bool) {
+		v, ok := gc.Get(key)
+		if !ok {
+			return nil, false
+		}
+		return []string{fmt.Sprint(v)}, true
+	})
+	e.RegisterBindingSource("jwt", func(gc *gin.Context, key string) ([]string, bool) {
+		claims, _ := gc.Value("claims").(map[string]string)
+		v, ok := claims[key]
+		return []string{v}, ok
+	})
+
+	r := gin.New()
+	r.Use(func(gc *gin.Context) {
+		if id := gc.GetHeader("X-Test-User"); id != "" {
+			gc.Set("user_id", id)
+		}
+		if gc.GetHeader("X-Test-Admin") != "" {
+			gc.Set("claims", map[string]string{"role": "admin", "scope": "read write"})
+		}
+	})
+	handler := func(ctx context.Context, req *sourceReq) (*sourceReq, error) { return req, nil }
+	POST(e.Wrap(r), "/sources", handler)
+	POST(r, "/plain", handler)
+
+	tests := []struct {
+		path, query, body, user string
+		admin                   bool
+		status                  int
+		want                    string
+	}{
+		{"/sources", "", `{"name":"n"}`, "42", true, http.StatusOK, `{"UserID":42,"Tenant":"acme","Role":"admin","Scopes":["read","write"],"name":"n"}`},
+		{"/sources", "", `{}`, "42", false, http.StatusOK, `{"UserID":42,"Tenant":"acme","Role":"guest","Scopes":null,"name":""}`},
+		{"/sources", "?UserID=7&Role=admin", `{"UserID":7,"Tenant":"evil","Role":"admin","Scopes":["all"]}`, "", false, http.StatusBadRequest, "user_id is required"},
+		{"/sources", "", `{}`, "abc", false, http.StatusBadRequest, ""},
+		{"/plain", "", `{"UserID":7}`, "42", true, http.StatusOK, `{"UserID":7,"Tenant":"acme","Role":"","Scopes":null,"name":""}`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path+tt.query, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.user != "" {
+			req.Header.Set("X-Test-User", tt.user)
+		}
+		if tt.admin {
+			req.Header.Set("X-Test-Admin", "1")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("%s%s %s: %d %s", tt.path, tt.query, tt.body, w.Code, w.Body.String())
+		}
+		var body struct {
+			Code int             `json:"code"`
+			Msg  string          `json:"msg"`
+			Data json.RawMessage `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if tt.status == http.StatusOK && string(body.Data) != tt.want || tt.status != http.StatusOK && (body.Code != 4000 || tt.want != "" && body.Msg != tt.want) {
+			t.Fatalf("%s%s %s: body %s", tt.path, tt.query, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestRegisterBindingSourceReserved(t *testing.T) {
+	for _, tag := range []string{"", "json", "header", "binding"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("tag %q should panic", tag)
+				}
+			}()
+			New().RegisterBindingSource(tag, func(*gin.Context, string) ([]string, bool) { return nil, false })
+		}()
+	}
+}
@@ -40,14 +40,15 @@ func ValidateResponseStatus(status int, expected ...int) error {
 }
 
 type dataWrapper struct {
-	Code *int            `json:"code"`
-	Msg  *string         `json:"msg"`
-	Data json.RawMessage `json:"data"`
+	Code      *int            `json:"code"`
+	Msg       *string         `json:"msg"`
+	Data      json.RawMessage `json:"data"`
+	RequestID string          `json:"request_id"`
 }
 
-func parseDataWrapper(body []byte) (dataWrapper, bool, error) {
+func parseDataWrapper(codec Codec, body []byte) (dataWrapper, bool, error) {
 	var wrapper dataWrapper
-	if err := json.Unmarshal(body, &wrapper); err != nil {
+	if err := codec.Unmarshal(body, &wrapper); err != nil {
 		return dataWrapper{}, false, err
 	}
 	if wrapper.Code == nil {
@@ -68,12 +69,20 @@ func wrapperMsg(wrapper dataWrapper) string {
 
 // ParseResponse 解析 HTTP 响应体, 兼容 DataWrap 和 NoDataWrap 两种模式.
 //   - HTTP 错误 + 空 body → 返回 *ErrWrap{HttpCode}
-//   - body 为 {code, msg, data} 格式且 code != 0 → 返回 *ErrWrap 业务错误
+//   - body 为 {code, msg, data} 格式且 code != 0 → 返回 *ErrWrap 业务错误, 带 request_id 时一并回填
 //   - HTTP 错误即使使用 code=0 的 wrapper → 仍返回 *ErrWrap HTTP 错误
 //   - body 为 {code:0, data:...} 格式 → 从 data 字段反序列化 result
 //   - body 非 wrapper 格式 + HTTP 错误 → 返回 *ErrWrap{HttpCode, Msg: body}
 //   - body 非 wrapper 格式 + HTTP 成功 → 直接反序列化 body 到 result
 func ParseResponse(statusCode int, body []byte, result any) error {
+	return ParseResponseWith(StdCodec, statusCode, body, result)
+}
+
+// ParseResponseWith 与 ParseResponse 相同, 但使用 codec 解码; codec 为 nil 时使用 StdCodec.
+func ParseResponseWith(codec Codec, statusCode int, body []byte, result any) error {
+	if codec == nil {
+		codec = StdCodec
+	}
 	if len(body) == 0 {
 		if statusCode >= 400 {
 			return &ErrWrap{HttpCode: statusCode}
@@ -81,15 +90,15 @@ func ParseResponse(statusCode int, body []byte, result any) error {
 		return nil
 	}
 
-	if wrapper, ok, err := parseDataWrapper(body); err == nil && ok {
+	if wrapper, ok, err := parseDataWrapper(codec, body); err == nil && ok {
 		if *wrapper.Code != 0 {
-			return &ErrWrap{Code: *wrapper.Code, Msg: wrapperMsg(wrapper), HttpCode: statusCode}
+			return &ErrWrap{Code: *wrapper.Code, Msg: wrapperMsg(wrapper), RequestID: wrapper.RequestID, HttpCode: statusCode}
 		}
 		if statusCode >= http.StatusBadRequest {
-			return &ErrWrap{Code: -1, Msg: wrapperMsg(wrapper), HttpCode: statusCode}
+			return &ErrWrap{Code: -1, Msg: wrapperMsg(wrapper), RequestID: wrapper.RequestID, HttpCode: statusCode}
 		}
 		if result != nil && wrapper.Data != nil {
-			return json.Unmarshal(wrapper.Data, result)
+			return codec.Unmarshal(wrapper.Data, result)
 		}
 		return nil
 	}
@@ -98,7 +107,7 @@ func ParseResponse(statusCode int, body []byte, result any) error {
 		return &ErrWrap{Code: -1, Msg: string(body), HttpCode: statusCode}
 	}
 	if result != nil {
-		return json.Unmarshal(body, result)
+		return codec.Unmarshal(body, result)
 	}
 	return nil
 }
@@ -266,6 +275,7 @@ func (s *SSEStream) closeES() {
 type JSONLinesStream struct {
 	body   io.ReadCloser
 	br     *bufio.Reader
+	codec  Codec
 	ctx    context.Context
 	cancel context.CancelFunc
 	once   sync.Once
@@ -308,6 +318,26 @@ func (s *JSONLinesStream) Recv() (json.RawMessage, error) {
 	}
 }
 
+// WithCodec sets the codec used by Decode; nil keeps StdCodec. Generated
+// clients pass the codec configured through their WithCodec method.
+func (s *JSONLinesStream) WithCodec(codec Codec) *JSONLinesStream {
+	s.codec = codec
+	return s
+}
+
+// Decode reads the next record and unmarshals it into v with the stream's
+// codec. Returns io.EOF at end of stream.
+func (s *JSONLinesStream) Decode(v any) error {
+	rec, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	if s.codec == nil {
+		return StdCodec.Unmarshal(rec, v)
+	}
+	return s.codec.Unmarshal(rec, v)
+}
+
 // Close releases the underlying response body. Safe to call multiple times.
 func (s *JSONLinesStream) Close() error {
 	s.cancel()
@@ -0,0 +1,138 @@
+package ginx
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+
+	"resty.dev/v3"
+)
+
+// Codec 是 ginx 使用的 JSON 编解码实现. 通过 WithCodec 设置到 Engine 后,
+// 请求体解码、响应渲染、JSON Lines / SSE 数据编码都使用它; 生成的客户端通过
+// WithCodec 方法设置后, 请求体编码与 ParseResponseWith / JSONLinesStream 解码也使用它.
+// sonic、goccy/go-json、encoding/json/v2 等实现只需适配这三个方法.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Decoder 是流式解码器, 方法语义与 *json.Decoder 一致.
+type Decoder interface {
+	Decode(v any) error
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+// StdCodec 基于 encoding/json, 是 Engine 与客户端的默认 Codec.
+var StdCodec Codec = stdCodec{}
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (stdCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (stdCodec) NewDecoder(r io.Reader) Decoder     { return json.NewDecoder(r) }
+
+// WithCodec 设置 Engine 的 JSON Codec, nil 恢复为 StdCodec. 同时清除 WithJSONRenderer,
+// 使 JSON 响应改用该 Codec 渲染; 仍需自定义渲染时在其后使用 WithJSONRenderer.
+// WithJsonDecoderUseNumber / WithStrictJSONBody 的语义保持不变.
+func WithCodec(c Codec) EngineOption {
+	return func(e *Engine) {
+		e.codec = c
+		e.jsonRenderer = nil
+	}
+}
+
+// Codec 返回 Engine 的 JSON Codec, 未设置时为 StdCodec; 供 ginx/jsonrpc 等其它传输
+// 编解码协议信封, 与路由保持一致.
+func (e *Engine) Codec() Codec {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.codec == nil {
+		return StdCodec
+	}
+	return e.codec
+}
+
+// jsonCodec 返回路由使用的 Codec, 未设置时为 StdCodec.
+func (r *resolved) jsonCodec() Codec {
+	if r.codec == nil {
+		return StdCodec
+	}
+	return r.codec
+}
+
+type codecKey struct{}
+
+// codecOf 返回当前请求使用的 Codec, 供拿不到路由配置的流式 sender 使用.
+func codecOf(x exchange) Codec {
+	if v, ok := x.Get(codecKey{}); ok {
+		return v.(Codec)
+	}
+	return StdCodec
+}
+
+// codecRender 实现 gin render.Render, 输出与 gin.Context.JSON 一致, 经 renderTo 写出.
+type codecRender struct {
+	codec Codec
+	body  any
+}
+
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+func (r codecRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	b, err := r.codec.Marshal(r.body)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func (r codecRender) WriteContentType(w http.ResponseWriter) {
+	if h := w.Header(); len(h["Content-Type"]) == 0 {
+		h["Content-Type"] = jsonContentType
+	}
+}
+
+// sseData 在非默认 Codec 下预先编码结构化 Data, 与 sse 包自身的 JSON 分支保持一致.
+func sseData(codec Codec, data any) (any, error) {
+	if codec == StdCodec || data == nil {
+		return data, nil
+	}
+	if _, ok := data.([]byte); ok {
+		return data, nil
+	}
+	v := reflect.ValueOf(data)
+	kind := v.Kind()
+	if kind == reflect.Pointer {
+		kind = v.Elem().Kind()
+	}
+	switch kind {
+	case reflect.Struct, reflect.Slice, reflect.Map:
+		return codec.Marshal(data)
+	}
+	return data, nil
+}
+
+// UseClientCodec 让 resty 客户端的 JSON 请求体编码与响应解码使用 codec.
+// 生成客户端的 WithCodec 方法会调用它.
+func UseClientCodec(c *resty.Client, codec Codec) {
+	if codec == nil {
+		codec = StdCodec
+	}
+	c.AddContentTypeEncoder("json", func(w io.Writer, v any) error {
+		b, err := codec.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	})
+	c.AddContentTypeDecoder("json", func(r io.Reader, v any) error {
+		return codec.NewDecoder(r).Decode(v)
+	})
+}
@@ -0,0 +1,137 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"resty.dev/v3"
+)
+
+// countingCodec 包装 StdCodec 并记录调用次数.
+type countingCodec struct {
+	marshal, unmarshal, decoders atomic.Int32
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshal.Add(1)
+	return StdCodec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshal.Add(1)
+	return StdCodec.Unmarshal(data, v)
+}
+
+func (c *countingCodec) NewDecoder(r io.Reader) Decoder {
+	c.decoders.Add(1)
+	return StdCodec.NewDecoder(r)
+}
+
+type codecReq struct {
+	Value any `json:"value" binding:"required"`
+}
+
+type codecRsp struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+func TestWithCodec(t *testing.T) {
+	codec := &countingCodec{}
+	newRouter := func(opts ...EngineOption) *gin.Engine {
+		r := gin.New()
+		e := New(opts...)
+		POST(e.Wrap(r), "/echo", func(ctx context.Context, req *codecReq) (*codecRsp, error) {
+			_, isNumber := req.Value.(json.Number)
+			return &codecRsp{Type: map[bool]string{true: "number", false: "other"}[isNumber], Value: req.Value}, nil
+		})
+		SSE(e.Wrap(r), "/events", func(ctx context.Context, req *struct{}, send Sender) error {
+			_ = send(Event{Event: "a", Data: map[string]int{"n": 1}})
+			return send(Event{Data: "plain"})
+		})
+		JSONLines(e.Wrap(r), http.MethodGet, "/lines", func(ctx context.Context, req *struct{}, send JSONLinesSender) error {
+			return send(codecRsp{Type: "line"})
+		})
+		return r
+	}
+	custom := newRouter(WithCodec(codec), WithJsonDecoderUseNumber(true), WithStrictJSONBody(true))
+	std := newRouter(WithJsonDecoderUseNumber(true), WithStrictJSONBody(true))
+
+	do := func(r http.Handler, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	for _, tt := range []struct{ method, target, body string }{
+		{http.MethodPost, "/echo", `{"value":1}`},
+		{http.MethodPost, "/echo", `{"value":1} {"value":2}`},
+		{http.MethodPost, "/echo", `{}`},
+		{http.MethodGet, "/events", ``},
+		{http.MethodGet, "/lines", ``},
+	} {
+		got, want := do(custom, tt.method, tt.target, tt.body), do(std, tt.method, tt.target, tt.body)
+		if got.Code != want.Code || got.Body.String() != want.Body.String() || got.Header().Get("Content-Type") != want.Header().Get("Content-Type") {
+			t.Fatalf("%s %s %s:\ncodec = %d %q %s\nstd   = %d %q %s", tt.method, tt.target, tt.body,
+				got.Code, got.Header().Get("Content-Type"), got.Body.String(), want.Code, want.Header().Get("Content-Type"), want.Body.String())
+		}
+	}
+	if !strings.Contains(do(std, http.MethodPost, "/echo", `{"value":1}`).Body.String(), `"type":"number"`) {
+		t.Fatal("UseNumber not applied")
+	}
+	// 3 次请求体解码; 成功 + 2 次错误响应渲染, SSE 结构化数据 1 次, JSON Lines 1 次.
+	if codec.decoders.Load() != 3 || codec.marshal.Load() != 5 {
+		t.Fatalf("decoders=%d marshal=%d", codec.decoders.Load(), codec.marshal.Load())
+	}
+}
+
+func TestClientCodec(t *testing.T) {
+	codec := &countingCodec{}
+	var rsp codecRsp
+	if err := ParseResponseWith(codec, http.StatusOK, []byte(`{"code":0,"msg":"","data":{"type":"x"}}`), &rsp); err != nil || rsp.Type != "x" {
+		t.Fatalf("rsp=%+v err=%v", rsp, err)
+	}
+	if codec.unmarshal.Load() != 2 {
+		t.Fatalf("unmarshal=%d", codec.unmarshal.Load())
+	}
+
+	stream := NewJSONLinesStream(context.Background(), io.NopCloser(strings.NewReader("{\"type\":\"a\"}\n\n{\"type\":\"b\"}\n"))).WithCodec(codec)
+	var lines []string
+	for {
+		var line codecRsp
+		if err := stream.Decode(&line); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line.Type)
+	}
+	if strings.Join(lines, ",") != "a,b" || codec.unmarshal.Load() != 4 {
+		t.Fatalf("lines=%v unmarshal=%d", lines, codec.unmarshal.Load())
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	c := resty.New()
+	defer c.Close()
+	UseClientCodec(c, codec)
+	var echoed codecRsp
+	if _, err := c.R().SetBody(codecRsp{Type: "req"}).SetResult(&echoed).Post(srv.URL); err != nil || echoed.Type != "req" {
+		t.Fatalf("echoed=%+v err=%v", echoed, err)
+	}
+	if codec.marshal.Load() != 1 || codec.decoders.Load() != 1 {
+		t.Fatalf("client marshal=%d decoders=%d", codec.marshal.Load(), codec.decoders.Load())
+	}
+}
@@ -0,0 +1,325 @@
+package ginx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultCompressionMinSize 小于该字节数的非流式响应体不压缩.
+const defaultCompressionMinSize = 1024
+
+// CompressWriter 是压缩编码器返回的 writer. Flush 需把已写入的数据完整输出,
+// 以便 SSE / JSON Lines 每条记录都能及时到达客户端. gzip.Writer 与
+// zstd.Encoder 均满足该接口.
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// CompressionOption 配置 WithCompression.
+type CompressionOption func(*compressionConfig)
+
+type compressionEncoder struct {
+	name      string
+	newWriter func(w io.Writer) (CompressWriter, error)
+}
+
+type compressionConfig struct {
+	minSize   int
+	gzipLevel int
+	encoders  []compressionEncoder // 按服务端偏好排序, gzip 在最后
+	skipTypes []string
+}
+
+// CompressionMinSize 设置压缩阈值, 非流式响应体小于 n 字节时原样输出; 默认 1024.
+func CompressionMinSize(n int) CompressionOption {
+	return func(c *compressionConfig) { c.minSize = n }
+}
+
+// CompressionLevel 设置内置 gzip 的压缩级别; 默认 gzip.DefaultCompression.
+func CompressionLevel(level int) CompressionOption {
+	return func(c *compressionConfig) { c.gzipLevel = level }
+}
+
+// CompressionEncoder 注册额外的 Content-Encoding (例如 zstd), 客户端同样接受时优先于 gzip;
+// 多次调用按注册顺序决定偏好.
+//
+//	ginx.CompressionEncoder("zstd", func(w io.Writer) (ginx.CompressWriter, error) {
+//	    return zstd.NewWriter(w)
+//	})
+func CompressionEncoder(name string, newWriter func(w io.Writer) (CompressWriter, error)) CompressionOption {
+	return func(c *compressionConfig) {
+		c.encoders = append(c.encoders, compressionEncoder{name: strings.ToLower(name), newWriter: newWriter})
+	}
+}
+
+// CompressionSkipTypes 追加不压缩的 Content-Type 前缀, 例如 "application/vnd.custom+zip".
+func CompressionSkipTypes(types ...string) CompressionOption {
+	return func(c *compressionConfig) { c.skipTypes = append(c.skipTypes, types...) }
+}
+
+// WithCompression 按 Accept-Encoding 协商压缩响应, 默认支持 gzip. 覆盖 JSON 包装响应、
+// DataRsp 等非 JSON 响应, 以及 SSE / JSON Lines 流 (每条记录后 flush 压缩器).
+// 压缩后响应的 ETag 在引号内追加编码名 (如 "<hash>-gzip"), 匹配 If-None-Match / If-Match 时忽略该后缀.
+// 以下情况不压缩: 小于阈值的非流式响应体、HEAD、204 / 304、已设置 Content-Encoding、
+// 带 Accept-Ranges / Content-Range 的响应 (如 FileResponse), 以及图片、音视频、
+// 压缩包等已压缩的 Content-Type. 单个路由可用 NoCompression() 关闭.
+func WithCompression(opts ...CompressionOption) EngineOption {
+	return func(e *Engine) {
+		c := &compressionConfig{minSize: defaultCompressionMinSize, gzipLevel: gzip.DefaultCompression}
+		for _, opt := range opts {
+			opt(c)
+		}
+		level := c.gzipLevel
+		c.encoders = append(c.encoders, compressionEncoder{name: "gzip", newWriter: func(w io.Writer) (CompressWriter, error) {
+			return acquireGzipWriter(w, level)
+		}})
+		for _, enc := range c.encoders {
+			etagCodings.Store(enc.name, struct{}{})
+		}
+		e.compression = c
+	}
+}
+
+// NoCompression 关闭当前路由的响应压缩.
+func NoCompression() RouteOption {
+	return func(c *routeConfig) { c.noCompression = true }
+}
+
+var gzipWriterPools sync.Map // level -> *sync.Pool
+
+type pooledGzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+func acquireGzipWriter(w io.Writer, level int) (CompressWriter, error) {
+	p, _ := gzipWriterPools.LoadOrStore(level, &sync.Pool{})
+	pool := p.(*sync.Pool)
+	if gz, ok := pool.Get().(*gzip.Writer); ok {
+		gz.Reset(w)
+		return &pooledGzipWriter{Writer: gz, pool: pool}, nil
+	}
+	gz, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, err
+	}
+	return &pooledGzipWriter{Writer: gz, pool: pool}, nil
+}
+
+func (w *pooledGzipWriter) Close() error {
+	err := w.Writer.Close()
+	w.Writer.Reset(io.Discard)
+	w.pool.Put(w.Writer)
+	return err
+}
+
+// negotiateEncoding 按 Accept-Encoding 的 q 值过滤, 返回服务端偏好最高的可用编码器.
+func (c *compressionConfig) negotiateEncoding(accept string) *compressionEncoder {
+	if accept == "" {
+		return nil
+	}
+	accepted := map[string]bool{}
+	wildcard := false
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		ok := true
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v <= 0 {
+				ok = false
+			}
+		}
+		if name == "*" {
+			wildcard = ok
+			continue
+		}
+		accepted[name] = ok
+	}
+	for i := range c.encoders {
+		enc := &c.encoders[i]
+		if ok, listed := accepted[enc.name]; ok || (!listed && wildcard) {
+			return enc
+		}
+	}
+	return nil
+}
+
+// alreadyCompressedTypes 是默认跳过压缩的 Content-Type 前缀.
+var alreadyCompressedTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-bzip2", "application/x-xz", "application/x-7z-compressed",
+	"application/x-rar-compressed", "application/wasm",
+}
+
+func (c *compressionConfig) compressibleType(ct string) bool {
+	ct = strings.ToLower(strings.TrimSpace(ct))
+	if strings.HasPrefix(ct, "image/svg") {
+		return true
+	}
+	for _, list := range [][]string{alreadyCompressedTypes, c.skipTypes} {
+		for _, prefix := range list {
+			if strings.HasPrefix(ct, strings.ToLower(prefix)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isStreamingContentType(ct string) bool {
+	return strings.HasPrefix(ct, "text/event-stream") || strings.HasPrefix(ct, "application/x-ndjson") ||
+		strings.HasPrefix(ct, "application/jsonl") || strings.HasPrefix(ct, "application/json-seq")
+}
+
+// compressWriter 在第一次写出时决定是否压缩: 非流式响应先缓冲到阈值,
+// 流式响应或显式 Flush 时立即决定. 决定之前 Status / Header 均未发送.
+type compressWriter struct {
+	responseWriter
+	cfg     *compressionConfig
+	enc     *compressionEncoder
+	method  string
+	buf     []byte
+	decided bool
+	cw      CompressWriter
+}
+
+// beginCompression 在客户端接受某种编码时替换响应 writer, 返回 nil 表示不参与协商.
+func beginCompression(x exchange, cfg *compressionConfig) *compressWriter {
+	r := x.Request()
+	enc := cfg.negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if enc == nil {
+		return nil
+	}
+	w := &compressWriter{responseWriter: x.Writer(), cfg: cfg, enc: enc, method: r.Method}
+	x.SetWriter(w)
+	return w
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		if w.cw != nil {
+			return w.cw.Write(b)
+		}
+		return w.responseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.cfg.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written 在缓冲中已有数据时也返回 true, 与未包装时的语义保持一致.
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.responseWriter.Written()
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	w.responseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		ct := w.Header().Get("Content-Type")
+		_ = w.decide(isStreamingContentType(ct) || len(w.buf) >= w.cfg.minSize)
+	}
+	if w.cw != nil {
+		_ = w.cw.Flush()
+	}
+	w.responseWriter.Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	return http.NewResponseController(w.responseWriter).Hijack()
+}
+
+// decide 确定是否压缩并写出已缓冲的数据; want 为 false 时总是原样输出.
+func (w *compressWriter) decide(want bool) error {
+	w.decided = true
+	h := w.Header()
+	if w.eligible() {
+		if !headerHasToken(h, "Vary", "Accept-Encoding") {
+			h.Add("Vary", "Accept-Encoding")
+		}
+		if want {
+			cw, err := w.enc.newWriter(w.responseWriter)
+			if err == nil {
+				w.cw = cw
+				h.Set("Content-Encoding", w.enc.name)
+				h.Del("Content-Length")
+				// 压缩后的表示与原始表示不同, 强 ETag 需要区分.
+				if etag := h.Get("ETag"); etag != "" {
+					h.Set("ETag", etagWithCoding(etag, w.enc.name))
+				}
+			}
+		}
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.cw != nil {
+		_, err := w.cw.Write(buf)
+		return err
+	}
+	_, err := w.responseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) eligible() bool {
+	status := w.Status()
+	if w.method == http.MethodHead || status < http.StatusOK ||
+		status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" || h.Get("Accept-Ranges") != "" {
+		return false
+	}
+	return w.cfg.compressibleType(h.Get("Content-Type"))
+}
+
+// finish 输出剩余缓冲并关闭压缩器, 恢复原始 writer. 以 defer 调用以覆盖 panic.
+func (w *compressWriter) finish(x exchange) {
+	if !w.decided {
+		_ = w.decide(len(w.buf) >= w.cfg.minSize)
+	}
+	if w.cw != nil {
+		_ = w.cw.Close()
+	}
+	if x.Writer() == responseWriter(w) {
+		x.SetWriter(w.responseWriter)
+	}
+}
+
+func headerHasToken(h http.Header, key, token string) bool {
+	for _, v := range h.Values(key) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package ginx
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"resty.dev/v3"
+)
+
+func compressedGet(r http.Handler, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept-Encoding", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func gunzip(t *testing.T, b []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gunzip: %v", err)
+	}
+	return string(out)
+}
+
+func TestCompressionJSONAndData(t *testing.T) {
+	large := strings.Repeat("ginx ", 400)
+	r := gin.New()
+	g := New(WithCompression()).Wrap(r)
+	GET(g, "/large", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{Message: large}, nil
+	})
+	GET(g, "/small", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{Message: "hi"}, nil
+	})
+	GET(g, "/text", func(ctx context.Context, req *simpleReq) (*DataRsp, error) {
+		return DataResponse(http.StatusOK, "text/csv", []byte(large)), nil
+	})
+	GET(g, "/png", func(ctx context.Context, req *simpleReq) (*DataRsp, error) {
+		return DataResponse(http.StatusOK, "image/png", []byte(large)), nil
+	})
+	GET(g, "/off", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{Message: large}, nil
+	}, NoCompression())
+
+	w := compressedGet(r, "/large", "br;q=1.0, gzip;q=0.8")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("large headers = %v", w.Header())
+	}
+	var body successBody
+	if err := json.Unmarshal([]byte(gunzip(t, w.Body.Bytes())), &body); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := body.Data.(map[string]any); data["message"] != large {
+		t.Fatalf("decoded body = %+v", body)
+	}
+
+	if w := compressedGet(r, "/text", "gzip"); w.Header().Get("Content-Encoding") != "gzip" || gunzip(t, w.Body.Bytes()) != large {
+		t.Fatalf("DataRsp not compressed: %v", w.Header())
+	}
+
+	for _, tt := range []struct{ path, accept string }{
+		{"/small", "gzip"},
+		{"/large", ""},
+		{"/large", "identity"},
+		{"/large", "gzip;q=0"},
+		{"/png", "gzip"},
+		{"/off", "gzip"},
+	} {
+		w := compressedGet(r, tt.path, tt.accept)
+		if w.Header().Get("Content-Encoding") != "" || w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "i") {
+			t.Fatalf("%s (%q) should not be compressed: %v", tt.path, tt.accept, w.Header())
+		}
+	}
+}
+
+func TestCompressionCustomEncoder(t *testing.T) {
+	r := gin.New()
+	deflate := CompressionEncoder("deflate", func(w io.Writer) (CompressWriter, error) {
+		return flate.NewWriter(w, flate.BestSpeed)
+	})
+	GET(New(WithCompression(deflate, CompressionMinSize(1))).Wrap(r), "/x", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{Message: "hello"}, nil
+	})
+
+	w := compressedGet(r, "/x", "gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("registered encoder should be preferred, got %q", w.Header().Get("Content-Encoding"))
+	}
+	out, err := io.ReadAll(flate.NewReader(w.Body))
+	if err != nil || !strings.Contains(string(out), "hello") {
+		t.Fatalf("inflate = %q, %v", out, err)
+	}
+	if w := compressedGet(r, "/x", "*"); w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("wildcard = %q", w.Header().Get("Content-Encoding"))
+	}
+	if w := compressedGet(r, "/x", "gzip"); w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("gzip fallback = %q", w.Header().Get("Content-Encoding"))
+	}
+}
+
+// TestCompressionStreams 验证压缩后每条记录仍能及时到达客户端: 服务端在客户端
+// 读到上一条记录之前不会发送下一条.
+func TestCompressionStreams(t *testing.T) {
+	acked := make(chan struct{})
+	r := gin.New()
+	g := New(WithCompression()).Wrap(r)
+	JSONLines(g, http.MethodGet, "/ndjson", func(ctx context.Context, req *simpleReq, send JSONLinesSender) error {
+		for i := 0; i < 3; i++ {
+			if err := send(map[string]int{"n": i}); err != nil {
+				return err
+			}
+			select {
+			case <-acked:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	SSE(g, "/sse", func(ctx context.Context, req *simpleReq, send Sender) error {
+		for i := 0; i < 3; i++ {
+			if err := send(Event{Event: "tick", Data: i}); err != nil {
+				return err
+			}
+			select {
+			case <-acked:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	t.Run("ndjson", func(t *testing.T) {
+		// resty 解压后会删除 Content-Encoding, 这里在 transport 层记录原始响应头.
+		var encoding string
+		c := resty.New().SetTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			res, err := http.DefaultTransport.RoundTrip(req)
+			if res != nil {
+				encoding = res.Header.Get("Content-Encoding")
+			}
+			return res, err
+		}))
+		defer c.Close()
+		resp, err := c.R().SetResponseDoNotParse(true).Get(srv.URL + "/ndjson")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoding != "gzip" {
+			t.Fatalf("Content-Encoding = %q", encoding)
+		}
+		stream := NewJSONLinesStream(context.Background(), resp.Body)
+		defer stream.Close()
+		for i := 0; i < 3; i++ {
+			rec, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("record %d: %v", i, err)
+			}
+			if want := `{"n":` + string(rune('0'+i)) + `}`; string(rec) != want {
+				t.Fatalf("record %d = %s, want %s", i, rec, want)
+			}
+			acked <- struct{}{}
+		}
+		if _, err := stream.Recv(); err != io.EOF {
+			t.Fatalf("end of stream = %v", err)
+		}
+	})
+
+	t.Run("sse", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/sse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if !resp.Uncompressed {
+			t.Fatal("SSE response should be gzip encoded")
+		}
+		sc := bufio.NewScanner(resp.Body)
+		for i := 0; i < 3; {
+			if !sc.Scan() {
+				t.Fatalf("event %d: %v", i, sc.Err())
+			}
+			if strings.HasPrefix(sc.Text(), "data:") {
+				i++
+				acked <- struct{}{}
+			}
+		}
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
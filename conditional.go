@@ -0,0 +1,248 @@
+package ginx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ETagger 由 Rsp 实现时, Conditional 路由直接使用其返回值作为 ETag, 不再对响应体求摘要.
+// 未加引号的值会被加上双引号; 返回空串表示没有 ETag.
+type ETagger interface {
+	ETag() string
+}
+
+// LastModifier 由 Rsp 实现时, Conditional 路由输出 Last-Modified 并处理 If-Modified-Since.
+// 返回零值表示没有修改时间.
+type LastModifier interface {
+	LastModified() time.Time
+}
+
+// ErrPreconditionFailed 由 Precondition 返回, 渲染为 412 与 invalidArgCode.
+// 可用 errors.Is 判断.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// Conditional 为路由开启条件请求:
+//
+//   - GET / HEAD 的 200 JSON 响应带上 ETag (Rsp 实现 ETagger 时取其值, 否则为
+//     Rsp 编码后 JSON 的 SHA-256) 与 Last-Modified (Rsp 实现 LastModifier 时),
+//     命中 If-None-Match / If-Modified-Since 时返回 304 且不写响应体
+//   - PUT / PATCH / DELETE 请求必须带 If-Match 或 If-Unmodified-Since, 否则返回 428;
+//     使用 ConditionalLoad 时在 handler 之前校验, 不满足时返回 412; 否则由 handler
+//     读取当前资源后调用 Precondition 校验.
+//     成功响应的 Rsp 实现 ETagger / LastModifier 时同样输出对应响应头
+//
+// 摘要只覆盖 Rsp 本身而非外层 {code,msg,data} 包装, 因此开启 WithRequestID 不影响缓存命中.
+func Conditional() RouteOption {
+	return func(c *routeConfig) { c.conditional = true }
+}
+
+// PreconditionLoader 读取资源当前的 ETag 与修改时间, 返回的错误按 handler 错误渲染 (如 404).
+type PreconditionLoader[Req any] func(ctx context.Context, req *Req) (etag string, lastModified time.Time, err error)
+
+// ConditionalLoad 同 Conditional, 并在 PUT / PATCH / DELETE 执行 handler 之前调用 load,
+// 按 Precondition 校验 If-Match / If-Unmodified-Since, 不满足时直接返回 412, handler 不会执行.
+// Req 须与 handler 的请求类型一致, 否则注册时 panic.
+//
+//	ginx.PUT(api, "/items/:id", UpdateItem, ginx.ConditionalLoad(func(ctx context.Context, req *UpdateItemReq) (string, time.Time, error) {
+//	    item, err := repo.Get(ctx, req.ID)
+//	    if err != nil { return "", time.Time{}, err }
+//	    return item.Version, item.UpdatedAt, nil
+//	}))
+func ConditionalLoad[Req any](load PreconditionLoader[Req]) RouteOption {
+	return func(c *routeConfig) {
+		c.conditional = true
+		c.preconditionReq = reflect.TypeFor[Req]()
+		c.precondition = func(ctx context.Context, req any) error {
+			etag, lastModified, err := load(ctx, req.(*Req))
+			if err != nil {
+				return err
+			}
+			return Precondition(ctx, etag, lastModified)
+		}
+	}
+}
+
+// checkPreconditionType 在注册时校验 ConditionalLoad 的 Req 与 handler 请求类型一致.
+func checkPreconditionType(cfg resolved, reqType reflect.Type) {
+	if cfg.preconditionReq != nil && cfg.preconditionReq != reqType {
+		panic(fmt.Sprintf("ginx: ConditionalLoad loads %v, but handler request is %v", cfg.preconditionReq, reqType))
+	}
+}
+
+// Precondition 按请求的 If-Match (优先) 或 If-Unmodified-Since 校验资源当前的
+// etag / lastModified, 不满足时返回 ErrPreconditionFailed; 请求未带这两个头时返回 nil.
+// etag 可带或不带双引号, lastModified 为零值时忽略 If-Unmodified-Since.
+//
+//	item, err := repo.Get(ctx, req.ID)
+//	if err != nil { return nil, err }
+//	if err := ginx.Precondition(ctx, item.ETag(), item.UpdatedAt); err != nil {
+//	    return nil, err
+//	}
+func Precondition(ctx context.Context, etag string, lastModified time.Time) error {
+	x, ok := exchangeOf(ctx)
+	if !ok {
+		return nil
+	}
+	h := x.Request().Header
+	if im := h.Get("If-Match"); im != "" {
+		if !etagListMatch(im, quoteETag(etag), false) {
+			return ErrPreconditionFailed
+		}
+		return nil
+	}
+	if ius := h.Get("If-Unmodified-Since"); ius != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ius)
+		if err == nil && lastModified.Truncate(time.Second).After(t) {
+			return ErrPreconditionFailed
+		}
+	}
+	return nil
+}
+
+// isConditionalWrite 报告 Conditional 路由上需要前置条件的写方法.
+func isConditionalWrite(method string) bool {
+	switch method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requiresPrecondition 报告 Conditional 路由上缺少前置条件头的写请求, 需要返回 428.
+func requiresPrecondition(x exchange) bool {
+	r := x.Request()
+	return isConditionalWrite(r.Method) && r.Header.Get("If-Match") == "" && r.Header.Get("If-Unmodified-Since") == ""
+}
+
+// resourcePrecondition 在 resource 实现 ETagger / LastModifier 时按其当前值执行 Precondition.
+func resourcePrecondition(ctx context.Context, resource any) error {
+	et, hasETag := resource.(ETagger)
+	lm, hasModified := resource.(LastModifier)
+	if !hasETag && !hasModified {
+		return nil
+	}
+	var etag string
+	var modified time.Time
+	if hasETag {
+		etag = et.ETag()
+	}
+	if hasModified {
+		modified = lm.LastModified()
+	}
+	return Precondition(ctx, etag, modified)
+}
+
+// applyValidators 为成功响应设置 ETag / Last-Modified; 返回 true 表示命中缓存, 应返回 304.
+func applyValidators(x exchange, codec Codec, status int, rsp any) bool {
+	if rsp == nil {
+		return false
+	}
+	r := x.Request()
+	safe := isSafeMethod(r.Method)
+	var etag string
+	if et, ok := rsp.(ETagger); ok {
+		etag = quoteETag(et.ETag())
+	} else if safe && status == http.StatusOK {
+		if b, err := codec.Marshal(rsp); err == nil {
+			sum := sha256.Sum256(b)
+			etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+		}
+	}
+	var modified time.Time
+	if lm, ok := rsp.(LastModifier); ok {
+		modified = lm.LastModified()
+	}
+
+	h := x.Writer().Header()
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+	if !modified.IsZero() {
+		h.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if !safe || status != http.StatusOK {
+		return false
+	}
+
+	// RFC 9110 13.2.2: If-None-Match 存在时忽略 If-Modified-Since.
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagListMatch(inm, etag, true)
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+func writeNotModified(x exchange) {
+	w := x.Writer()
+	w.WriteHeader(http.StatusNotModified)
+	w.WriteHeaderNow()
+}
+
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, `W/"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagListMatch 判断 If-Match / If-None-Match 列表是否包含 etag.
+// weak 为 true 时使用弱比较 (忽略 W/ 前缀), 否则弱 ETag 永不匹配.
+func etagListMatch(list, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == etag || stripETagCoding(candidate) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// etagCodings 记录 WithCompression 使用的编码名, 用于识别 etagWithCoding 追加的后缀.
+var etagCodings sync.Map // string -> struct{}
+
+// etagWithCoding 在 ETag 的引号内追加 "-coding", 如 "abc" -> "abc-gzip".
+func etagWithCoding(etag, coding string) string {
+	if len(etag) < 2 || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return etag[:len(etag)-1] + "-" + coding + `"`
+}
+
+// stripETagCoding 去掉 etagWithCoding 追加的编码后缀, 没有时原样返回.
+func stripETagCoding(etag string) string {
+	i := strings.LastIndexByte(etag, '-')
+	if i < 0 || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	if _, ok := etagCodings.Load(etag[i+1 : len(etag)-1]); !ok {
+		return etag
+	}
+	return etag[:i] + `"`
+}
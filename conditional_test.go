@@ -0,0 +1,204 @@
+package ginx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type articleReq struct {
+	ID    string `uri:"id"`
+	Title string `json:"title"`
+}
+
+type articleRsp struct {
+	ID      string    `json:"id"`
+	Title   string    `json:"title"`
+	Version int       `json:"version"`
+	Updated time.Time `json:"-"`
+}
+
+func (a *articleRsp) ETag() string            { return fmt.Sprintf("v%d", a.Version) }
+func (a *articleRsp) LastModified() time.Time { return a.Updated }
+
+func conditionalRequest(r http.Handler, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	var body *strings.Reader
+	if method == http.MethodGet || method == http.MethodHead {
+		body = strings.NewReader("")
+	} else {
+		body = strings.NewReader(`{"title":"new"}`)
+	}
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestConditionalDigestETag(t *testing.T) {
+	var calls int
+	r := gin.New()
+	GET(New(WithRequestID(nil)).Wrap(r), "/items", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		calls++
+		return &simpleRsp{Message: "hello"}, nil
+	}, Conditional())
+
+	first := conditionalRequest(r, http.MethodGet, "/items", nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("first = %d etag %q", first.Code, etag)
+	}
+	// 请求 ID 每次不同, 但 ETag 只覆盖 Rsp 本身.
+	second := conditionalRequest(r, http.MethodGet, "/items", map[string]string{"If-None-Match": `"other", W/` + etag})
+	if second.Code != http.StatusNotModified || second.Body.Len() != 0 || second.Header().Get("ETag") != etag {
+		t.Fatalf("revalidate = %d %q etag %q", second.Code, second.Body.String(), second.Header().Get("ETag"))
+	}
+	if w := conditionalRequest(r, http.MethodGet, "/items", map[string]string{"If-None-Match": `"stale"`}); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("stale = %d", w.Code)
+	}
+	if calls != 3 {
+		t.Fatalf("calls = %d", calls)
+	}
+
+	plain := gin.New()
+	GET(New().Wrap(plain), "/items", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{}, nil
+	})
+	if w := conditionalRequest(plain, http.MethodGet, "/items", nil); w.Header().Get("ETag") != "" {
+		t.Fatal("ETag should be opt-in")
+	}
+}
+
+func TestConditionalValidatorsFromRsp(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	current := &articleRsp{ID: "1", Title: "old", Version: 3, Updated: updated}
+	r := gin.New()
+	g := New().Wrap(r)
+	Handle(g, []string{http.MethodGet, http.MethodHead}, "/articles/:id", func(ctx context.Context, req *articleReq) (*articleRsp, error) {
+		return current, nil
+	}, Conditional())
+	var updates int
+	PUT(g, "/articles/:id", func(ctx context.Context, req *articleReq) (*articleRsp, error) {
+		updates++
+		return &articleRsp{ID: req.ID, Title: req.Title, Version: current.Version + 1, Updated: updated.Add(time.Hour)}, nil
+	}, ConditionalLoad(func(ctx context.Context, req *articleReq) (string, time.Time, error) {
+		if req.ID != current.ID {
+			return "", time.Time{}, Error(4004, "article not found").Status(http.StatusNotFound)
+		}
+		return current.ETag(), current.Updated, nil
+	}))
+
+	w := conditionalRequest(r, http.MethodGet, "/articles/1", nil)
+	if w.Header().Get("ETag") != `"v3"` || w.Header().Get("Last-Modified") != updated.Format(http.TimeFormat) {
+		t.Fatalf("validators = %v", w.Header())
+	}
+	if w := conditionalRequest(r, http.MethodGet, "/articles/1", map[string]string{"If-Modified-Since": updated.Format(http.TimeFormat)}); w.Code != http.StatusNotModified {
+		t.Fatalf("If-Modified-Since = %d", w.Code)
+	}
+	if w := conditionalRequest(r, http.MethodHead, "/articles/1", map[string]string{"If-None-Match": `"v3"`}); w.Code != http.StatusNotModified {
+		t.Fatalf("HEAD If-None-Match = %d", w.Code)
+	}
+	earlier := updated.Add(-time.Hour).Format(http.TimeFormat)
+	if w := conditionalRequest(r, http.MethodGet, "/articles/1", map[string]string{"If-Modified-Since": earlier}); w.Code != http.StatusOK {
+		t.Fatalf("modified since = %d", w.Code)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+	}{
+		{"missing precondition", nil, http.StatusPreconditionRequired},
+		{"stale etag", map[string]string{"If-Match": `"v2"`}, http.StatusPreconditionFailed},
+		{"weak etag never matches", map[string]string{"If-Match": `W/"v3"`}, http.StatusPreconditionFailed},
+		{"modified since", map[string]string{"If-Unmodified-Since": earlier}, http.StatusPreconditionFailed},
+		{"matching etag", map[string]string{"If-Match": `"v1", "v3"`}, http.StatusOK},
+		{"wildcard", map[string]string{"If-Match": "*"}, http.StatusOK},
+		{"unmodified", map[string]string{"If-Unmodified-Since": updated.Format(http.TimeFormat)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := updates
+			w := conditionalRequest(r, http.MethodPut, "/articles/1", tt.header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				if !strings.Contains(w.Body.String(), `"code":1`) || updates != before {
+					t.Fatalf("body = %s, handler calls %d", w.Body.String(), updates-before)
+				}
+				return
+			}
+			if w.Header().Get("ETag") != `"v4"` {
+				t.Fatalf("updated ETag = %q", w.Header().Get("ETag"))
+			}
+		})
+	}
+	// load 的错误按 handler 错误渲染.
+	if w := conditionalRequest(r, http.MethodPut, "/articles/2", map[string]string{"If-Match": `"v3"`}); w.Code != http.StatusNotFound {
+		t.Fatalf("missing article = %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestConditionalCompressedETag(t *testing.T) {
+	current := &articleRsp{ID: "1", Title: "old", Version: 3}
+	r := gin.New()
+	g := New(WithCompression(CompressionMinSize(1))).Wrap(r)
+	GET(g, "/articles/:id", func(ctx context.Context, req *articleReq) (*articleRsp, error) {
+		return current, nil
+	}, Conditional())
+	PUT(g, "/articles/:id", func(ctx context.Context, req *articleReq) (*articleRsp, error) {
+		return current, nil
+	}, ConditionalLoad(func(ctx context.Context, req *articleReq) (string, time.Time, error) {
+		return current.ETag(), time.Time{}, nil
+	}))
+
+	gzipped := map[string]string{"Accept-Encoding": "gzip"}
+	if w := conditionalRequest(r, http.MethodGet, "/articles/1", gzipped); w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("ETag") != `"v3-gzip"` {
+		t.Fatalf("gzip = %v", w.Header())
+	}
+	if w := conditionalRequest(r, http.MethodGet, "/articles/1", nil); w.Header().Get("ETag") != `"v3"` {
+		t.Fatalf("identity = %v", w.Header())
+	}
+
+	tests := []struct {
+		method string
+		header map[string]string
+		status int
+	}{
+		{http.MethodGet, map[string]string{"Accept-Encoding": "gzip", "If-None-Match": `"v3-gzip"`}, http.StatusNotModified},
+		{http.MethodGet, map[string]string{"If-None-Match": `"v3-gzip"`}, http.StatusNotModified},
+		{http.MethodGet, map[string]string{"Accept-Encoding": "gzip", "If-None-Match": `"v3"`}, http.StatusNotModified},
+		{http.MethodGet, map[string]string{"If-None-Match": `"v3-other"`}, http.StatusOK},
+		{http.MethodPut, map[string]string{"If-Match": `"v3-gzip"`}, http.StatusOK},
+		{http.MethodPut, map[string]string{"If-Match": `"v2-gzip"`}, http.StatusPreconditionFailed},
+		{http.MethodPut, map[string]string{"If-Match": `"v3-other"`}, http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		if w := conditionalRequest(r, tt.method, "/articles/1", tt.header); w.Code != tt.status {
+			t.Fatalf("%s %v = %d, want %d", tt.method, tt.header, w.Code, tt.status)
+		}
+	}
+}
+
+func TestConditionalLoadTypeMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("mismatched ConditionalLoad should panic")
+		}
+	}()
+	PUT(New().Wrap(gin.New()), "/items/:id", func(ctx context.Context, req *articleReq) (*articleRsp, error) {
+		return nil, nil
+	}, ConditionalLoad(func(ctx context.Context, req *simpleReq) (string, time.Time, error) {
+		return "", time.Time{}, nil
+	}))
+}
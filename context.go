@@ -2,7 +2,9 @@ package ginx
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,15 +23,15 @@ type Context struct {
 	gc     *gin.Context
 }
 
-func requestContext(gc *gin.Context) context.Context {
-	if gc != nil && gc.Request != nil {
-		return gc.Request.Context()
+func requestContext(x exchange) context.Context {
+	if x != nil && x.Request() != nil {
+		return x.Request().Context()
 	}
 	return context.Background()
 }
 
-func acquireContext(gc *gin.Context) context.Context {
-	return context.WithValue(requestContext(gc), internalGinContextKey, gc)
+func acquireContext(x exchange) context.Context {
+	return context.WithValue(requestContext(x), internalGinContextKey, x)
 }
 
 func releaseContext(context.Context) {
@@ -37,7 +39,11 @@ func releaseContext(context.Context) {
 
 // newContext 直接构造, 主要用于测试. 业务路径走 acquireContext.
 func newContext(gc *gin.Context) *Context {
-	return &Context{parent: requestContext(gc), gc: gc}
+	var x exchange
+	if gc != nil {
+		x = ginExchangeOf(gc)
+	}
+	return &Context{parent: requestContext(x), gc: gc}
 }
 
 // Deadline 实现 context.Context.
@@ -52,103 +58,141 @@ func (c *Context) Err() error { return c.parent.Err() }
 // Value 实现 context.Context.
 func (c *Context) Value(key any) any {
 	if key == internalGinContextKey {
-		return c.gc
+		return exchange(ginExchangeOf(c.gc))
 	}
 	return c.parent.Value(key)
 }
 
 // GinContext 返回底层 *gin.Context 作为显式逃逸出口.
+// HTTPHandler 处理的 net/http 请求没有 *gin.Context, 返回 false.
 func GinContext(ctx context.Context) (*gin.Context, bool) {
-	if c, ok := ctx.(*Context); ok && c.gc != nil {
-		return c.gc, true
+	x, ok := exchangeOf(ctx)
+	if !ok {
+		return nil, false
 	}
-	gc, ok := ctx.Value(internalGinContextKey).(*gin.Context)
-	if !ok || gc == nil {
+	gx, ok := x.(ginExchange)
+	if !ok {
 		return nil, false
 	}
-	return gc, true
+	return gx.gin(), true
 }
 
 // Get 获取中间件设置的值.
 func Get(ctx context.Context, key string) (any, bool) {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
 		return nil, false
 	}
-	return gc.Get(key)
+	return x.Get(key)
 }
 
 // MustGet 不存在时 panic.
 func MustGet(ctx context.Context, key string) any {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
-		panic("ginx: context does not contain *gin.Context")
+		panic("ginx: context does not contain a request")
 	}
-	return gc.MustGet(key)
+	v, ok := x.Get(key)
+	if !ok {
+		panic(fmt.Sprintf("key %v does not exist", key))
+	}
+	return v
 }
 
 // Set 设置键值对.
 func Set(ctx context.Context, key string, value any) {
-	if gc, ok := GinContext(ctx); ok {
-		gc.Set(key, value)
+	if x, ok := exchangeOf(ctx); ok {
+		x.Set(key, value)
 	}
 }
 
 // GetHeader 获取请求头.
 func GetHeader(ctx context.Context, key string) string {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
 		return ""
 	}
-	return gc.GetHeader(key)
+	return x.Request().Header.Get(key)
 }
 
-// SetHeader 设置响应头.
+// SetHeader 设置响应头, value 为空时删除.
 func SetHeader(ctx context.Context, key, value string) {
-	if gc, ok := GinContext(ctx); ok {
-		gc.Header(key, value)
+	if x, ok := exchangeOf(ctx); ok {
+		setHeader(x, key, value)
 	}
 }
 
 // AddHeader 追加响应头(允许同名多值).
 func AddHeader(ctx context.Context, key, value string) {
-	if gc, ok := GinContext(ctx); ok {
-		gc.Writer.Header().Add(key, value)
+	if x, ok := exchangeOf(ctx); ok {
+		x.Writer().Header().Add(key, value)
 	}
 }
 
 // ClientIP 返回客户端 IP.
 func ClientIP(ctx context.Context) string {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
 		return ""
 	}
-	return gc.ClientIP()
+	return x.ClientIP()
 }
 
 // Request 返回底层 *http.Request.
 func Request(ctx context.Context) *http.Request {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
 		return nil
 	}
-	return gc.Request
+	return x.Request()
 }
 
 // Cookie 获取 cookie.
 func Cookie(ctx context.Context, name string) (string, error) {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
 		return "", http.ErrNoCookie
 	}
-	return gc.Cookie(name)
+	cookie, err := x.Request().Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	val, _ := url.QueryUnescape(cookie.Value)
+	return val, nil
 }
 
 // SetCookie 设置 cookie.
 func SetCookie(ctx context.Context, name, value string, maxAge int, path, domain string, secure, httpOnly bool) {
 	if gc, ok := GinContext(ctx); ok {
+		// 沿用 gin.Context.SetSameSite 的设置.
 		gc.SetCookie(name, value, maxAge, path, domain, secure, httpOnly)
+		return
+	}
+	x, ok := exchangeOf(ctx)
+	if !ok {
+		return
+	}
+	if path == "" {
+		path = "/"
+	}
+	http.SetCookie(x.Writer(), &http.Cookie{
+		Name:     name,
+		Value:    url.QueryEscape(value),
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   domain,
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	})
+}
+
+// setHeader 与 gin.Context.Header 一致: value 为空时删除该响应头.
+func setHeader(x exchange, key, value string) {
+	if value == "" {
+		x.Writer().Header().Del(key)
+		return
 	}
+	x.Writer().Header().Set(key, value)
 }
 
 // GetValue 泛型获取中间件值, 类型断言失败返回零值+false.
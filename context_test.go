@@ -66,7 +66,7 @@ func TestGinContextFromPlainContextWithStoredGinContext(t *testing.T) {
 func TestAcquiredContextSurvivesRelease(t *testing.T) {
 	w := httptest.NewRecorder()
 	gc, _ := gin.CreateTestContext(w)
-	ctx := acquireContext(gc)
+	ctx := acquireContext(ginExchangeOf(gc))
 	derived := context.WithValue(ctx, "trace_id", "t-1")
 
 	releaseContext(ctx)
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,17 +17,29 @@ type Engine struct {
 	dataWrap             bool
 	invalidArgCode       int
 	internalErrorCode    int
+	timeoutCode          int
+	timeoutStatus        int
+	timeout              time.Duration
 	jsonDecoderUseNumber bool
 	strictJSONBody       bool
+	disallowUnknown      bool
 	exposeInternalError  bool
 	internalErrorMessage string
+	requestIDGen         func() string // nil 表示未开启请求 ID
+	compression          *compressionConfig
+	limits               requestLimits
+	pageLimits           pageLimits
 
 	errorHandler      ErrorHandler
 	validationHandler ValidationErrorHandler
 	successHandler    SuccessHandler
 	jsonRenderer      JSONRenderer
+	codec             Codec
+	validator         *engineValidator
+	bindingSources    map[string]BindingSource // 写时复制, 见 RegisterBindingSource
 
 	interceptors []Interceptor
+	observers    []Observer
 	onRegister   []RegisterHook
 }
 
@@ -35,7 +48,7 @@ type Engine struct {
 // 返回 httpStatus<=0 表示沿用默认处理.
 type ErrorHandler func(ctx context.Context, err error) (httpStatus int, body any)
 
-// ValidationErrorHandler 专门处理 validator 校验错误, 以便脱敏/国际化.
+// ValidationErrorHandler 专门处理 validator 校验错误与 RequestValidator 返回的错误, 以便脱敏/国际化.
 // 返回 httpStatus<=0 表示沿用默认处理.
 type ValidationErrorHandler func(ctx context.Context, err error) (httpStatus int, body any)
 
@@ -43,7 +56,8 @@ type ValidationErrorHandler func(ctx context.Context, err error) (httpStatus int
 // 仅在 dataWrap=true 时生效; 返回 httpStatus<=0 时视为 200.
 type SuccessHandler func(ctx context.Context, data any) (httpStatus int, body any)
 
-// JSONRenderer 负责把 JSON 类型响应写到客户端.
+// JSONRenderer 负责把 JSON 类型响应写到客户端. 未设置时按 Engine 的 Codec 编码;
+// HTTPHandler 处理的请求没有 *gin.Context, 总是按 Codec 编码.
 type JSONRenderer func(c *gin.Context, status int, body any)
 
 // Interceptor 在 handler 真正执行前后插入逻辑. next() 执行下一层.
@@ -51,12 +65,40 @@ type JSONRenderer func(c *gin.Context, status int, body any)
 // 注意: next 在单次请求中只能调用一次, 重复调用会 panic.
 type Interceptor func(ctx context.Context, req any, next func() (any, error)) (any, error)
 
+// Observer 观察单次请求的完整生命周期, 包括 Interceptor 看不到的绑定/校验失败.
+// 它在绑定之前调用, ctx 中可用 GinContext / GetHeader 读取请求;
+// 返回的 context 会替换请求 context (例如携带 tracing span), 返回 nil 表示不替换;
+// 返回的 done 在响应写出之后调用. Observer 不能改变响应, 适合 tracing / 访问日志.
+type Observer func(ctx context.Context, info RegisterInfo) (context.Context, func(Outcome))
+
+// ErrorStage 标识请求在哪个阶段失败.
+type ErrorStage string
+
+const (
+	StageNone       ErrorStage = ""           // 成功
+	StageBinding    ErrorStage = "binding"    // 请求绑定失败
+	StageValidation ErrorStage = "validation" // binding tag 校验失败
+	StageHandler    ErrorStage = "handler"    // handler 或拦截器返回错误
+)
+
+// Outcome 是单次请求的处理结果摘要, 在响应写出后交给 Observer.
+type Outcome struct {
+	Status int        // 实际写出的 HTTP 状态码
+	Code   int        // 业务 code, 成功时为 0
+	Err    error      // 失败原因, 成功时为 nil
+	Stage  ErrorStage // 失败阶段
+	Events int        // SSE / JSON Lines 已写出的记录数
+	Req    any        // 绑定后的 *Req, 绑定失败时可能只填充了一部分
+	Rsp    any        // handler 返回的 *Rsp
+}
+
 // RegisterInfo 路由注册时的元信息, 供外部生成 OpenAPI 等.
 type RegisterInfo struct {
-	Method  string
-	Path    string
-	ReqType reflect.Type
-	RspType reflect.Type
+	Method      string
+	Path        string
+	OperationID string // 由 OperationID 路由选项设置, codegen 会填入 OpenAPI operationId
+	ReqType     reflect.Type
+	RspType     reflect.Type
 }
 
 // RegisterHook 每次路由注册时触发.
@@ -71,10 +113,12 @@ func New(opts ...EngineOption) *Engine {
 		dataWrap:             true,
 		invalidArgCode:       1,
 		internalErrorCode:    2,
+		timeoutCode:          3,
+		timeoutStatus:        http.StatusGatewayTimeout,
+		pageLimits:           pageLimits{defaultLimit: 20, maxLimit: 100},
 		exposeInternalError:  true,
 		internalErrorMessage: http.StatusText(http.StatusInternalServerError),
 		successHandler:       defaultSuccessHandler,
-		jsonRenderer:         defaultJSONRenderer,
 	}
 	for _, opt := range opts {
 		opt(e)
@@ -102,7 +146,7 @@ func WithErrorHandler(h ErrorHandler) EngineOption {
 	return func(e *Engine) { e.errorHandler = h }
 }
 
-// WithValidationErrorHandler 专门处理 validator 产生的校验错误.
+// WithValidationErrorHandler 专门处理 validator 产生的校验错误与 Req.Validate 返回的错误.
 func WithValidationErrorHandler(h ValidationErrorHandler) EngineOption {
 	return func(e *Engine) { e.validationHandler = h }
 }
@@ -112,7 +156,7 @@ func WithSuccessHandler(h SuccessHandler) EngineOption {
 	return func(e *Engine) { e.successHandler = h }
 }
 
-// WithJSONRenderer 自定义 JSON 响应写出方式.
+// WithJSONRenderer 自定义 gin 路由的 JSON 响应写出方式, nil 恢复为按 Codec 编码.
 func WithJSONRenderer(r JSONRenderer) EngineOption {
 	return func(e *Engine) { e.jsonRenderer = r }
 }
@@ -122,6 +166,23 @@ func WithInterceptor(i Interceptor) EngineOption {
 	return func(e *Engine) { e.interceptors = append(e.interceptors, i) }
 }
 
+// WithObserver 追加一个 Observer, 多次调用按注册顺序嵌套(最先注册的最先开始、最后结束).
+func WithObserver(o Observer) EngineOption {
+	return func(e *Engine) { e.observers = append(e.observers, o) }
+}
+
+// WithRequestID 开启请求 ID: 优先取请求头 X-Request-ID, 其次取 traceparent 的 trace-id,
+// 都没有时调用 gen 生成 (nil 使用随机 32 位 hex). 请求 ID 写入 context (见 RequestID),
+// 回显到 X-Request-ID 响应头, 并作为 request_id 字段出现在默认的成功 / 错误响应体中.
+func WithRequestID(gen func() string) EngineOption {
+	return func(e *Engine) {
+		if gen == nil {
+			gen = defaultRequestIDGenerator
+		}
+		e.requestIDGen = gen
+	}
+}
+
 // WithOnRegister 注册路由时触发, 可用于生成 OpenAPI.
 func WithOnRegister(h RegisterHook) EngineOption {
 	return func(e *Engine) { e.onRegister = append(e.onRegister, h) }
@@ -209,6 +270,9 @@ func SetJsonDecoderUseNumber(b bool) { Configure(WithJsonDecoderUseNumber(b)) }
 // SetStrictJSONBody 便捷调用 Configure(WithStrictJSONBody(b)).
 func SetStrictJSONBody(b bool) { Configure(WithStrictJSONBody(b)) }
 
+// SetDisallowUnknownFields 便捷调用 Configure(WithDisallowUnknownFields(b)).
+func SetDisallowUnknownFields(b bool) { Configure(WithDisallowUnknownFields(b)) }
+
 // SetExposeInternalError 便捷调用 Configure(WithExposeInternalError(b)).
 func SetExposeInternalError(b bool) { Configure(WithExposeInternalError(b)) }
 
@@ -224,7 +288,30 @@ type routeConfig struct {
 	dataWrap      *bool // nil 表示沿用 Engine
 	alwaysOK      bool
 	successStatus int
+	operationID   string
+	idempotency   IdempotencyStore
+	conditional   bool
+	fieldMask     bool
+	noCompression bool
+	streaming     bool
+	timeout       *time.Duration // nil 表示沿用 Engine
+	idleTimeout   time.Duration
 	interceptors  []Interceptor
+	observers     []Observer
+
+	precondition    func(ctx context.Context, req any) error // ConditionalLoad
+	preconditionReq reflect.Type
+
+	disallowUnknown *bool // nil 表示沿用 Engine
+	patchDocument   bool  // body 是 patch 文档, 不绑定到 Req
+	rawBodyBytes    int64 // KeepRawBody 的上限, 0 表示不保留原始请求体
+	rawBodyChecks   []func(ctx context.Context, body []byte) error
+
+	// 请求体限制, nil 表示沿用 Engine
+	maxBodyBytes          *int64
+	maxJSONDepth          *int
+	maxMultipartFiles     *int
+	maxMultipartPartBytes *int64
 }
 
 // WrapData 强制该路由走 {code,msg,data} 包装.
@@ -258,6 +345,16 @@ func RouteInterceptor(i Interceptor) RouteOption {
 	return func(c *routeConfig) { c.interceptors = append(c.interceptors, i) }
 }
 
+// RouteObserver 追加路由级 Observer, 位于 Engine Observer 之后(更内层).
+func RouteObserver(o Observer) RouteOption {
+	return func(c *routeConfig) { c.observers = append(c.observers, o) }
+}
+
+// OperationID 为路由标注 OpenAPI operationId, 会出现在 RegisterInfo 中供 Observer/Hook 使用.
+func OperationID(id string) RouteOption {
+	return func(c *routeConfig) { c.operationID = id }
+}
+
 func (e *Engine) resolveRoute(opts []RouteOption) resolved {
 	rc := routeConfig{}
 	for _, opt := range opts {
@@ -269,25 +366,75 @@ func (e *Engine) resolveRoute(opts []RouteOption) resolved {
 		dataWrap:             e.dataWrap,
 		alwaysOK:             rc.alwaysOK,
 		successStatus:        rc.successStatus,
+		route:                RegisterInfo{OperationID: rc.operationID},
+		idempotency:          rc.idempotency,
+		conditional:          rc.conditional,
+		precondition:         rc.precondition,
+		preconditionReq:      rc.preconditionReq,
+		fieldMask:            rc.fieldMask,
 		invalidArgCode:       e.invalidArgCode,
 		internalErrorCode:    e.internalErrorCode,
+		timeoutCode:          e.timeoutCode,
+		timeoutStatus:        normalizeTimeoutStatus(e.timeoutStatus),
 		jsonDecoderUseNumber: e.jsonDecoderUseNumber,
 		strictJSONBody:       e.strictJSONBody,
+		disallowUnknown:      e.disallowUnknown,
+		patchDocument:        rc.patchDocument,
+		rawBodyBytes:         rc.rawBodyBytes,
+		rawBodyChecks:        rc.rawBodyChecks,
 		exposeInternalError:  e.exposeInternalError,
 		internalErrorMessage: e.internalErrorMessage,
+		requestIDGen:         e.requestIDGen,
+		pageLimits:           e.pageLimits,
 		errorHandler:         e.errorHandler,
 		validationHandler:    e.validationHandler,
 		successHandler:       e.successHandler,
 		jsonRenderer:         e.jsonRenderer,
+		codec:                e.codec,
+		validator:            e.validator,
+		bindingSources:       e.bindingSources,
 	}
 	if rc.dataWrap != nil {
 		r.dataWrap = *rc.dataWrap
 	}
+	if rc.disallowUnknown != nil {
+		r.disallowUnknown = *rc.disallowUnknown
+	}
+	if len(rc.rawBodyChecks) > 0 && r.rawBodyBytes == 0 {
+		r.rawBodyBytes = defaultRawBodyBytes
+	}
+	if !rc.noCompression {
+		r.compression = e.compression
+	}
+	if rc.streaming {
+		r.idleTimeout = rc.idleTimeout
+	} else {
+		r.timeout = e.timeout
+		if rc.timeout != nil {
+			r.timeout = *rc.timeout
+		}
+	}
+	r.limits = e.limits
+	if rc.maxBodyBytes != nil {
+		r.limits.maxBodyBytes = *rc.maxBodyBytes
+	}
+	if rc.maxJSONDepth != nil {
+		r.limits.maxJSONDepth = *rc.maxJSONDepth
+	}
+	if rc.maxMultipartFiles != nil {
+		r.limits.maxMultipartFiles = *rc.maxMultipartFiles
+		r.limits.maxMultipartPartBytes = *rc.maxMultipartPartBytes
+	}
 	if n := len(e.interceptors) + len(rc.interceptors); n > 0 {
 		r.interceptors = make([]Interceptor, 0, n)
 		r.interceptors = append(r.interceptors, e.interceptors...)
 		r.interceptors = append(r.interceptors, rc.interceptors...)
 	}
+	if n := len(e.observers) + len(rc.observers); n > 0 {
+		r.observers = make([]Observer, 0, n)
+		r.observers = append(r.observers, e.observers...)
+		r.observers = append(r.observers, rc.observers...)
+	}
 	return r
 }
 
@@ -298,15 +445,39 @@ type resolved struct {
 	successStatus        int
 	invalidArgCode       int
 	internalErrorCode    int
+	timeoutCode          int
+	timeoutStatus        int
+	timeout              time.Duration
+	idleTimeout          time.Duration
 	jsonDecoderUseNumber bool
 	strictJSONBody       bool
+	disallowUnknown      bool
+	patchDocument        bool
+	rawBodyBytes         int64
+	rawBodyChecks        []func(ctx context.Context, body []byte) error // VerifyRawBody, 绑定前执行
 	exposeInternalError  bool
 	internalErrorMessage string
+	requestIDGen         func() string
+	compression          *compressionConfig
+	limits               requestLimits
+	pageLimits           pageLimits
 	errorHandler         ErrorHandler
 	validationHandler    ValidationErrorHandler
 	successHandler       SuccessHandler
-	jsonRenderer         JSONRenderer
+	jsonRenderer         JSONRenderer // nil 表示按 codec 编码
+	codec                Codec
+	validator            *engineValidator
+	bindingSources       map[string]BindingSource
+	idempotency          IdempotencyStore
+	conditional          bool
+	precondition         func(ctx context.Context, req any) error // ConditionalLoad, req 为 *Req
+	preconditionReq      reflect.Type
+	fieldMask            bool
+	fieldTree            *fieldNode // 由 register 按 Rsp 计算
 	interceptors         []Interceptor
+	observers            []Observer
+	route                RegisterInfo // 由 register 填充, 供 Observer 使用
+	routeFromRequest     bool         // NewHTTPHandler: Observer 的 Method / Path 取自本次请求
 }
 
 // engineOf 从注册入参解析 Engine 与底层 gin.IRoutes.
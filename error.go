@@ -8,6 +8,8 @@ import "fmt"
 type ErrWrap struct {
 	Code int    `json:"code"`
 	Msg  string `json:"msg"`
+	// RequestID 在开启 WithRequestID 时由服务端渲染, 客户端 ParseResponse 会回填.
+	RequestID string `json:"request_id,omitempty"`
 
 	HttpCode int `json:"-"`
 }
@@ -0,0 +1,270 @@
+package ginx
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+)
+
+// exchange 是单次请求的请求/响应抽象, 绑定、校验与写出路径只依赖它.
+// gin 路由使用 *gin.Context (ginExchange), HTTPHandler 使用 net/http 的
+// ResponseWriter 与 *http.Request (httpExchange).
+type exchange interface {
+	Request() *http.Request
+	SetRequest(r *http.Request)
+	Writer() responseWriter
+	// SetWriter 替换响应 writer, 用于压缩与幂等回放等需要包装写出的场景.
+	SetWriter(w responseWriter)
+	// Param 返回路径参数, 对应 uri tag.
+	Param(key string) (string, bool)
+	Query(key string) ([]string, bool)
+	Get(key any) (any, bool)
+	Set(key, value any)
+	Abort()
+	IsAborted() bool
+	// Error 记录写出响应之后发生的错误, 例如已开始的流中断.
+	Error(err error)
+	ClientIP() string
+}
+
+// responseWriter 是写出路径使用的 writer: 状态码延迟到首次写出 body 或
+// WriteHeaderNow 时才发送, 在此之前仍可修改. gin.ResponseWriter 满足该接口.
+type responseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	Status() int
+	Written() bool
+	WriteHeaderNow()
+}
+
+// exchangeOf 返回 ctx 所属请求的 exchange.
+func exchangeOf(ctx context.Context) (exchange, bool) {
+	if c, ok := ctx.(*Context); ok && c.gc != nil {
+		return ginExchange{c.gc}, true
+	}
+	switch v := ctx.Value(internalGinContextKey).(type) {
+	case exchange:
+		return v, true
+	case *gin.Context:
+		// 兼容直接把 *gin.Context 存入 context 的调用方.
+		return ginExchange{v}, v != nil
+	}
+	return nil, false
+}
+
+// contentType 返回去掉参数后的请求 Content-Type, 与 gin.Context.ContentType 一致.
+func contentType(r *http.Request) string {
+	ct := r.Header.Get("Content-Type")
+	if i := strings.IndexAny(ct, " ;"); i >= 0 {
+		return ct[:i]
+	}
+	return ct
+}
+
+// renderTo 与 gin.Context.Render 一致: 不允许 body 的状态码只写出响应头,
+// 渲染失败时记录错误并中止.
+func renderTo(x exchange, status int, r render.Render) {
+	w := x.Writer()
+	w.WriteHeader(status)
+	if !bodyAllowedForStatus(status) {
+		r.WriteContentType(w)
+		w.WriteHeaderNow()
+		return
+	}
+	if err := r.Render(w); err != nil {
+		x.Error(err)
+		x.Abort()
+	}
+}
+
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= http.StatusContinue && status < http.StatusOK:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// --- gin ---
+
+// ginExchange 以 *gin.Context 实现 exchange. 只含一个指针, 转为接口时不额外分配.
+type ginExchange struct{ gc *gin.Context }
+
+func ginExchangeOf(gc *gin.Context) ginExchange { return ginExchange{gc} }
+
+func (x ginExchange) gin() *gin.Context { return x.gc }
+
+func (x ginExchange) Request() *http.Request     { return x.gin().Request }
+func (x ginExchange) SetRequest(r *http.Request) { x.gin().Request = r }
+
+func (x ginExchange) Writer() responseWriter {
+	if w, ok := x.gin().Writer.(*ginWriter); ok {
+		return w.responseWriter
+	}
+	return x.gin().Writer
+}
+
+func (x ginExchange) SetWriter(w responseWriter) {
+	gc := x.gin()
+	if gw, ok := w.(gin.ResponseWriter); ok {
+		gc.Writer = gw
+		return
+	}
+	gc.Writer = &ginWriter{responseWriter: w, orig: gc.Writer}
+}
+
+func (x ginExchange) Param(key string) (string, bool) {
+	for _, p := range x.gin().Params {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+func (x ginExchange) Query(key string) ([]string, bool) { return x.gin().GetQueryArray(key) }
+func (x ginExchange) Get(key any) (any, bool)           { return x.gin().Get(key) }
+func (x ginExchange) Set(key, value any)                { x.gin().Set(key, value) }
+func (x ginExchange) Abort()                            { x.gin().Abort() }
+func (x ginExchange) IsAborted() bool                   { return x.gin().IsAborted() }
+func (x ginExchange) Error(err error)                   { _ = x.gin().Error(err) }
+func (x ginExchange) ClientIP() string                  { return x.gin().ClientIP() }
+
+// ginWriter 把包装后的 responseWriter 补全为 gin.ResponseWriter, 使 gin 中间件与
+// Response.WriteTo 也经过包装写出; 包装未实现的方法交给原 writer.
+type ginWriter struct {
+	responseWriter
+	orig gin.ResponseWriter
+}
+
+func (w *ginWriter) Size() int { return w.orig.Size() }
+
+func (w *ginWriter) WriteString(s string) (int, error) { return io.WriteString(w.responseWriter, s) }
+
+func (w *ginWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.responseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return w.orig.Hijack()
+}
+
+func (w *ginWriter) CloseNotify() <-chan bool { return w.orig.CloseNotify() }
+
+func (w *ginWriter) Pusher() http.Pusher { return w.orig.Pusher() }
+
+// --- net/http ---
+
+// httpExchange 以 net/http 的 ResponseWriter 与 *http.Request 实现 exchange.
+type httpExchange struct {
+	w       responseWriter
+	r       *http.Request
+	query   url.Values
+	keys    map[any]any
+	aborted bool
+}
+
+func newHTTPExchange(w http.ResponseWriter, r *http.Request) *httpExchange {
+	return &httpExchange{w: &httpWriter{ResponseWriter: w, status: http.StatusOK, size: -1}, r: r}
+}
+
+func (x *httpExchange) Request() *http.Request     { return x.r }
+func (x *httpExchange) SetRequest(r *http.Request) { x.r = r }
+func (x *httpExchange) Writer() responseWriter     { return x.w }
+func (x *httpExchange) SetWriter(w responseWriter) { x.w = w }
+func (x *httpExchange) Abort()                     { x.aborted = true }
+func (x *httpExchange) IsAborted() bool            { return x.aborted }
+func (x *httpExchange) Error(error)                {}
+func (x *httpExchange) Param(key string) (string, bool) {
+	v := x.r.PathValue(key)
+	return v, v != ""
+}
+
+func (x *httpExchange) Query(key string) ([]string, bool) {
+	if x.query == nil {
+		x.query = x.r.URL.Query()
+	}
+	vs, ok := x.query[key]
+	return vs, ok
+}
+
+func (x *httpExchange) Get(key any) (any, bool) {
+	v, ok := x.keys[key]
+	return v, ok
+}
+
+func (x *httpExchange) Set(key, value any) {
+	if x.keys == nil {
+		x.keys = make(map[any]any)
+	}
+	x.keys[key] = value
+}
+
+// ClientIP 返回 RemoteAddr 的主机部分; 需要信任代理头时由外层中间件改写 RemoteAddr.
+func (x *httpExchange) ClientIP() string {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(x.r.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(x.r.RemoteAddr)
+	}
+	return host
+}
+
+var errHijackWritten = errors.New("ginx: response body already written")
+
+// httpWriter 为 http.ResponseWriter 记录状态码与写出字节数, 状态码延迟发送.
+type httpWriter struct {
+	http.ResponseWriter
+	status int
+	size   int // -1 表示尚未写出
+}
+
+func (w *httpWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func (w *httpWriter) WriteHeader(code int) {
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+func (w *httpWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *httpWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *httpWriter) Flush() {
+	w.WriteHeaderNow()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *httpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if w.size > 0 {
+		return nil, nil, errHijackWritten
+	}
+	if w.size < 0 {
+		w.size = 0
+	}
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *httpWriter) Status() int   { return w.status }
+func (w *httpWriter) Written() bool { return w.size >= 0 }
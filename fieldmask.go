@@ -0,0 +1,198 @@
+package ginx
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FieldMask 为路由开启稀疏字段: 客户端通过 fields query 参数 (逗号分隔,
+// 嵌套字段用点号, 如 ?fields=id,profile.avatar) 只取部分字段. 路径按 Rsp 的
+// JSON 字段树校验, 未知路径返回 400; 裁剪只作用于 Rsp, 不影响外层包装.
+// 未传 fields 时响应不变.
+func FieldMask() RouteOption {
+	return func(c *routeConfig) { c.fieldMask = true }
+}
+
+// fieldNode 是 Rsp 类型的 JSON 字段树.
+type fieldNode struct {
+	children map[string]*fieldNode
+	open     bool // map / interface 等结构未知的值, 允许任意子路径
+}
+
+var fieldTreeCache sync.Map // reflect.Type -> *fieldNode
+
+// fieldTreeOf 计算并缓存 t 的字段树.
+func fieldTreeOf(t reflect.Type) *fieldNode {
+	if cached, ok := fieldTreeCache.Load(t); ok {
+		return cached.(*fieldNode)
+	}
+	node := buildFieldTree(t, map[reflect.Type]*fieldNode{})
+	fieldTreeCache.Store(t, node)
+	return node
+}
+
+func buildFieldTree(t reflect.Type, seen map[reflect.Type]*fieldNode) *fieldNode {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// 自定义编码的类型 (如 time.Time) 视为叶子.
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &fieldNode{}
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &fieldNode{}
+		}
+		return buildFieldTree(t.Elem(), seen)
+	case reflect.Map, reflect.Interface:
+		return &fieldNode{open: true}
+	case reflect.Struct:
+	default:
+		return &fieldNode{}
+	}
+	if node, ok := seen[t]; ok {
+		return node
+	}
+	node := &fieldNode{children: make(map[string]*fieldNode)}
+	seen[t] = node
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			// 嵌入结构体的字段提升到当前层, 外层同名字段优先.
+			for k, v := range buildFieldTree(ft, seen).children {
+				if _, ok := node.children[k]; !ok {
+					node.children[k] = v
+				}
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		node.children[name] = buildFieldTree(f.Type, seen)
+	}
+	return node
+}
+
+// fieldMask 是请求选择的字段, nil 子节点表示保留整个子树.
+type fieldMask map[string]fieldMask
+
+type fieldMaskKey struct{}
+
+// selectFields 解析并校验 fields 参数, 结果记录到请求上.
+func selectFields(x exchange, tree *fieldNode) error {
+	var mask fieldMask
+	fields, _ := x.Query("fields")
+	for _, v := range fields {
+		for _, path := range strings.Split(v, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			if !tree.has(path) {
+				return fmt.Errorf("unknown field %q", path)
+			}
+			if mask == nil {
+				mask = make(fieldMask)
+			}
+			mask.add(strings.Split(path, "."))
+		}
+	}
+	if mask != nil {
+		x.Set(fieldMaskKey{}, mask)
+	}
+	return nil
+}
+
+func (n *fieldNode) has(path string) bool {
+	for _, part := range strings.Split(path, ".") {
+		if part == "" {
+			return false
+		}
+		if n.open {
+			return true
+		}
+		child, ok := n.children[part]
+		if !ok {
+			return false
+		}
+		n = child
+	}
+	return true
+}
+
+func (m fieldMask) add(parts []string) {
+	sub, ok := m[parts[0]]
+	switch {
+	case len(parts) == 1:
+		m[parts[0]] = nil
+	case ok && sub == nil:
+		// 已选择整个子树.
+	default:
+		if sub == nil {
+			sub = make(fieldMask)
+			m[parts[0]] = sub
+		}
+		sub.add(parts[1:])
+	}
+}
+
+// maskFields 按请求的 fields 裁剪 rsp; 未选择字段或编码失败时原样返回.
+func maskFields(x exchange, codec Codec, rsp any) any {
+	v, ok := x.Get(fieldMaskKey{})
+	if !ok || rsp == nil {
+		return rsp
+	}
+	b, err := codec.Marshal(rsp)
+	if err != nil {
+		return rsp
+	}
+	dec := codec.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return rsp
+	}
+	return v.(fieldMask).prune(doc)
+}
+
+func (m fieldMask) prune(v any) any {
+	switch x := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(m))
+		for k, sub := range m {
+			val, ok := x[k]
+			if !ok {
+				continue
+			}
+			if sub == nil {
+				out[k] = val
+			} else {
+				out[k] = sub.prune(val)
+			}
+		}
+		return out
+	case []any:
+		for i := range x {
+			x[i] = m.prune(x[i])
+		}
+		return x
+	}
+	return v
+}
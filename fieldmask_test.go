@@ -0,0 +1,73 @@
+package ginx
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type maskProfile struct {
+	Avatar string `json:"avatar"`
+	Bio    string `json:"bio"`
+}
+
+type maskAudit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type maskUser struct {
+	maskAudit
+	ID      int               `json:"id"`
+	Name    string            `json:"name"`
+	Secret  string            `json:"-"`
+	Profile *maskProfile      `json:"profile"`
+	Tags    []maskProfile     `json:"tags"`
+	Meta    map[string]string `json:"meta"`
+	Friends []*maskUser       `json:"friends,omitempty"`
+}
+
+func TestFieldMask(t *testing.T) {
+	user := &maskUser{
+		maskAudit: maskAudit{CreatedAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		ID:        7,
+		Name:      "ann",
+		Profile:   &maskProfile{Avatar: "a.png", Bio: "hi"},
+		Tags:      []maskProfile{{Avatar: "t1", Bio: "x"}, {Avatar: "t2", Bio: "y"}},
+		Meta:      map[string]string{"team": "core", "tz": "UTC"},
+		Friends:   []*maskUser{{ID: 8, Name: "bob"}},
+	}
+	r := gin.New()
+	g := New().Wrap(r)
+	GET(g, "/user", func(ctx context.Context, req *simpleReq) (*maskUser, error) { return user, nil }, FieldMask())
+	GET(g, "/users", func(ctx context.Context, req *simpleReq) (*Page[maskUser], error) {
+		return NewCursorPage([]maskUser{*user}, "next"), nil
+	}, FieldMask())
+	GET(g, "/plain", func(ctx context.Context, req *simpleReq) (*maskUser, error) { return user, nil })
+
+	tests := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"/user?fields=id,profile.avatar", http.StatusOK, `"data":{"id":7,"profile":{"avatar":"a.png"}}`},
+		{"/user?fields=tags.bio&fields=created_at", http.StatusOK, `"data":{"created_at":"2026-01-02T00:00:00Z","tags":[{"bio":"x"},{"bio":"y"}]}`},
+		{"/user?fields=profile,profile.bio,meta.team", http.StatusOK, `"data":{"meta":{"team":"core"},"profile":{"avatar":"a.png","bio":"hi"}}`},
+		{"/user?fields=friends.friends.name", http.StatusOK, `"data":{"friends":[{}]}`},
+		{"/user", http.StatusOK, `"bio":"hi"`},
+		{"/user?fields=Secret", http.StatusBadRequest, `"code":1,"msg":"unknown field \"Secret\""`},
+		{"/user?fields=id.value", http.StatusBadRequest, `unknown field \"id.value\"`},
+		{"/user?fields=profile.", http.StatusBadRequest, `unknown field`},
+		{"/users?fields=items.name,next_cursor", http.StatusOK, `"data":{"items":[{"name":"ann"}],"next_cursor":"next"}`},
+		{"/plain?fields=nope", http.StatusOK, `"name":"ann"`},
+	}
+	for _, tt := range tests {
+		w := pageGet(r, tt.target)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Fatalf("%s = %d %s, want %d %s", tt.target, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+}
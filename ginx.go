@@ -2,7 +2,6 @@ package ginx
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"net/http"
 
@@ -10,7 +9,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-var errResponseHandled = errors.New("ginx: response handled")
+var (
+	errResponseHandled = errors.New("ginx: response handled")
+	errNoExchange      = errors.New("ginx: context does not carry a request")
+)
 
 // HandlerFunc RPC 风格 handler 签名.
 type HandlerFunc[Req, Rsp any] func(ctx context.Context, req *Req) (*Rsp, error)
@@ -39,17 +41,14 @@ type SSEHandler[Req any] func(ctx context.Context, req *Req, send Sender) error
 
 // successBody 成功响应在 dataWrap=true 时使用的标准包装体.
 type successBody struct {
-	Code int    `json:"code"`
-	Msg  string `json:"msg"`
-	Data any    `json:"data"`
+	Code      int    `json:"code"`
+	Msg       string `json:"msg"`
+	Data      any    `json:"data"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 func defaultSuccessHandler(ctx context.Context, data any) (int, any) {
-	return http.StatusOK, successBody{Code: 0, Msg: "", Data: data}
-}
-
-func defaultJSONRenderer(c *gin.Context, status int, body any) {
-	c.JSON(status, body)
+	return http.StatusOK, successBody{Code: 0, Msg: "", Data: data, RequestID: RequestID(ctx)}
 }
 
 // --- Public registration API ---
@@ -95,31 +94,36 @@ func SSE[Req any](r gin.IRoutes, path string, fn SSEHandler[Req], opts ...RouteO
 		SetHeader(ctx, "Content-Type", "text/event-stream")
 		SetHeader(ctx, "Cache-Control", "no-cache")
 		SetHeader(ctx, "Connection", "keep-alive")
-		gc, ok := GinContext(ctx)
+		x, ok := exchangeOf(ctx)
 		if !ok {
-			return nil, errors.New("ginx: context does not contain *gin.Context")
+			return nil, errNoExchange
 		}
-		sender := newSSESender(gc)
+		sender := newSSESender(x)
 		if err := fn(ctx, req, sender); err != nil {
 			return nil, err
 		}
 		return nil, errResponseHandled
-	}, append([]RouteOption{NoDataWrap()}, opts...)...)
+	}, append([]RouteOption{NoDataWrap(), streamingRoute()}, opts...)...)
 }
 
-func newSSESender(c *gin.Context) Sender {
+func newSSESender(x exchange) Sender {
 	return func(evt Event) error {
-		if err := sse.Encode(c.Writer, sse.Event{
+		data, err := sseData(codecOf(x), evt.Data)
+		if err != nil {
+			return err
+		}
+		w := x.Writer()
+		if err := sse.Encode(w, sse.Event{
 			Id:    evt.ID,
 			Event: evt.Event,
-			Data:  evt.Data,
+			Data:  data,
 			Retry: evt.Retry,
 		}); err != nil {
 			return err
 		}
-		if flusher, ok := c.Writer.(http.Flusher); ok {
-			flusher.Flush()
-		}
+		w.Flush()
+		observationOf(x).sent()
+		touchIdle(x)
 		return nil
 	}
 }
@@ -148,58 +152,62 @@ type JSONLinesHandler[Req any] func(ctx context.Context, req *Req, send JSONLine
 // method 显式作为参数 (不同于必须 GET 的 SSE): NDJSON 惯例是 POST, 但并不强制.
 func JSONLines[Req any](r gin.IRoutes, method, path string, fn JSONLinesHandler[Req], opts ...RouteOption) {
 	register(r, method, path, func(ctx context.Context, req *Req) (*struct{}, error) {
-		gc, ok := GinContext(ctx)
+		x, ok := exchangeOf(ctx)
 		if !ok {
-			return nil, errors.New("ginx: context does not contain *gin.Context")
+			return nil, errNoExchange
 		}
-		sender := newJSONLinesSender(gc)
+		sender := newJSONLinesSender(x)
 		if err := fn(ctx, req, sender); err != nil {
 			// Once the first record has been flushed, the status and stream framing
 			// are committed. Rendering the regular JSON error envelope here would
 			// append an untyped record that consumers could mistake for domain data.
 			// Preserve the error for Gin logging/observability and terminate the
 			// stream without attempting a second HTTP response.
-			if gc.Writer.Written() {
-				_ = gc.Error(err)
-				gc.Abort()
+			if x.Writer().Written() {
+				obs := observationOf(x)
+				obs.fail(StageHandler, err, obs.internalErrorCode())
+				x.Error(err)
+				x.Abort()
 				return nil, errResponseHandled
 			}
 			return nil, err
 		}
 		// A successful stream may legitimately contain zero records. In that
 		// case no sender call has initialized the response headers yet.
-		if !gc.Writer.Written() {
-			setJSONLinesHeaders(gc)
+		if !x.Writer().Written() {
+			setJSONLinesHeaders(x)
 		}
 		return nil, errResponseHandled
-	}, append([]RouteOption{NoDataWrap()}, opts...)...)
+	}, append([]RouteOption{NoDataWrap(), streamingRoute()}, opts...)...)
 }
 
-func setJSONLinesHeaders(c *gin.Context) {
-	c.Header("Content-Type", "application/x-ndjson")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
+func setJSONLinesHeaders(x exchange) {
+	h := x.Writer().Header()
+	h.Set("Content-Type", "application/x-ndjson")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
 }
 
-func newJSONLinesSender(c *gin.Context) JSONLinesSender {
+func newJSONLinesSender(x exchange) JSONLinesSender {
 	// Marshal before committing headers so unsupported values can still use the
 	// normal HTTP error path. Append the delimiter and perform one write to avoid
 	// exposing a partial record between separate payload/newline writes.
 	return func(item any) error {
-		record, err := json.Marshal(item)
+		record, err := codecOf(x).Marshal(item)
 		if err != nil {
 			return err
 		}
 		record = append(record, '\n')
-		if !c.Writer.Written() {
-			setJSONLinesHeaders(c)
+		w := x.Writer()
+		if !w.Written() {
+			setJSONLinesHeaders(x)
 		}
-		if _, err := c.Writer.Write(record); err != nil {
+		if _, err := w.Write(record); err != nil {
 			return err
 		}
-		if flusher, ok := c.Writer.(http.Flusher); ok {
-			flusher.Flush()
-		}
+		w.Flush()
+		observationOf(x).sent()
+		touchIdle(x)
 		return nil
 	}
 }
@@ -1379,7 +1379,7 @@ func BenchmarkBindJSONVsShouldBindJSON(b *testing.B) {
 			gc.Request.Header.Set("Content-Type", "application/json")
 
 			var req jsonBodyReq
-			if err := bindJSON(gc, cfg, &req); err != nil {
+			if err := bindJSON(gc.Request, cfg, &req); err != nil {
 				b.Fatalf("bindJSON: %v", err)
 			}
 		}
@@ -1410,7 +1410,7 @@ func BenchmarkBindJSONVsShouldBindJSON(b *testing.B) {
 			gc.Request.Header.Set("Content-Type", "application/json")
 
 			var req numberReq
-			if err := bindJSON(gc, cfg, &req); err != nil {
+			if err := bindJSON(gc.Request, cfg, &req); err != nil {
 				b.Fatalf("bindJSON use number: %v", err)
 			}
 			if _, ok := req.Value.(json.Number); !ok {
@@ -2457,7 +2457,7 @@ func TestMultipartUploadBinding(t *testing.T) {
 func TestSSESenderWritesEvent(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	sender := newSSESender(c)
+	sender := newSSESender(ginExchangeOf(c))
 	if err := sender(Event{Event: "message", Data: map[string]string{"hello": "world"}}); err != nil {
 		t.Fatalf("send err=%v", err)
 	}
@@ -2467,7 +2467,7 @@ func TestSSESenderReturnsWriterError(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Writer = &brokenWriter{ResponseWriter: c.Writer}
-	sender := newSSESender(c)
+	sender := newSSESender(ginExchangeOf(c))
 	if err := sender(Event{Event: "message", Data: map[string]string{"hello": "world"}}); err == nil {
 		t.Fatalf("expected writer error")
 	}
@@ -0,0 +1,293 @@
+// Package ginxtest 提供进程内调用 ginx 路由的测试工具.
+//
+//	r := gin.New()
+//	ginx.GET(r, "/users/:id", GetUser)
+//
+//	rsp, errw, raw := ginxtest.Call[GetUserReq, GetUserRsp](r, http.MethodGet, "/users/:id",
+//	    &GetUserReq{ID: 7, Fields: "name"}, ginxtest.WithHeader("Authorization", "Bearer t"))
+//
+// Req 按 tag 序列化: uri 填充路径模板中的 :name / *name, form 编码为 query,
+// header / cookie 写入请求头, json 字段编码为 JSON body. 请求经过完整的
+// gin 中间件与 ginx 绑定、校验、拦截器, 响应按 ginx.ParseResponse 解包.
+package ginxtest
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/chendefine/ginx"
+)
+
+// Option 配置单次调用.
+type Option func(*config)
+
+type config struct {
+	header      http.Header
+	cookies     []*http.Cookie
+	body        []byte
+	contentType string
+	formBody    bool
+}
+
+// WithHeader 追加请求头, 与 Req 中 header 字段同名时以此为准.
+func WithHeader(key, value string) Option {
+	return func(c *config) { c.header.Set(key, value) }
+}
+
+// WithCookie 追加 cookie.
+func WithCookie(name, value string) Option {
+	return func(c *config) { c.cookies = append(c.cookies, &http.Cookie{Name: name, Value: value}) }
+}
+
+// WithBody 直接指定请求 body, 忽略 Req 中的 json 字段.
+func WithBody(contentType string, body []byte) Option {
+	return func(c *config) {
+		c.contentType = contentType
+		c.body = body
+	}
+}
+
+// WithFormBody 把 form 字段编码为 application/x-www-form-urlencoded body 而不是 query.
+func WithFormBody() Option {
+	return func(c *config) { c.formBody = true }
+}
+
+// Call 构造请求并在 h 上执行, 成功时返回解码后的 *Rsp; 失败时返回 *ginx.ErrWrap,
+// 响应体无法解码为 Rsp 时 ErrWrap.Code 为 -1. raw 始终为原始响应.
+func Call[Req, Rsp any](h http.Handler, method, path string, req *Req, opts ...Option) (*Rsp, *ginx.ErrWrap, *httptest.ResponseRecorder) {
+	raw := Do(h, method, path, req, opts...)
+	var rsp Rsp
+	if err := ginx.ParseResponse(raw.Code, raw.Body.Bytes(), &rsp); err != nil {
+		var ew *ginx.ErrWrap
+		if errors.As(err, &ew) {
+			return nil, ew, raw
+		}
+		return nil, &ginx.ErrWrap{Code: -1, Msg: "ginxtest: decode response: " + err.Error(), HttpCode: raw.Code}, raw
+	}
+	return &rsp, nil, raw
+}
+
+// Do 构造请求并在 h 上执行, 返回原始响应. req 可以为 nil.
+func Do(h http.Handler, method, path string, req any, opts ...Option) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, NewRequest(method, path, req, opts...))
+	return w
+}
+
+// NewRequest 按 Req 的 tag 构造 *http.Request; path 为路由模板或具体路径,
+// 可以带 query. Req 无法编码时 panic.
+func NewRequest(method, path string, req any, opts ...Option) *http.Request {
+	c := config{header: make(http.Header)}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	p := newParts()
+	if req != nil {
+		if err := p.encode(reflect.ValueOf(req)); err != nil {
+			panic("ginxtest: " + err.Error())
+		}
+	}
+
+	u, err := url.Parse(fillPath(path, p.uri))
+	if err != nil {
+		panic("ginxtest: " + err.Error())
+	}
+	var body io.Reader
+	contentType := c.contentType
+	switch {
+	case c.body != nil:
+		body = bytes.NewReader(c.body)
+	case c.formBody && len(p.form) > 0:
+		body = strings.NewReader(p.form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case len(p.json) > 0:
+		b, err := json.Marshal(p.json)
+		if err != nil {
+			panic("ginxtest: " + err.Error())
+		}
+		body = bytes.NewReader(b)
+		contentType = "application/json"
+	}
+	if !c.formBody && len(p.form) > 0 {
+		q := u.Query()
+		for k, vs := range p.form {
+			q[k] = append(q[k], vs...)
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	r := httptest.NewRequest(method, u.RequestURI(), body)
+	for k, vs := range p.header {
+		r.Header[k] = vs
+	}
+	for k, vs := range c.header {
+		r.Header[k] = vs
+	}
+	if contentType != "" && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	for _, ck := range append(p.cookies, c.cookies...) {
+		r.AddCookie(ck)
+	}
+	return r
+}
+
+// fillPath 用 uri 字段替换路径模板中的 :name 与 *name 段.
+func fillPath(path string, uri map[string]string) string {
+	rawPath, query, hasQuery := strings.Cut(path, "?")
+	segs := strings.Split(rawPath, "/")
+	for i, seg := range segs {
+		if len(seg) < 2 || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		if v, ok := uri[seg[1:]]; ok {
+			if seg[0] == '*' {
+				segs[i] = strings.TrimPrefix(v, "/")
+			} else {
+				segs[i] = url.PathEscape(v)
+			}
+		}
+	}
+	out := strings.Join(segs, "/")
+	if hasQuery {
+		out += "?" + query
+	}
+	return out
+}
+
+type parts struct {
+	uri     map[string]string
+	form    url.Values
+	header  http.Header
+	cookies []*http.Cookie
+	json    map[string]json.RawMessage
+}
+
+func newParts() *parts {
+	return &parts{uri: map[string]string{}, form: url.Values{}, header: http.Header{}, json: map[string]json.RawMessage{}}
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+func (p *parts) encode(v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("request must be a struct, got %s", v.Type())
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+		sourced := false
+		for _, tag := range []string{"uri", "form", "header", "cookie"} {
+			name, ok := tagName(f, tag)
+			if !ok {
+				continue
+			}
+			sourced = true
+			values, err := formatValues(fv)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			switch {
+			case tag == "uri" && len(values) > 0:
+				p.uri[name] = values[0]
+			case fv.IsZero():
+				// 零值不发送, 由服务端 default tag 决定.
+			case tag == "form":
+				p.form[name] = append(p.form[name], values...)
+			case tag == "header":
+				for _, s := range values {
+					p.header.Add(name, s)
+				}
+			case tag == "cookie":
+				for _, s := range values {
+					p.cookies = append(p.cookies, &http.Cookie{Name: name, Value: url.QueryEscape(s)})
+				}
+			}
+		}
+		if sourced {
+			continue
+		}
+		jsonTag, hasJSON := f.Tag.Lookup("json")
+		name, opts, _ := strings.Cut(jsonTag, ",")
+		if f.Anonymous && name == "" {
+			// 嵌入结构体 (如 ginx.PageReq) 的字段提升到当前层.
+			if err := p.encode(fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if !hasJSON || name == "-" || !f.IsExported() {
+			continue
+		}
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+		b, err := json.Marshal(fv.Interface())
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		if name == "" {
+			name = f.Name
+		}
+		p.json[name] = b
+	}
+	return nil
+}
+
+func tagName(f reflect.StructField, tag string) (string, bool) {
+	v, ok := f.Tag.Lookup(tag)
+	if !ok || !f.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(v, ",")
+	if name == "" || name == "-" {
+		return "", false
+	}
+	return name, true
+}
+
+// formatValues 把字段值格式化为字符串列表, 切片按元素展开.
+func formatValues(v reflect.Value) ([]string, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return []string{string(b)}, err
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		out := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			s, err := formatValues(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, s...)
+		}
+		return out, nil
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return []string{fmt.Sprint(v.Interface())}, nil
+}
@@ -0,0 +1,141 @@
+package ginxtest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chendefine/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type updateReq struct {
+	ginx.PageReq
+	ID      int       `uri:"id" binding:"required"`
+	Tags    []string  `form:"tag"`
+	Since   time.Time `form:"since"`
+	Token   string    `header:"X-Token"`
+	Session string    `cookie:"session"`
+	Name    string    `json:"name" binding:"required"`
+	Note    string    `json:"note,omitempty"`
+	Secret  string    `json:"-"`
+}
+
+type updateRsp struct {
+	ID      int      `json:"id"`
+	Tags    []string `json:"tags"`
+	Since   string   `json:"since"`
+	Token   string   `json:"token"`
+	Session string   `json:"session"`
+	Name    string   `json:"name"`
+	Limit   int      `json:"limit"`
+	Cursor  string   `json:"cursor"`
+}
+
+type emptyReq struct{}
+
+func newRouter() *gin.Engine {
+	r := gin.New()
+	ginx.PUT(r, "/users/:id", func(ctx context.Context, req *updateReq) (*updateRsp, error) {
+		if req.Name == "taken" {
+			return nil, ginx.Error(4009, "name taken").Status(http.StatusConflict)
+		}
+		return &updateRsp{
+			ID: req.ID, Tags: req.Tags, Since: req.Since.Format(time.RFC3339), Token: req.Token,
+			Session: req.Session, Name: req.Name, Limit: req.Limit, Cursor: req.Cursor,
+		}, nil
+	})
+	ginx.SSE(r, "/events", func(ctx context.Context, req *emptyReq, send ginx.Sender) error {
+		_ = send(ginx.Event{ID: "1", Event: "tick", Data: map[string]int{"n": 1}})
+		return send(ginx.Event{Data: "line1\nline2"})
+	})
+	ginx.JSONLines(r, http.MethodGet, "/lines", func(ctx context.Context, req *emptyReq, send ginx.JSONLinesSender) error {
+		for i := range 3 {
+			if err := send(map[string]int{"n": i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	r.GET("/plain", func(gc *gin.Context) { gc.String(http.StatusOK, "not json") })
+	return r
+}
+
+func TestCall(t *testing.T) {
+	r := newRouter()
+	req := &updateReq{
+		PageReq: ginx.PageReq{Cursor: "c1"},
+		ID:      7,
+		Tags:    []string{"a", "b"},
+		Since:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Token:   "t",
+		Session: "s 1",
+		Name:    "ann",
+		Secret:  "x",
+	}
+	rsp, errw, raw := Call[updateReq, updateRsp](r, http.MethodPut, "/users/:id", req, WithCookie("other", "1"))
+	if errw != nil {
+		t.Fatalf("err = %v (%s)", errw, raw.Body.String())
+	}
+	want := updateRsp{ID: 7, Tags: []string{"a", "b"}, Since: "2026-01-02T03:04:05Z", Token: "t", Session: "s 1", Name: "ann", Limit: 20, Cursor: "c1"}
+	if rsp.ID != want.ID || strings.Join(rsp.Tags, ",") != "a,b" || rsp.Since != want.Since || rsp.Token != want.Token ||
+		rsp.Session != want.Session || rsp.Name != want.Name || rsp.Limit != want.Limit || rsp.Cursor != want.Cursor {
+		t.Fatalf("rsp = %+v, want %+v", rsp, want)
+	}
+	AssertGolden(t, "update_user", raw.Body.Bytes())
+
+	_, errw, raw = Call[updateReq, updateRsp](r, http.MethodPut, "/users/:id", &updateReq{ID: 1, Name: "taken"})
+	if errw == nil || errw.Code != 4009 || errw.HttpCode != http.StatusConflict || raw.Code != http.StatusConflict {
+		t.Fatalf("business error = %+v", errw)
+	}
+	_, errw, _ = Call[updateReq, updateRsp](r, http.MethodPut, "/users/:id", &updateReq{ID: 1})
+	if errw == nil || errw.Code != 1 || errw.Msg != "name is required" {
+		t.Fatalf("validation error = %+v", errw)
+	}
+	_, errw, _ = Call[updateReq, updateRsp](r, http.MethodPut, "/users/1", &updateReq{ID: 1},
+		WithBody("application/json", []byte(`{"name":"raw"}`)), WithHeader("X-Token", "override"))
+	if errw != nil {
+		t.Fatalf("raw body = %+v", errw)
+	}
+	_, errw, _ = Call[emptyReq, updateRsp](r, http.MethodGet, "/plain", nil)
+	if errw == nil || errw.Code != -1 {
+		t.Fatalf("undecodable = %+v", errw)
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	type formReq struct {
+		Path string `uri:"path"`
+		Q    string `form:"q"`
+		Page int    `form:"page"`
+	}
+	r := NewRequest(http.MethodPost, "/files/*path?x=1", &formReq{Path: "/a/b c", Q: "go"})
+	if r.URL.Path != "/files/a/b c" || r.URL.Query().Get("x") != "1" || r.URL.Query().Get("q") != "go" || r.URL.Query().Has("page") {
+		t.Fatalf("url = %s", r.URL)
+	}
+	r = NewRequest(http.MethodPost, "/search", &formReq{Q: "go"}, WithFormBody())
+	if r.URL.RawQuery != "" || r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Fatalf("form body request = %s %v", r.URL, r.Header)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	r := newRouter()
+	events := SSEEvents(Do(r, http.MethodGet, "/events", nil))
+	if len(events) != 2 || events[0].ID != "1" || events[0].Event != "tick" || events[0].Data != `{"n":1}` || events[1].Data != "line1\nline2" {
+		t.Fatalf("events = %+v", events)
+	}
+	lines, err := JSONLines[map[string]int](Do(r, http.MethodGet, "/lines", nil))
+	if err != nil || len(lines) != 3 || lines[2]["n"] != 2 {
+		t.Fatalf("lines = %v (%v)", lines, err)
+	}
+	if _, err := JSONLines[map[string]int](Do(r, http.MethodGet, "/plain", nil)); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
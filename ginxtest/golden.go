@@ -0,0 +1,42 @@
+package ginxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateEnv 非空时 AssertGolden 用实际内容覆盖 golden 文件.
+const UpdateEnv = "GINXTEST_UPDATE"
+
+// AssertGolden 比较 got 与 testdata/<name>.golden; 合法 JSON 会先格式化为缩进形式,
+// 便于审阅 diff. 设置环境变量 GINXTEST_UPDATE=1 运行测试可生成或更新 golden 文件.
+func AssertGolden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	if json.Valid(got) {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, got, "", "  "); err == nil {
+			buf.WriteByte('\n')
+			got = buf.Bytes()
+		}
+	}
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ginxtest: read golden file (run with %s=1 to create it): %v", UpdateEnv, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("ginxtest: %s mismatch\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package ginxtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/chendefine/ginx"
+)
+
+// SSEEvents 解析 SSE 响应中的全部事件, Data 为原始文本 (多行 data 以 \n 连接).
+func SSEEvents(raw *httptest.ResponseRecorder) []ginx.Event {
+	var (
+		events []ginx.Event
+		evt    ginx.Event
+		data   []string
+		seen   bool
+	)
+	flush := func() {
+		if seen {
+			evt.Data = strings.Join(data, "\n")
+			events = append(events, evt)
+		}
+		evt, data, seen = ginx.Event{}, nil, false
+	}
+	sc := bufio.NewScanner(bytes.NewReader(raw.Body.Bytes()))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			flush()
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			evt.ID = value
+		case "event":
+			evt.Event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			n, _ := strconv.ParseUint(value, 10, 0)
+			evt.Retry = uint(n)
+		default:
+			continue // 注释行或未知字段
+		}
+		seen = true
+	}
+	flush()
+	return events
+}
+
+// JSONLines 把 NDJSON / JSON Lines 响应逐行解码为 T, 空行被忽略.
+func JSONLines[T any](raw *httptest.ResponseRecorder) ([]T, error) {
+	var out []T
+	for i, line := range bytes.Split(raw.Body.Bytes(), []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var v T
+		if err := json.Unmarshal(line, &v); err != nil {
+			return out, fmt.Errorf("ginxtest: line %d: %w", i+1, err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
@@ -0,0 +1,63 @@
+package ginx
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// HTTPHandler 使用默认 Engine 把 fn 适配为标准 http.Handler, 可挂到 net/http
+// ServeMux (Go 1.22+ 模式) 或 chi 等路由上:
+//
+//	mux.Handle("GET /users/{id}", ginx.HTTPHandler(GetUser))
+//
+// uri 字段取自 r.PathValue, 绑定、校验、拦截器、Observer 与响应包装和 gin 路由完全一致.
+// 请求不经过 gin: GinContext 返回 false, 自定义 Response 的 WriteTo 与 RegisterBindingSource
+// 注册的来源依赖 *gin.Context, 不可用; JSONRenderer 也不生效, 响应按 Codec 编码.
+func HTTPHandler[Req, Rsp any](fn HandlerFunc[Req, Rsp], opts ...RouteOption) http.Handler {
+	return NewHTTPHandler(nil, fn, opts...)
+}
+
+// NewHTTPHandler 与 HTTPHandler 相同, 但按 e 的配置执行; e 为 nil 时使用默认 Engine.
+// 不触发 OnRegister; Observer 收到的 RegisterInfo.Method / Path 取自本次请求与 r.Pattern.
+// Req 使用了自定义绑定来源时 panic.
+func NewHTTPHandler[Req, Rsp any](e *Engine, fn HandlerFunc[Req, Rsp], opts ...RouteOption) http.Handler {
+	if e == nil {
+		e = defaultEngine
+	}
+	cfg := e.resolveRoute(opts)
+	var reqZero Req
+	checkPreconditionType(cfg, reflect.TypeOf(reqZero))
+	plan := routePlan(cfg, reflect.TypeOf(reqZero))
+	if len(plan.sources) > 0 {
+		panic(fmt.Sprintf("ginx: %T uses custom binding sources, which require a gin route", reqZero))
+	}
+	cfg.route.ReqType = reflect.TypeOf(reqZero)
+	cfg.route.RspType = reflect.TypeOf((*Rsp)(nil)).Elem()
+	if cfg.fieldMask {
+		cfg.fieldTree = fieldTreeOf(cfg.route.RspType)
+	}
+	cfg.routeFromRequest = true
+	safe := cfg
+	safe.idempotency = nil
+	handler, safeHandler := makeHandler(cfg, plan, fn), makeHandler(safe, plan, fn)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := handler
+		if isSafeMethod(r.Method) {
+			h = safeHandler
+		}
+		x := newHTTPExchange(w, r)
+		h(x)
+		x.Writer().WriteHeaderNow()
+	})
+}
+
+// patternPath 从 ServeMux 模式 "[METHOD ][HOST]/path" 中取出路径部分.
+func patternPath(pattern string) string {
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		return pattern[i:]
+	}
+	return ""
+}
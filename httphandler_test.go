@@ -0,0 +1,137 @@
+package ginx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type httpUserReq struct {
+	ID    int    `uri:"id" binding:"required,min=1"`
+	Name  string `json:"name" binding:"required"`
+	Trace string `header:"X-Trace"`
+	Q     string `form:"q" default:"none"`
+}
+
+type httpUserRsp struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Trace string `json:"trace"`
+	Q     string `json:"q"`
+}
+
+func TestHTTPHandler(t *testing.T) {
+	var routes []string
+	e := New(
+		WithInterceptor(func(ctx context.Context, req any, next func() (any, error)) (any, error) {
+			SetHeader(ctx, "X-Intercepted", "1")
+			return next()
+		}),
+		WithObserver(func(ctx context.Context, info RegisterInfo) (context.Context, func(Outcome)) {
+			return nil, func(o Outcome) { routes = append(routes, info.Method+" "+info.Path) }
+		}),
+	)
+	fn := func(ctx context.Context, req *httpUserReq) (*httpUserRsp, error) {
+		if req.Name == "boom" {
+			return nil, Error(4004, "user %d not found").Format(req.ID).Status(http.StatusNotFound)
+		}
+		return &httpUserRsp{ID: req.ID, Name: req.Name, Trace: req.Trace, Q: req.Q}, nil
+	}
+
+	r := gin.New()
+	PUT(e.Wrap(r), "/users/:id", fn, SuccessStatus(http.StatusAccepted))
+	mux := http.NewServeMux()
+	h := NewHTTPHandler(e, fn, SuccessStatus(http.StatusAccepted))
+	mux.Handle("PUT /users/{id}", h)
+	mux.Handle("PUT /members/{id}", h)
+
+	tests := []struct {
+		name, target, body string
+	}{
+		{"ok", "/users/7?q=x", `{"name":"ann"}`},
+		{"default", "/users/7", `{"name":"ann"}`},
+		{"validation", "/users/0", `{}`},
+		{"bad json", "/users/7", `{`},
+		{"business error", "/users/3", `{"name":"boom"}`},
+	}
+	for _, tt := range tests {
+		var got [2]*httptest.ResponseRecorder
+		for i, h := range []http.Handler{r, mux} {
+			req := httptest.NewRequest(http.MethodPut, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Trace", "t1")
+			got[i] = httptest.NewRecorder()
+			h.ServeHTTP(got[i], req)
+		}
+		g, m := got[0], got[1]
+		if g.Code != m.Code || g.Body.String() != m.Body.String() ||
+			g.Header().Get("Content-Type") != m.Header().Get("Content-Type") || g.Header().Get("X-Intercepted") != m.Header().Get("X-Intercepted") {
+			t.Fatalf("%s: gin = %d %v %s\nnet/http = %d %v %s", tt.name, g.Code, g.Header(), g.Body.String(), m.Code, m.Header(), m.Body.String())
+		}
+	}
+	if routes[1] != "PUT /users/{id}" {
+		t.Fatalf("observer route = %q", routes[1])
+	}
+	// 同一 handler 挂在多个模式上时, Observer 按本次请求的模式上报.
+	req := httptest.NewRequest(http.MethodPut, "/members/9", strings.NewReader(`{"name":"bob"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), `"id":9`) || routes[len(routes)-1] != "PUT /members/{id}" {
+		t.Fatalf("second pattern = %d %s, route %q", w.Code, w.Body.String(), routes[len(routes)-1])
+	}
+
+	w = httptest.NewRecorder()
+	HTTPHandler(func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return nil, nil
+	}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"code":0`) {
+		t.Fatalf("default engine = %d %s", w.Code, w.Body.String())
+	}
+}
+
+type httpGinOnlyRsp struct{}
+
+func (httpGinOnlyRsp) WriteTo(c *gin.Context) error { return nil }
+
+type httpSourceReq struct {
+	User string `ctx:"user"`
+}
+
+func TestHTTPHandlerWithoutGin(t *testing.T) {
+	var hasGin bool
+	h := HTTPHandler(func(ctx context.Context, req *simpleReq) (*StringRsp, error) {
+		_, hasGin = GinContext(ctx)
+		SetHeader(ctx, "X-Client", ClientIP(ctx))
+		return StringResponse(http.StatusCreated, "ok"), nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if hasGin || w.Code != http.StatusCreated || w.Body.String() != "ok" || w.Header().Get("X-Client") != "10.0.0.1" {
+		t.Fatalf("builtin response = %v %d %v %q", hasGin, w.Code, w.Header(), w.Body.String())
+	}
+
+	// 自定义 Response 依赖 *gin.Context, 在 net/http 下按错误渲染.
+	w = httptest.NewRecorder()
+	HTTPHandler(func(ctx context.Context, req *simpleReq) (*httpGinOnlyRsp, error) {
+		return &httpGinOnlyRsp{}, nil
+	}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("custom response = %d %s", w.Code, w.Body.String())
+	}
+
+	e := New()
+	e.RegisterBindingSource("ctx", func(gc *gin.Context, key string) ([]string, bool) { return nil, false })
+	defer func() {
+		if recover() == nil {
+			t.Fatal("custom binding source did not panic")
+		}
+	}()
+	NewHTTPHandler(e, func(ctx context.Context, req *httpSourceReq) (*simpleRsp, error) { return nil, nil })
+}
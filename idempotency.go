@@ -0,0 +1,334 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// IdempotencyKeyHeader 是幂等键请求头名.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotencyReplayedHeader 在回放已存储响应时设置为 "true".
+const IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+const maxIdempotencyKeyLen = 255
+
+// IdempotencyRecord 是一个幂等键对应的状态. Completed 为 false 表示首个请求仍在处理中.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Completed   bool
+	Status      int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore 存储幂等键与首个完成的响应, 实现必须并发安全.
+//
+//   - Reserve 原子地占用 key: key 不存在时以 fingerprint 占位并返回 (nil, nil);
+//     已存在时返回已有记录 (可能仍未完成), 不修改它.
+//   - Complete 保存完成的响应, 之后的 Reserve 会返回该记录.
+//   - Release 删除占位, 用于 handler 失败 (5xx / panic) 后允许客户端重试.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key, fingerprint string) (*IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, rec IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotent 为非安全方法路由开启 Idempotency-Key 支持. 请求带该请求头时:
+//
+//   - 首个请求完成后, 状态码、响应头与响应体 (含 ginx 包装) 存入 store
+//   - 之后相同 key 且相同请求的重试直接回放已存储的响应
+//   - 首个请求仍在处理中时返回 409
+//   - 相同 key 但绑定后的请求不同时返回 422
+//
+// 指纹为 method、请求路径与绑定后 Req 的 JSON 的 SHA-256. 5xx 与 panic 不会被存储.
+// 未带请求头的请求不受影响; GET / HEAD / OPTIONS 路由上该选项被忽略.
+func Idempotent(store IdempotencyStore) RouteOption {
+	return func(c *routeConfig) { c.idempotency = store }
+}
+
+// idempotencyScope 表示一个已占用的幂等键, 负责在响应写出后保存或释放.
+type idempotencyScope struct {
+	store     IdempotencyStore
+	key       string
+	fp        string
+	tee       *teeWriter
+	committed bool
+}
+
+// beginIdempotency 在 handler 执行前检查幂等键; handled 为 true 表示响应已写出.
+func beginIdempotency(ctx context.Context, x exchange, cfg resolved, req any) (*idempotencyScope, bool) {
+	key := x.Request().Header.Get(IdempotencyKeyHeader)
+	if key == "" {
+		return nil, false
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		writeError(ctx, cfg, Error(cfg.invalidArgCode, "idempotency key too long").Status(http.StatusBadRequest))
+		return nil, true
+	}
+	fp, err := requestFingerprint(x.Request(), cfg.jsonCodec(), req)
+	if err != nil {
+		writeError(ctx, cfg, err)
+		return nil, true
+	}
+	rec, err := cfg.idempotency.Reserve(ctx, key, fp)
+	if err != nil {
+		writeError(ctx, cfg, err)
+		return nil, true
+	}
+	if rec != nil {
+		switch {
+		case rec.Fingerprint != fp:
+			writeError(ctx, cfg, Error(cfg.invalidArgCode, "idempotency key reused with a different request").Status(http.StatusUnprocessableEntity))
+		case !rec.Completed:
+			writeError(ctx, cfg, Error(cfg.invalidArgCode, "a request with the same idempotency key is in progress").Status(http.StatusConflict))
+		default:
+			replayIdempotent(x, rec)
+		}
+		return nil, true
+	}
+
+	tee := &teeWriter{responseWriter: x.Writer()}
+	x.SetWriter(tee)
+	return &idempotencyScope{store: cfg.idempotency, key: key, fp: fp, tee: tee}, false
+}
+
+// commit 保存已写出的响应; 5xx 释放 key 以便重试.
+func (s *idempotencyScope) commit(ctx context.Context, x exchange) {
+	x.SetWriter(s.tee.responseWriter)
+	status := s.tee.Status()
+	if status >= http.StatusInternalServerError {
+		return
+	}
+	// 存储未压缩的响应体, 回放时按重试请求的 Accept-Encoding 重新协商.
+	header := s.tee.Header().Clone()
+	if header.Get("Content-Encoding") != "" {
+		header.Del("Content-Encoding")
+		if etag := header.Get("ETag"); etag != "" {
+			header.Set("ETag", stripETagCoding(etag))
+		}
+	}
+	err := s.store.Complete(ctx, s.key, IdempotencyRecord{
+		Fingerprint: s.fp,
+		Completed:   true,
+		Status:      status,
+		Header:      header,
+		Body:        bytes.Clone(s.tee.buf.Bytes()),
+	})
+	if err != nil {
+		x.Error(err)
+		return
+	}
+	s.committed = true
+}
+
+// release 在未成功保存时删除占位, 以 defer 调用以覆盖 panic.
+func (s *idempotencyScope) release(ctx context.Context, x exchange) {
+	if s.committed {
+		return
+	}
+	x.SetWriter(s.tee.responseWriter)
+	if err := s.store.Release(context.WithoutCancel(ctx), s.key); err != nil {
+		x.Error(err)
+	}
+}
+
+// replayIdempotent 回放已存储的响应; X-Request-ID 保留本次请求自己的值.
+func replayIdempotent(x exchange, rec *IdempotencyRecord) {
+	w := x.Writer()
+	h := w.Header()
+	for k, v := range rec.Header {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(RequestIDHeader) {
+			continue
+		}
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set(IdempotencyReplayedHeader, "true")
+	w.WriteHeader(rec.Status)
+	if len(rec.Body) > 0 {
+		_, _ = w.Write(rec.Body)
+	} else {
+		w.WriteHeaderNow()
+	}
+	x.Abort()
+}
+
+// requestFingerprint 对方法、路径与 req 求摘要. req 按导出字段逐个编码而不经过 json tag,
+// 因此 query / header / uri 等 json:"-" 字段同样参与比较.
+func requestFingerprint(r *http.Request, codec Codec, req any) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	if err := writeFingerprint(h, codec, reflect.ValueOf(req)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeFingerprint 把 v 写入 w: 结构体按字段名递归, 自定义编码的类型 (如 time.Time、Optional)
+// 与其它值由 codec 编码.
+func writeFingerprint(w io.Writer, codec Codec, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			_, err := w.Write([]byte("null"))
+			return err
+		}
+		v = v.Elem()
+	}
+	t := v.Type()
+	if v.Kind() != reflect.Struct || t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		if v.CanAddr() {
+			v = v.Addr()
+		}
+		b, err := codec.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+	_, _ = w.Write([]byte{'{'})
+	for i := range t.NumField() {
+		f := t.Field(i)
+		// 未导出类型的内嵌结构体仍需遍历其导出字段.
+		if !f.IsExported() && !(f.Anonymous && f.Type.Kind() == reflect.Struct) {
+			continue
+		}
+		_, _ = io.WriteString(w, f.Name)
+		_, _ = w.Write([]byte{':'})
+		if err := writeFingerprint(w, codec, v.Field(i)); err != nil {
+			return err
+		}
+		_, _ = w.Write([]byte{','})
+	}
+	_, err := w.Write([]byte{'}'})
+	return err
+}
+
+// teeWriter 在写出响应的同时保留一份副本.
+type teeWriter struct {
+	responseWriter
+	buf bytes.Buffer
+}
+
+func (w *teeWriter) Write(b []byte) (int, error) {
+	n, err := w.responseWriter.Write(b)
+	w.buf.Write(b[:n])
+	return n, err
+}
+
+func (w *teeWriter) WriteString(s string) (int, error) { return w.Write([]byte(s)) }
+
+// MemoryIdempotencyStore 是进程内的 IdempotencyStore, 记录 (包括处理中的占位) 在 ttl 后过期.
+// 多实例部署需要换成共享存储的实现.
+type MemoryIdempotencyStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	rec     IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore 创建进程内存储; ttl <= 0 时使用 24h.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &MemoryIdempotencyStore{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]memoryIdempotencyEntry),
+	}
+}
+
+// Reserve 实现 IdempotencyStore.
+func (s *MemoryIdempotencyStore) Reserve(_ context.Context, key, fingerprint string) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweepLocked(now)
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		rec := e.rec
+		return &rec, nil
+	}
+	s.entries[key] = memoryIdempotencyEntry{
+		rec:     IdempotencyRecord{Fingerprint: fingerprint},
+		expires: now.Add(s.ttl),
+	}
+	return nil, nil
+}
+
+// Complete 实现 IdempotencyStore.
+func (s *MemoryIdempotencyStore) Complete(_ context.Context, key string, rec IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryIdempotencyEntry{rec: rec, expires: s.now().Add(s.ttl)}
+	return nil
+}
+
+// Release 实现 IdempotencyStore.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// sweepLocked 每个 ttl 周期最多全量清理一次过期记录.
+func (s *MemoryIdempotencyStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+		}
+	}
+}
+
+// IdempotencyKeyOnRetry 是 resty 请求中间件: 对开启了重试且允许重试非幂等方法
+// (SetRetryCount + SetRetryAllowNonIdempotent) 的 POST / PATCH 请求, 在未显式设置时
+// 生成一个 Idempotency-Key. 同一次调用的各次重试复用同一个 key.
+// codegen 生成的 NewClient 会自动注册.
+func IdempotencyKeyOnRetry(_ *resty.Client, r *resty.Request) error {
+	if r.RetryCount <= 0 || !r.IsRetryAllowNonIdempotent {
+		return nil
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodPatch {
+		return nil
+	}
+	if r.Header.Get(IdempotencyKeyHeader) == "" {
+		var b [16]byte
+		_, _ = rand.Read(b[:])
+		r.Header.Set(IdempotencyKeyHeader, hex.EncodeToString(b[:]))
+	}
+	return nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
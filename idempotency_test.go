@@ -0,0 +1,290 @@
+package ginx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"resty.dev/v3"
+)
+
+type chargeReq struct {
+	Amount int `json:"amount" binding:"required"`
+}
+
+type chargeRsp struct {
+	ID     int64 `json:"id"`
+	Amount int   `json:"amount"`
+}
+
+func postCharge(r http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/charges", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	var calls atomic.Int64
+	store := NewMemoryIdempotencyStore(time.Hour)
+	r := gin.New()
+	POST(New().Wrap(r), "/charges", func(ctx context.Context, req *chargeReq) (*chargeRsp, error) {
+		n := calls.Add(1)
+		SetHeader(ctx, "X-Charge", "created")
+		if req.Amount < 0 {
+			return nil, Error(4001, "negative amount").Status(http.StatusBadRequest)
+		}
+		return &chargeRsp{ID: n, Amount: req.Amount}, nil
+	}, Idempotent(store), SuccessStatus(http.StatusCreated))
+
+	first := postCharge(r, "k1", `{"amount":100}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first status = %d: %s", first.Code, first.Body.String())
+	}
+	second := postCharge(r, "k1", `{"amount": 100}`)
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %s, want %d %s", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	if second.Header().Get("X-Charge") != "created" || second.Header().Get(IdempotencyReplayedHeader) != "true" {
+		t.Fatalf("replayed headers = %v", second.Header())
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("handler calls = %d, want 1", calls.Load())
+	}
+
+	mismatch := postCharge(r, "k1", `{"amount":200}`)
+	if mismatch.Code != http.StatusUnprocessableEntity || !strings.Contains(mismatch.Body.String(), `"code":1`) {
+		t.Fatalf("mismatch = %d %s", mismatch.Code, mismatch.Body.String())
+	}
+
+	// 4xx 业务错误同样被存储与回放.
+	bad := postCharge(r, "k2", `{"amount":-1}`)
+	again := postCharge(r, "k2", `{"amount":-1}`)
+	if bad.Code != http.StatusBadRequest || again.Body.String() != bad.Body.String() || calls.Load() != 2 {
+		t.Fatalf("4xx replay = %d %s (calls %d)", again.Code, again.Body.String(), calls.Load())
+	}
+
+	// 无请求头时不受影响.
+	postCharge(r, "", `{"amount":1}`)
+	postCharge(r, "", `{"amount":1}`)
+	if calls.Load() != 4 {
+		t.Fatalf("calls without key = %d, want 4", calls.Load())
+	}
+
+	// 绑定失败不占用 key.
+	if w := postCharge(r, "k3", `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("binding error = %d", w.Code)
+	}
+	if w := postCharge(r, "k3", `{"amount":5}`); w.Code != http.StatusCreated {
+		t.Fatalf("key after binding error = %d", w.Code)
+	}
+}
+
+type transferReq struct {
+	Account string `uri:"account" json:"-"`
+	Mode    string `form:"mode" json:"-"`
+	Tenant  string `header:"X-Tenant" json:"-"`
+	Amount  int    `json:"amount"`
+}
+
+// 存储的是未压缩的响应, ETag 也去掉编码后缀, 回放时按重试请求重新协商.
+func TestIdempotentReplayCompressed(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Hour)
+	r := gin.New()
+	POST(New(WithCompression(CompressionMinSize(1))).Wrap(r), "/charges", func(ctx context.Context, req *chargeReq) (*chargeRsp, error) {
+		SetHeader(ctx, "ETag", `"c1"`)
+		return &chargeRsp{ID: 1, Amount: req.Amount}, nil
+	}, Idempotent(store))
+
+	post := func(encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/charges", strings.NewReader(`{"amount":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		req.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := post("gzip"); w.Header().Get("ETag") != `"c1-gzip"` {
+		t.Fatalf("first = %v", w.Header())
+	}
+	if w := post(""); w.Header().Get("ETag") != `"c1"` || w.Header().Get("Content-Encoding") != "" || !strings.Contains(w.Body.String(), `"amount":1`) {
+		t.Fatalf("identity replay = %v %s", w.Header(), w.Body.String())
+	}
+	if w := post("gzip"); w.Header().Get("ETag") != `"c1-gzip"` || w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("gzip replay = %v", w.Header())
+	}
+}
+
+func TestIdempotentFingerprintSources(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Hour)
+	r := gin.New()
+	POST(New(WithRequestID(nil)).Wrap(r), "/accounts/:account/transfers", func(ctx context.Context, req *transferReq) (*chargeRsp, error) {
+		return &chargeRsp{Amount: req.Amount}, nil
+	}, Idempotent(store))
+
+	send := func(target, tenant, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"amount":5}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "k1")
+		req.Header.Set("X-Tenant", tenant)
+		req.Header.Set(RequestIDHeader, requestID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := send("/accounts/a1/transfers?mode=fast", "t1", "req-1"); w.Code != http.StatusOK {
+		t.Fatalf("first = %d %s", w.Code, w.Body.String())
+	}
+	// 重试带自己的请求 ID, 回放不应覆盖.
+	if w := send("/accounts/a1/transfers?mode=fast", "t1", "req-2"); w.Header().Get(IdempotencyReplayedHeader) != "true" || w.Header().Get(RequestIDHeader) != "req-2" {
+		t.Fatalf("replay headers = %v", w.Header())
+	}
+	// query / header 字段不在 JSON 中, 同样属于请求指纹.
+	for _, tt := range []struct{ target, tenant string }{
+		{"/accounts/a1/transfers?mode=slow", "t1"},
+		{"/accounts/a1/transfers?mode=fast", "t2"},
+	} {
+		if w := send(tt.target, tt.tenant, "req-3"); w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("%s %s = %d %s", tt.target, tt.tenant, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestIdempotentConcurrentDuplicate(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Hour)
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	r := gin.New()
+	POST(New().Wrap(r), "/charges", func(ctx context.Context, req *chargeReq) (*chargeRsp, error) {
+		close(started)
+		<-unblock
+		return &chargeRsp{ID: 1, Amount: req.Amount}, nil
+	}, Idempotent(store))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postCharge(r, "dup", `{"amount":1}`) }()
+	<-started
+	w := postCharge(r, "dup", `{"amount":1}`)
+	close(unblock)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("concurrent duplicate = %d %s", w.Code, w.Body.String())
+	}
+	if first := <-done; first.Code != http.StatusOK {
+		t.Fatalf("first = %d", first.Code)
+	}
+}
+
+func TestIdempotentReleasesOnFailure(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Hour)
+	var calls atomic.Int64
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) { c.AbortWithStatus(http.StatusInternalServerError) }))
+	POST(New().Wrap(r), "/charges", func(ctx context.Context, req *chargeReq) (*chargeRsp, error) {
+		switch calls.Add(1) {
+		case 1:
+			return nil, errors.New("db down")
+		case 2:
+			panic("boom")
+		}
+		return &chargeRsp{ID: 3, Amount: req.Amount}, nil
+	}, Idempotent(store))
+
+	for i, want := range []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK, http.StatusOK} {
+		if w := postCharge(r, "retry", `{"amount":1}`); w.Code != want {
+			t.Fatalf("attempt %d status = %d, want %d", i+1, w.Code, want)
+		}
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("handler calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestIdempotentIgnoredOnSafeMethods(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Hour)
+	var calls int
+	r := gin.New()
+	GET(New().Wrap(r), "/charges", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		calls++
+		return &simpleRsp{}, nil
+	}, Idempotent(store))
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/charges", nil)
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestMemoryIdempotencyStoreTTL(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewMemoryIdempotencyStore(time.Minute)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if rec, _ := s.Reserve(ctx, "k", "fp"); rec != nil {
+		t.Fatalf("first reserve = %+v", rec)
+	}
+	if rec, _ := s.Reserve(ctx, "k", "fp"); rec == nil || rec.Completed {
+		t.Fatalf("in-progress reserve = %+v", rec)
+	}
+	_ = s.Complete(ctx, "k", IdempotencyRecord{Fingerprint: "fp", Completed: true, Status: 201})
+	if rec, _ := s.Reserve(ctx, "k", "fp"); rec == nil || rec.Status != 201 {
+		t.Fatalf("completed reserve = %+v", rec)
+	}
+	now = now.Add(2 * time.Minute)
+	if rec, _ := s.Reserve(ctx, "k", "fp"); rec != nil {
+		t.Fatalf("expired reserve = %+v", rec)
+	}
+}
+
+func TestIdempotencyKeyOnRetry(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := resty.New().
+		SetRetryCount(2).
+		SetRetryWaitTime(time.Millisecond).
+		SetRetryAllowNonIdempotent(true).
+		AddRetryConditions(func(res *resty.Response, err error) bool {
+			return res != nil && res.StatusCode() == http.StatusServiceUnavailable
+		}).
+		AddRequestMiddleware(IdempotencyKeyOnRetry)
+	defer c.Close()
+	if _, err := c.R().Post(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("keys = %q, want the same non-empty key on each attempt", keys)
+	}
+
+	keys = nil
+	plain := resty.New().AddRequestMiddleware(IdempotencyKeyOnRetry)
+	defer plain.Close()
+	if _, err := plain.R().Post(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if keys[0] != "" {
+		t.Fatalf("key set without retries: %q", keys[0])
+	}
+}
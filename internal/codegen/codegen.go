@@ -120,6 +120,8 @@ func GenerateMulti(cfg Config) (*GenerateResult, error) {
 		return nil, err
 	}
 
+	patterns := collectPatterns(allTypes)
+
 	pkgName := cfg.PackageName
 	if pkgName == "" {
 		pkgName = "api"
@@ -130,12 +132,23 @@ func GenerateMulti(cfg Config) (*GenerateResult, error) {
 
 	if cfg.Output.IsMultiFile() {
 		typesImports := filterTypesImports(importsMap)
+		if hasPaginatedOperations(ops) || hasOptionalRequestFields(ops) || hasJSONPatchOperations(ops) {
+			typesImports["github.com/chendefine/ginx"] = true
+		}
+		if hasDependentRequired(allTypes) {
+			typesImports["context"] = true
+			typesImports["github.com/chendefine/ginx"] = true
+		}
+		if len(patterns) > 0 {
+			typesImports["github.com/chendefine/ginx"] = true
+		}
 		typesCode, err := executeTypesTemplate(&typesTemplateData{
 			PackageName:       pkgName,
 			GenerateDirective: cfg.GenerateDirective,
 			Imports:           sortedImports(typesImports),
 			Types:             allTypes,
 			Operations:        ops,
+			Patterns:          patterns,
 		})
 		if err != nil {
 			return nil, fmt.Errorf("render types: %w", err)
@@ -156,6 +169,12 @@ func GenerateMulti(cfg Config) (*GenerateResult, error) {
 					serverImports["github.com/chendefine/ginx"] = true
 				}
 			}
+			if hasTimeoutOperations(ops) {
+				serverImports["time"] = true
+			}
+			if hasSignedWebhooks(ops) {
+				serverImports["github.com/chendefine/ginx/webhook"] = true
+			}
 			serverCode, err := executeServerTemplate(&serverTemplateData{
 				PackageName:       pkgName,
 				GenerateDirective: cfg.GenerateDirective,
@@ -211,6 +230,9 @@ func GenerateMulti(cfg Config) (*GenerateResult, error) {
 			if hasClientTimeParameters(ops) {
 				clientImports["time"] = true
 			}
+			if hasPaginatedOperations(ops) {
+				clientImports["iter"] = true
+			}
 			clientCode, err := executeClientTemplate(&clientTemplateData{
 				PackageName:       pkgName,
 				GenerateDirective: cfg.GenerateDirective,
@@ -227,6 +249,22 @@ func GenerateMulti(cfg Config) (*GenerateResult, error) {
 			}
 		}
 	} else {
+		if generateServer && hasTimeoutOperations(ops) {
+			importsMap["time"] = true
+		}
+		if generateServer && hasSignedWebhooks(ops) {
+			importsMap["github.com/chendefine/ginx/webhook"] = true
+		}
+		if hasPaginatedOperations(ops) {
+			importsMap["github.com/chendefine/ginx"] = true
+		}
+		if hasDependentRequired(allTypes) {
+			importsMap["context"] = true
+			importsMap["github.com/chendefine/ginx"] = true
+		}
+		if len(patterns) > 0 {
+			importsMap["github.com/chendefine/ginx"] = true
+		}
 		allImports := sortedImports(importsMap)
 		if generateClient && len(ops) > 0 {
 			importsMap["fmt"] = true
@@ -245,6 +283,9 @@ func GenerateMulti(cfg Config) (*GenerateResult, error) {
 			if hasClientTimeParameters(ops) {
 				importsMap["time"] = true
 			}
+			if hasPaginatedOperations(ops) {
+				importsMap["iter"] = true
+			}
 			allImports = sortedImports(importsMap)
 		}
 		code, err := executeCombinedTemplate(&combinedTemplateData{
@@ -253,6 +294,7 @@ func GenerateMulti(cfg Config) (*GenerateResult, error) {
 			Imports:           allImports,
 			Types:             allTypes,
 			Operations:        ops,
+			Patterns:          patterns,
 			GenerateServer:    generateServer,
 			GenerateClient:    generateClient,
 			ServerName:        cfg.GetServerName(),
@@ -462,6 +504,60 @@ func hasJSONLinesOperations(ops []OperationDef) bool {
 	return false
 }
 
+func hasSignedWebhooks(ops []OperationDef) bool {
+	for _, op := range ops {
+		if op.WebhookSignature != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTimeoutOperations(ops []OperationDef) bool {
+	for _, op := range ops {
+		if op.Timeout > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPaginatedOperations(ops []OperationDef) bool {
+	for _, op := range ops {
+		if op.Pagination != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOptionalRequestFields(ops []OperationDef) bool {
+	for _, op := range ops {
+		if op.Request != nil && hasOptionalFields(op.Request.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDependentRequired(types []TypeDef) bool {
+	for _, td := range types {
+		if td.Struct != nil && len(td.Struct.DependentRequired) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasJSONPatchOperations(ops []OperationDef) bool {
+	for _, op := range ops {
+		if op.PatchKind == "json" {
+			return true
+		}
+	}
+	return false
+}
+
 func hasClientCookieParameters(ops []OperationDef) bool {
 	for _, op := range ops {
 		if len(filterCookieParams(op.Request)) > 0 {
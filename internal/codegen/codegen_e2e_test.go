@@ -721,7 +721,7 @@ func TestE2E_ResponseTypes_NoContent(t *testing.T) {
 	result := generateMultiFile(t, "response_types.yaml")
 	server := string(result.Server)
 	assertContains(t, server, "DeleteItem(ctx context.Context, req *DeleteItemReq) (*struct{}, error)")
-	assertContains(t, server, `ginx.DELETE(r, "/no-content", s.DeleteItem, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204))...)`)
+	assertContains(t, server, `ginx.DELETE(r, "/no-content", s.DeleteItem, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204), ginx.OperationID("deleteItem"))...)`)
 }
 
 func TestE2E_ResponseTypes_FixedAndExpectedStatuses(t *testing.T) {
@@ -729,8 +729,8 @@ func TestE2E_ResponseTypes_FixedAndExpectedStatuses(t *testing.T) {
 	server := string(result.Server)
 	client := string(result.Client)
 
-	assertContains(t, server, `ginx.POST(r, "/accepted-job", s.CreateJob, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(202))...)`)
-	assertContains(t, server, `ginx.POST(r, "/created-item", s.CreateItem, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(201))...)`)
+	assertContains(t, server, `ginx.POST(r, "/accepted-job", s.CreateJob, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(202), ginx.OperationID("createJob"))...)`)
+	assertContains(t, server, `ginx.POST(r, "/created-item", s.CreateItem, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(201), ginx.OperationID("createItem"))...)`)
 	assertContains(t, client, "ginx.ValidateResponseStatus(resp.StatusCode(), 202)")
 	assertContains(t, client, "ginx.ValidateResponseStatus(resp.StatusCode(), 201)")
 	assertContains(t, client, "ginx.ValidateResponseStatus(resp.StatusCode(), 200, 206)")
@@ -1480,6 +1480,33 @@ func TestE2E_Validation_CustomBinding(t *testing.T) {
 	assertContains(t, code, `binding:"omitempty,e164"`)
 }
 
+func TestE2E_Validation_PatternAndMultipleOf(t *testing.T) {
+	code := generateSingleFile(t, "validation.yaml")
+	name := patternName(`^[A-Z]{3}-\d{4}$`)
+	assertContains(t, code, `Sku *string `+"`"+`json:"sku" binding:"omitempty,pattern=`+name+`"`+"`")
+	assertContains(t, code, "ginx.RegisterPattern(\""+name+"\", `^[A-Z]{3}-\\d{4}$`)")
+	assertContains(t, code, `binding:"omitempty,multiple_of=5"`)
+	assertContains(t, code, `binding:"omitempty,multiple_of=0.01"`)
+
+	// A pattern on a non-string Go type is documented only; a quoted
+	// literal is used when the expression cannot be backquoted.
+	code = generateFromInlineSpec(t, `openapi: "3.0.3"
+info: {title: t, version: "1"}
+paths: {}
+components:
+  schemas:
+    Event:
+      type: object
+      required: [code]
+      properties:
+        at: {type: string, format: date-time, pattern: "^2"}
+        code: {type: string, pattern: "^`+"`"+`[a-z]+$"}
+`)
+	assertContains(t, code, `At *time.Time `+"`"+`json:"at"`+"`")
+	assertContains(t, code, `binding:"required,pattern=`+patternName("^`[a-z]+$")+`"`)
+	assertContains(t, code, `ginx.RegisterPattern("`+patternName("^`[a-z]+$")+`", "^`+"`"+`[a-z]+$")`)
+}
+
 func TestE2E_Validation_DefaultValues(t *testing.T) {
 	code := generateSingleFile(t, "validation.yaml")
 	assertContains(t, code, `default:"1"`)
@@ -1534,8 +1561,9 @@ func TestE2E_OAI31_InclusiveBounds(t *testing.T) {
 func TestE2E_OAI31_NullableTypeArray(t *testing.T) {
 	code := generateSingleFileV(t, "openapi-3.1", "openapi31.yaml")
 
-	// `type: ["string", "null"]` must parse without breaking generation.
-	assertContains(t, code, `Nickname *string `+"`"+`json:"nickname"`+"`")
+	// `type: ["string", "null"]` on a request body property becomes
+	// ginx.Optional so handlers can tell null from absent.
+	assertContains(t, code, `Nickname ginx.Optional[string] `+"`"+`json:"nickname,omitzero"`+"`")
 }
 
 func TestE2E_OAI31_StringAndEnumAndArray(t *testing.T) {
@@ -1550,7 +1578,7 @@ func TestE2E_OAI31_StringAndEnumAndArray(t *testing.T) {
 func TestE2E_OAI31_RoutesAndValidGo(t *testing.T) {
 	multi := generateMultiFileV(t, "openapi-3.1", "openapi31.yaml")
 
-	assertContains(t, string(multi.Server), `ginx.POST(r, "/oai31/validate", s.CreateOai31, opts...)`)
+	assertContains(t, string(multi.Server), `ginx.POST(r, "/oai31/validate", s.CreateOai31, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("createOai31"))...)`)
 	assertContains(t, string(multi.Server), `CreateOai31(ctx context.Context, req *CreateOai31Req) (*CreateOai31Rsp, error)`)
 	assertValidGo(t, string(multi.Types))
 	assertValidGo(t, string(multi.Server))
@@ -1629,13 +1657,18 @@ func TestE2E_OAI31_PrefixItemsTuples(t *testing.T) {
 func TestE2E_OAI31_NullableTypeArrays(t *testing.T) {
 	code := generateSingleFileV(t, "openapi-3.1", "nullable_types.yaml")
 
-	// Scalar nullable type arrays -> pointers of the scalar Go type.
-	assertContains(t, code, `Nickname *string `+"`"+`json:"nickname"`+"`")
-	assertContains(t, code, `Age *int `+"`"+`json:"age"`+"`")
-	assertContains(t, code, `Score *float64 `+"`"+`json:"score"`+"`")
-	assertContains(t, code, `Flag *bool `+"`"+`json:"flag"`+"`")
-	// Nullable array ["array","null"] -> []string (Go slices are nilable).
-	assertContains(t, code, `Tags []string `+"`"+`json:"tags"`+"`")
+	// Nullable type arrays on a component schema -> ginx.Optional of the
+	// non-null Go type, like nullable request body properties.
+	assertContains(t, code, `Nickname ginx.Optional[string] `+"`"+`json:"nickname,omitzero"`+"`")
+	assertContains(t, code, `Age ginx.Optional[int] `+"`"+`json:"age,omitzero"`+"`")
+	assertContains(t, code, `Score ginx.Optional[float64] `+"`"+`json:"score,omitzero"`+"`")
+	assertContains(t, code, `Flag ginx.Optional[bool] `+"`"+`json:"flag,omitzero"`+"`")
+	assertContains(t, code, `Tags ginx.Optional[[]string] `+"`"+`json:"tags,omitzero"`+"`")
+	// A PATCH body that $refs an object schema is flattened, so its optional
+	// properties become ginx.Optional while the component keeps *T.
+	assertContains(t, code, `Age *int `+"`"+`json:"age" binding:"omitempty,gte=0"`+"`")
+	assertContains(t, code, "type PatchNullableReq struct {\n\tID int `uri:\"id\" binding:\"required\"`\n\tNickname ginx.Optional[string]")
+	assertContains(t, code, `Age ginx.Optional[int] `+"`"+`json:"age,omitzero" binding:"omitempty,gte=0"`+"`")
 	assertValidGo(t, code)
 }
 
@@ -1644,12 +1677,51 @@ func TestE2E_OAI31_Webhooks(t *testing.T) {
 	server := string(multi.Server)
 
 	// Webhook synthesized as a receiver route under /webhooks/<name>.
-	assertContains(t, server, `ginx.POST(r, "/webhooks/ordercreated", s.HandleOrderCreated, opts...)`)
+	assertContains(t, server, `ginx.POST(r, "/webhooks/ordercreated", s.HandleOrderCreated, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("handleOrderCreated"))...)`)
 	assertContains(t, server, "HandleOrderCreated(ctx context.Context, req *HandleOrderCreatedReq) (*HandleOrderCreatedRsp, error)")
 	assertValidGo(t, server)
 	assertValidGo(t, string(multi.Client))
 }
 
+func TestE2E_WebhookSignature(t *testing.T) {
+	multi := generateMultiFileV(t, "openapi-3.1", "webhooks.yaml")
+	server := string(multi.Server)
+	assertContains(t, server, `"github.com/chendefine/ginx/webhook"`)
+	assertContains(t, server, "HandlePaymentSettledVerifier() *webhook.Verifier")
+	assertNotContains(t, server, "HandleOrderCreatedVerifier")
+	assertContains(t, server, `ginx.OperationID("handlePaymentSettled"), webhook.VerifySignature(webhook.StandardWebhooks, s.HandlePaymentSettledVerifier()), ginx.RouteInterceptor(webhook.Interceptor(webhook.StandardWebhooks, s.HandlePaymentSettledVerifier())))...)`)
+
+	single := generateSingleFileV(t, "openapi-3.1", "webhooks.yaml")
+	assertContains(t, single, `"github.com/chendefine/ginx/webhook"`)
+	assertContains(t, single, "HandlePaymentSettledVerifier() *webhook.Verifier")
+	assertValidGo(t, single)
+
+	spec, err := os.ReadFile(specPath("openapi-3.1", "webhooks.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for with, want := range map[string]string{
+		"x-ginx-webhook-signature: HMAC-SHA256": "webhook.VerifySignature(webhook.HMACSHA256, s.HandlePaymentSettledVerifier())",
+		"x-ginx-webhook-signature: md5":         "x-ginx-webhook-signature=md5 is unsupported",
+	} {
+		path := filepath.Join(t.TempDir(), "webhooks.yaml")
+		if err := os.WriteFile(path, []byte(strings.Replace(string(spec), "x-ginx-webhook-signature: standard-webhooks", with, 1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		res, err := GenerateMulti(Config{PackageName: "api", SpecPath: path, Output: OutputConfig{Types: "types.go", Server: "server.go"}})
+		if strings.Contains(want, "unsupported") {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Fatalf("GenerateMulti error = %v, want %q", err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertContains(t, string(res.Server), want)
+	}
+}
+
 func TestE2E_OAI31_DefsAndRefs(t *testing.T) {
 	code := generateSingleFileV(t, "openapi-3.1", "defs_and_refs.yaml")
 
@@ -1662,6 +1734,29 @@ func TestE2E_OAI31_DefsAndRefs(t *testing.T) {
 	assertValidGo(t, code)
 }
 
+func TestE2E_OAI31_DependentRequired(t *testing.T) {
+	multi := generateMultiFileV(t, "openapi-3.1", "dependent_required.yaml")
+	types := string(multi.Types)
+
+	// dependentRequired on a component and on an inline request body becomes
+	// a Validate method; the ref-embedding Req inherits it by promotion.
+	assertContains(t, types, "func (r *Payment) Validate(ctx context.Context) error {")
+	assertContains(t, types, "func (r *CreateBookingReq) Validate(ctx context.Context) error {")
+	assertContains(t, types, `if r.CardNumber != nil && r.Cvv == nil {`)
+	assertContains(t, types, `ginx.FieldError{Field: "check_in", Message: "is required when check_out is present"}`)
+	// A required nullable property becomes ginx.Optional without a
+	// `required` binding, so Validate keeps its presence check.
+	assertContains(t, types, "GuestNote ginx.Optional[string] `json:\"guest_note,omitzero\"`")
+	assertContains(t, types, `if !r.GuestNote.IsSet() {`)
+	assertContains(t, types, `ginx.FieldError{Field: "guest_note", Message: "is required"}`)
+	assertContains(t, types, "type CreatePaymentReq struct {\n\tPayment")
+	// room is required and non-pointer, so coupon -> room cannot be violated.
+	assertNotContains(t, types, `Field: "room"`)
+	assertContains(t, types, `"context"`)
+	assertValidGo(t, types)
+	assertNotContains(t, generateSingleFile(t, "validation.yaml"), "Validate(ctx")
+}
+
 // ============================================================
 // Module 5d: OpenAPI 3.2 (SSE + JSON Lines + querystring)
 //
@@ -1685,7 +1780,7 @@ func TestE2E_OAI32_VersionLoadsAndGenerates(t *testing.T) {
 func TestE2E_OAI32_SSEUnderDoc(t *testing.T) {
 	server := string(generateMultiFileV(t, "openapi-3.2", "sse_operations.yaml").Server)
 	assertContains(t, server, "StreamEvents(ctx context.Context, req *StreamEventsReq, send ginx.Sender) error")
-	assertContains(t, server, `ginx.SSE(r, "/events/stream", s.StreamEvents, opts...)`)
+	assertContains(t, server, `ginx.SSE(r, "/events/stream", s.StreamEvents, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("streamEvents"))...)`)
 }
 
 func TestE2E_OAI32_JSONLinesItemSchemaPreserved(t *testing.T) {
@@ -1745,8 +1840,8 @@ func TestE2E_OAI32_JSONLinesServer(t *testing.T) {
 	// ginx.JSONLines streaming handlers, NOT FileRsp binary handlers.
 	assertContains(t, server, "TailLogs(ctx context.Context, req *TailLogsReq, send ginx.JSONLinesSender) error")
 	assertContains(t, server, "IngestBatch(ctx context.Context, req *IngestBatchReq, send ginx.JSONLinesSender) error")
-	assertContains(t, server, `ginx.JSONLines(r, "GET", "/logs/:source/tail", s.TailLogs, opts...)`)
-	assertContains(t, server, `ginx.JSONLines(r, "POST", "/ingest", s.IngestBatch, opts...)`)
+	assertContains(t, server, `ginx.JSONLines(r, "GET", "/logs/:source/tail", s.TailLogs, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("tailLogs"))...)`)
+	assertContains(t, server, `ginx.JSONLines(r, "POST", "/ingest", s.IngestBatch, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("ingestBatch"))...)`)
 	assertNotContains(t, server, "ginx.FileRsp")
 }
 
@@ -1754,8 +1849,8 @@ func TestE2E_OAI32_JSONLinesSingleFileServer(t *testing.T) {
 	code := generateSingleFileV(t, "openapi-3.2", "jsonlines.yaml")
 	assertContains(t, code, "TailLogs(ctx context.Context, req *TailLogsReq, send ginx.JSONLinesSender) error")
 	assertContains(t, code, "IngestBatch(ctx context.Context, req *IngestBatchReq, send ginx.JSONLinesSender) error")
-	assertContains(t, code, `ginx.JSONLines(r, "GET", "/logs/:source/tail", s.TailLogs, opts...)`)
-	assertContains(t, code, `ginx.JSONLines(r, "POST", "/ingest", s.IngestBatch, opts...)`)
+	assertContains(t, code, `ginx.JSONLines(r, "GET", "/logs/:source/tail", s.TailLogs, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("tailLogs"))...)`)
+	assertContains(t, code, `ginx.JSONLines(r, "POST", "/ingest", s.IngestBatch, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("ingestBatch"))...)`)
 	assertValidGo(t, code)
 }
 
@@ -1766,7 +1861,9 @@ func TestE2E_OAI32_JSONLinesClient(t *testing.T) {
 	// The streaming client must opt out of response buffering.
 	assertContains(t, client, ".SetResponseDoNotParse(true)")
 	assertContains(t, client, "ginx.ValidateResponseStatus(resp.StatusCode(), 200)")
-	assertContains(t, client, "ginx.NewJSONLinesStream(ctx, resp.Body)")
+	assertContains(t, client, "ginx.NewJSONLinesStream(ctx, resp.Body).WithCodec(c.codec)")
+	assertContains(t, client, "ginx.ParseResponseWith(c.codec, resp.StatusCode(), body, nil)")
+	assertContains(t, client, "WithCodec(codec ginx.Codec) *Client")
 }
 
 func TestE2E_OAI32_JSONLinesMediaTypesNotBinary(t *testing.T) {
@@ -1864,10 +1961,10 @@ func TestE2E_Server_RegisterRoutes(t *testing.T) {
 	server := string(result.Server)
 
 	assertContains(t, server, "func RegisterRoutes(r gin.IRoutes, s ServerInterface, opts ...ginx.RouteOption)")
-	assertContains(t, server, `ginx.GET(r, "/pets", s.ListPets, opts...)`)
-	assertContains(t, server, `ginx.POST(r, "/pets", s.CreatePet, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(201))...)`)
-	assertContains(t, server, `ginx.GET(r, "/pets/:pet_id", s.GetPet, opts...)`)
-	assertContains(t, server, `ginx.DELETE(r, "/pets/:pet_id", s.DeletePet, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204))...)`)
+	assertContains(t, server, `ginx.GET(r, "/pets", s.ListPets, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("listPets"))...)`)
+	assertContains(t, server, `ginx.POST(r, "/pets", s.CreatePet, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(201), ginx.OperationID("createPet"))...)`)
+	assertContains(t, server, `ginx.GET(r, "/pets/:pet_id", s.GetPet, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("getPet"))...)`)
+	assertContains(t, server, `ginx.DELETE(r, "/pets/:pet_id", s.DeletePet, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204), ginx.OperationID("deletePet"))...)`)
 }
 
 func TestE2E_Server_SSEHandler(t *testing.T) {
@@ -1875,7 +1972,7 @@ func TestE2E_Server_SSEHandler(t *testing.T) {
 	server := string(result.Server)
 
 	assertContains(t, server, "StreamEvents(ctx context.Context, req *StreamEventsReq, send ginx.Sender) error")
-	assertContains(t, server, `ginx.SSE(r, "/events", s.StreamEvents, opts...)`)
+	assertContains(t, server, `ginx.SSE(r, "/events", s.StreamEvents, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("streamEvents"))...)`)
 }
 
 func TestE2E_Server_SSEViaContentType(t *testing.T) {
@@ -1883,7 +1980,7 @@ func TestE2E_Server_SSEViaContentType(t *testing.T) {
 	server := string(result.Server)
 
 	assertContains(t, server, "StreamNotifications(ctx context.Context, req *StreamNotificationsReq, send ginx.Sender) error")
-	assertContains(t, server, `ginx.SSE(r, "/notifications", s.StreamNotifications, opts...)`)
+	assertContains(t, server, `ginx.SSE(r, "/notifications", s.StreamNotifications, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("streamNotifications"))...)`)
 }
 
 func TestE2E_Server_SSERejectsNon200Success(t *testing.T) {
@@ -1956,6 +2053,160 @@ paths:
 	}
 }
 
+func TestE2E_Server_TimeoutExtension(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "timeout.yaml")
+	spec := `openapi: 3.0.3
+info:
+  title: timeouts
+  version: 1.0.0
+paths:
+  /reports:
+    post:
+      operationId: buildReport
+      x-ginx-timeout: 1m30s
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id:
+                    type: string
+  /quick:
+    get:
+      operationId: quick
+      x-ginx-timeout: 0.5
+      responses:
+        "200":
+          description: ok
+  /events:
+    get:
+      operationId: tailEvents
+      x-ginx-timeout: 30s
+      responses:
+        "200":
+          description: stream
+          content:
+            application/x-ndjson:
+              schema:
+                type: string
+`
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	result, err := GenerateMulti(Config{PackageName: "api", SpecPath: path, Output: OutputConfig{Types: "types.go", Server: "server.go"}})
+	if err != nil {
+		t.Fatalf("GenerateMulti: %v", err)
+	}
+	server := string(result.Server)
+	assertContains(t, server, `"time"`)
+	assertContains(t, server, `ginx.SuccessStatus(201), ginx.OperationID("buildReport"), ginx.Timeout(90*time.Second)`)
+	assertContains(t, server, `ginx.Timeout(500*time.Millisecond)`)
+	assertContains(t, server, `ginx.IdleTimeout(30*time.Second)`)
+	assertNotContains(t, string(result.Types), `"time"`)
+
+	invalid := filepath.Join(dir, "timeout-invalid.yaml")
+	if err := os.WriteFile(invalid, []byte(strings.Replace(spec, "1m30s", "soon", 1)), 0o644); err != nil {
+		t.Fatalf("write spec: %v", err)
+	}
+	if _, err := GenerateMulti(Config{PackageName: "api", SpecPath: invalid, Output: OutputConfig{Types: "types.go", Server: "server.go"}}); err == nil || !strings.Contains(err.Error(), `x-ginx-timeout="soon"`) {
+		t.Fatalf("GenerateMulti error = %v, want invalid x-ginx-timeout", err)
+	}
+}
+
+func TestE2E_Pagination(t *testing.T) {
+	generateClient := true
+	single := generateSingleFileV(t, "openapi-3.1", "pagination.yaml", func(cfg *Config) {
+		cfg.OutputOptions.GenerateClient = &generateClient
+	})
+	assertContains(t, single, `"iter"`)
+	assertContains(t, single, "type ListUsersReq struct {\n\tginx.PageReq")
+	assertContains(t, single, "type ListUsersRsp = ginx.Page[User]")
+	assertContains(t, single, "ListUsersIter(ctx context.Context, req *ListUsersReq) iter.Seq2[User, error]")
+	assertContains(t, single, "ginx.OffsetPages(ctx, req.PageReq")
+	assertNotContains(t, single, `SetQueryParam("cursor"`)
+	assertContains(t, single, `ginx.OperationID("listUsers"), ginx.FieldMask()`)
+	assertContains(t, single, "Fields *string `form:\"fields\"`")
+
+	dir := t.TempDir()
+	spec, err := os.ReadFile(specPath("openapi-3.1", "pagination.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tc := range map[string]struct{ replace, with, want string }{
+		"mode":   {"x-ginx-pagination: cursor", "x-ginx-pagination: keyset", "x-ginx-pagination=keyset is unsupported"},
+		"fields": {"x-ginx-fields: true", "x-ginx-fields: sometimes", "x-ginx-fields must be a boolean"},
+		"items": {
+			"                  items:\n                    type: array\n                    items:\n                      $ref: \"#/components/schemas/User\"",
+			"                  data:\n                    type: string",
+			"must have an items array property",
+		},
+	} {
+		if !strings.Contains(string(spec), tc.replace) {
+			t.Fatalf("%s: fixture does not contain %q", name, tc.replace)
+		}
+		path := filepath.Join(dir, name+".yaml")
+		if err := os.WriteFile(path, []byte(strings.Replace(string(spec), tc.replace, tc.with, 1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := GenerateMulti(Config{PackageName: "api", SpecPath: path}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: GenerateMulti error = %v, want %q", name, err, tc.want)
+		}
+	}
+}
+
+func TestE2E_PatchDocuments(t *testing.T) {
+	generateClient := true
+	single := generateSingleFile(t, "client_sdk.yaml", func(cfg *Config) {
+		cfg.OutputOptions.GenerateClient = &generateClient
+	})
+	assertContains(t, single, "MergeItemAttributes(ctx context.Context, req *MergeItemAttributesReq, patched *ItemAttributes) (*MergeItemAttributesRsp, error)")
+	assertContains(t, single, "LoadPatchItemTags(ctx context.Context, req *PatchItemTagsReq) (*PatchItemTagsRsp, error)")
+	assertContains(t, single, `ginx.MergePatch(r, "/items/:item_id/attributes", s.LoadMergeItemAttributes, s.MergeItemAttributes,`)
+	assertContains(t, single, `ginx.JSONPatch(r, "/items/:item_id/tags", s.LoadPatchItemTags, s.PatchItemTags,`)
+	assertContains(t, single, "Patch []ginx.PatchOperation `json:\"-\"`")
+	assertContains(t, single, `r.SetHeader("Content-Type", ginx.MergePatchContentType)`)
+	assertValidGo(t, single)
+
+	dir := t.TempDir()
+	spec, err := os.ReadFile(testdataPath("client_sdk.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mergeBody := "          application/merge-patch+json:\n            schema:\n              $ref: \"#/components/schemas/ItemAttributes\""
+	for name, tc := range map[string]struct{ replace, with, want string }{
+		"both": {mergeBody, mergeBody + "\n          application/json-patch+json:\n            schema:\n              type: array\n              items:\n                type: object", "declares both application/merge-patch+json and application/json-patch+json"},
+		"json": {mergeBody, mergeBody + "\n          application/json:\n            schema:\n              $ref: \"#/components/schemas/ItemAttributes\"", ""},
+		"no-resource": {
+			"      responses:\n        \"200\":\n          description: ok\n          content:\n            application/json:\n              schema:\n                $ref: \"#/components/schemas/ItemTags\"",
+			"      responses:\n        \"204\":\n          description: patched",
+			"need a $ref body schema or a JSON success response",
+		},
+	} {
+		if !strings.Contains(string(spec), tc.replace) {
+			t.Fatalf("%s: fixture does not contain %q", name, tc.replace)
+		}
+		path := filepath.Join(dir, name+".yaml")
+		if err := os.WriteFile(path, []byte(strings.Replace(string(spec), tc.replace, tc.with, 1)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		result, err := GenerateMulti(Config{PackageName: "api", SpecPath: path})
+		if tc.want == "" {
+			if err != nil {
+				t.Fatalf("%s: GenerateMulti: %v", name, err)
+			}
+			assertNotContains(t, string(result.Server), "ginx.MergePatch(")
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: GenerateMulti error = %v, want %q", name, err, tc.want)
+		}
+	}
+}
+
 func TestE2E_Server_PathConversion(t *testing.T) {
 	result := generateMultiFile(t, "server_interface.yaml")
 	server := string(result.Server)
@@ -1997,6 +2248,8 @@ func TestE2E_Client_StructAndConstructor(t *testing.T) {
 	assertContains(t, client, "client *resty.Client")
 	assertContains(t, client, "func NewClient(baseURL string, opts ...ClientOption) *Client")
 	assertContains(t, client, "type ClientOption func(*resty.Client)")
+	assertContains(t, client, "c.AddRequestMiddleware(ginx.PropagateRequestID)")
+	assertContains(t, client, "c.AddRequestMiddleware(ginx.IdempotencyKeyOnRetry)")
 }
 
 func TestE2E_Client_PathParams(t *testing.T) {
@@ -2181,10 +2434,10 @@ func TestE2E_SSE_RouteRegistration(t *testing.T) {
 	result := generateMultiFile(t, "sse_operations.yaml")
 	server := string(result.Server)
 
-	assertContains(t, server, `ginx.SSE(r, "/events/stream", s.StreamEvents, opts...)`)
-	assertContains(t, server, `ginx.SSE(r, "/rooms/:room_id/messages", s.StreamRoomMessages, opts...)`)
-	assertContains(t, server, `ginx.SSE(r, "/notifications", s.StreamNotifications, opts...)`)
-	assertContains(t, server, `ginx.SSE(r, "/metrics", s.StreamMetrics, opts...)`)
+	assertContains(t, server, `ginx.SSE(r, "/events/stream", s.StreamEvents, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("streamEvents"))...)`)
+	assertContains(t, server, `ginx.SSE(r, "/rooms/:room_id/messages", s.StreamRoomMessages, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("streamRoomMessages"))...)`)
+	assertContains(t, server, `ginx.SSE(r, "/notifications", s.StreamNotifications, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("streamNotifications"))...)`)
+	assertContains(t, server, `ginx.SSE(r, "/metrics", s.StreamMetrics, append(append([]ginx.RouteOption(nil), opts...), ginx.OperationID("streamMetrics"))...)`)
 }
 
 func TestE2E_SSE_ClientInterface(t *testing.T) {
@@ -2217,6 +2470,7 @@ func TestE2E_SSE_ClientHeaderParam(t *testing.T) {
 	client := string(result.Client)
 
 	assertContains(t, client, `es.SetHeader("X-Auth-Token"`)
+	assertContains(t, client, "es.SetHeader(ginx.RequestIDHeader, id)")
 }
 
 func TestE2E_SSE_ClientImports(t *testing.T) {
@@ -2608,8 +2862,8 @@ paths:
 	server := string(result.Server)
 	client := string(result.Client)
 	assertContains(t, server, "HeadPing(ctx context.Context, req *HeadPingReq) (*struct{}, error)")
-	assertContains(t, server, `ginx.HEAD(r, "/ping", s.HeadPing, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204))...)`)
-	assertContains(t, server, `ginx.OPTIONS(r, "/ping", s.OptionsPing, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204))...)`)
+	assertContains(t, server, `ginx.HEAD(r, "/ping", s.HeadPing, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204), ginx.OperationID("headPing"))...)`)
+	assertContains(t, server, `ginx.OPTIONS(r, "/ping", s.OptionsPing, append(append([]ginx.RouteOption(nil), opts...), ginx.SuccessStatus(204), ginx.OperationID("optionsPing"))...)`)
 	assertContains(t, server, "TypedHead(ctx context.Context, req *TypedHeadReq) (*TypedHeadRsp, error)")
 	assertContains(t, client, "TypedHead(ctx context.Context, req *TypedHeadReq) error")
 	assertContains(t, client, `resp, err := r.Head("/ping")`)
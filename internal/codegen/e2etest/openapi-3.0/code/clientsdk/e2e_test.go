@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/chendefine/ginx"
@@ -118,6 +119,108 @@ func TestUpdateItem(t *testing.T) {
 	}
 }
 
+func TestPatchItemOptionalFields(t *testing.T) {
+	srv, client, svc := setupServer()
+	defer srv.Close()
+	defer svc.Cleanup()
+	ctx := context.Background()
+
+	price := float32(9.5)
+	created, _ := client.CreateItem(ctx, &CreateItemReq{
+		CreateItemInput: CreateItemInput{Name: "Old", Price: &price},
+		XIdempotencyKey: "idem",
+	})
+	id := int64(*created.ID)
+
+	// 只传 name: price 未出现, 保持不变.
+	if _, err := client.PatchItem(ctx, &PatchItemReq{ItemID: id, Name: ginx.Some("New")}); err != nil {
+		t.Fatalf("PatchItem: %v", err)
+	}
+	desc, _ := client.GetItemDescription(ctx, &GetItemDescriptionReq{ItemID: id})
+	if desc != "Item: New (9.50)" {
+		t.Fatalf("after name patch: %q", desc)
+	}
+
+	// price 显式 null: 清空; name 未出现, 保持不变.
+	if _, err := client.PatchItem(ctx, &PatchItemReq{ItemID: id, Price: ginx.Null[float32]()}); err != nil {
+		t.Fatalf("PatchItem: %v", err)
+	}
+	desc, _ = client.GetItemDescription(ctx, &GetItemDescriptionReq{ItemID: id})
+	if desc != "Item: New (0.00)" {
+		t.Fatalf("after null price: %q", desc)
+	}
+
+	// 出现的值仍按 schema 校验.
+	_, err := client.PatchItem(ctx, &PatchItemReq{ItemID: id, Name: ginx.Some("much-too-long")})
+	var apiErr *ginx.ErrWrap
+	if !errors.As(err, &apiErr) || apiErr.HttpCode != 400 {
+		t.Fatalf("empty name: %v", err)
+	}
+}
+
+func TestMergeItemAttributes(t *testing.T) {
+	srv, client, svc := setupServer()
+	defer srv.Close()
+	defer svc.Cleanup()
+	ctx := context.Background()
+
+	created, _ := client.CreateItem(ctx, &CreateItemReq{CreateItemInput: CreateItemInput{Name: "Box"}, XIdempotencyKey: "idem"})
+	id := int64(*created.ID)
+
+	got, err := client.MergeItemAttributes(ctx, &MergeItemAttributesReq{ItemID: id, Patch: map[string]any{"color": "red", "size": 3}})
+	if err != nil || got.Color != "red" || got.Size == nil || *got.Size != 3 {
+		t.Fatalf("merge = %+v %v", got, err)
+	}
+	// null 删除字段, 未出现的字段保持不变.
+	got, err = client.MergeItemAttributes(ctx, &MergeItemAttributesReq{ItemID: id, Patch: map[string]any{"size": nil}})
+	if err != nil || got.Color != "red" || got.Size != nil {
+		t.Fatalf("merge null = %+v %v", got, err)
+	}
+
+	// 修补结果按 schema 重新校验.
+	_, err = client.MergeItemAttributes(ctx, &MergeItemAttributesReq{ItemID: id, Patch: map[string]any{"color": nil}})
+	var apiErr *ginx.ErrWrap
+	if !errors.As(err, &apiErr) || apiErr.HttpCode != 422 {
+		t.Fatalf("required color: %v", err)
+	}
+	_, err = client.MergeItemAttributes(ctx, &MergeItemAttributesReq{ItemID: 999, Patch: map[string]any{}})
+	if !errors.As(err, &apiErr) || apiErr.HttpCode != 404 {
+		t.Fatalf("missing item: %v", err)
+	}
+}
+
+func TestPatchItemTags(t *testing.T) {
+	srv, client, svc := setupServer()
+	defer srv.Close()
+	defer svc.Cleanup()
+	ctx := context.Background()
+
+	created, _ := client.CreateItem(ctx, &CreateItemReq{CreateItemInput: CreateItemInput{Name: "Box"}, XIdempotencyKey: "idem"})
+	id := int64(*created.ID)
+
+	got, err := client.PatchItemTags(ctx, &PatchItemTagsReq{ItemID: id, Patch: []ginx.PatchOperation{
+		{Op: "add", Path: "/tags", Value: []string{"a"}},
+		{Op: "add", Path: "/tags/-", Value: "b"},
+		{Op: "add", Path: "/tags/0", Value: "z"},
+	}})
+	if err != nil || strings.Join(got.Tags, ",") != "z,a,b" {
+		t.Fatalf("patch = %+v %v", got, err)
+	}
+
+	_, err = client.PatchItemTags(ctx, &PatchItemTagsReq{ItemID: id, Patch: []ginx.PatchOperation{
+		{Op: "test", Path: "/tags/0", Value: "a"},
+		{Op: "remove", Path: "/tags/0"},
+	}})
+	var apiErr *ginx.ErrWrap
+	if !errors.As(err, &apiErr) || apiErr.HttpCode != 409 {
+		t.Fatalf("failed test op: %v", err)
+	}
+	_, err = client.PatchItemTags(ctx, &PatchItemTagsReq{ItemID: id, Patch: []ginx.PatchOperation{{Op: "remove", Path: "/tags/9"}}})
+	if !errors.As(err, &apiErr) || apiErr.HttpCode != 422 {
+		t.Fatalf("missing index: %v", err)
+	}
+}
+
 func TestDeleteItem(t *testing.T) {
 	srv, client, svc := setupServer()
 	defer srv.Close()
@@ -162,6 +265,26 @@ func TestGetItemNotFound(t *testing.T) {
 	}
 }
 
+func TestRequestIDRoundTrip(t *testing.T) {
+	svc := NewTestService()
+	defer svc.Cleanup()
+	r := gin.New()
+	RegisterRoutes(ginx.New(ginx.WithRequestID(nil)).Wrap(r), svc)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	client := NewClient(srv.URL)
+
+	ctx := ginx.ContextWithRequestID(context.Background(), "req-e2e-1")
+	_, err := client.GetItem(ctx, &GetItemReq{ItemID: 99999})
+	var apiErr *ginx.ErrWrap
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *ginx.ErrWrap, got %T", err)
+	}
+	if apiErr.RequestID != "req-e2e-1" {
+		t.Fatalf("RequestID = %q, want req-e2e-1", apiErr.RequestID)
+	}
+}
+
 func TestGetItemDescription(t *testing.T) {
 	srv, client, svc := setupServer()
 	defer srv.Close()
@@ -15,6 +15,8 @@ type itemData struct {
 	ID    int64
 	Name  string
 	Price float32
+	Attrs ItemAttributes
+	Tags  []string
 }
 
 type TestService struct {
@@ -92,6 +94,58 @@ func (s *TestService) UpdateItem(_ context.Context, req *UpdateItemReq) (*Update
 	return &UpdateItemRsp{Success: &b}, nil
 }
 
+func (s *TestService) PatchItem(_ context.Context, req *PatchItemReq) (*PatchItemRsp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[req.ItemID]
+	if !ok {
+		return nil, ginx.Error(404, "not found").Status(http.StatusNotFound)
+	}
+	if name, ok := req.Name.Get(); ok {
+		item.Name = name
+	}
+	if req.Price.IsSet() {
+		item.Price = req.Price.Or(0)
+	}
+	b := true
+	return &PatchItemRsp{Success: &b}, nil
+}
+
+func (s *TestService) LoadMergeItemAttributes(_ context.Context, req *MergeItemAttributesReq) (*ItemAttributes, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.items[req.ItemID]
+	if !ok {
+		return nil, ginx.Error(404, "not found").Status(http.StatusNotFound)
+	}
+	attrs := item.Attrs
+	return &attrs, nil
+}
+
+func (s *TestService) MergeItemAttributes(_ context.Context, req *MergeItemAttributesReq, patched *ItemAttributes) (*MergeItemAttributesRsp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[req.ItemID].Attrs = *patched
+	return patched, nil
+}
+
+func (s *TestService) LoadPatchItemTags(_ context.Context, req *PatchItemTagsReq) (*ItemTags, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.items[req.ItemID]
+	if !ok {
+		return nil, ginx.Error(404, "not found").Status(http.StatusNotFound)
+	}
+	return &ItemTags{Tags: append([]string(nil), item.Tags...)}, nil
+}
+
+func (s *TestService) PatchItemTags(_ context.Context, req *PatchItemTagsReq, patched *ItemTags) (*PatchItemTagsRsp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[req.ItemID].Tags = patched.Tags
+	return patched, nil
+}
+
 func (s *TestService) DeleteItem(_ context.Context, req *DeleteItemReq) (*struct{}, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
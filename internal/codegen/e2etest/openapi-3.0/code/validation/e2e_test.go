@@ -3,7 +3,10 @@ package validation
 import (
 	"context"
 	"errors"
+	"io"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/chendefine/ginx"
@@ -37,6 +40,9 @@ func TestCreateValidated_Success(t *testing.T) {
 		Ipv6Field:     strPtr("::1"),
 		HostnameField: strPtr("example.com"),
 		Phone:         strPtr("+14155552671"),
+		Sku:           strPtr("ABC-1234"),
+		Quantity:      intPtr(15),
+		Price:         floatPtr(19.99),
 	})
 	if err != nil {
 		t.Fatalf("CreateValidated: %v", err)
@@ -110,6 +116,46 @@ func TestCreateValidated_ScoreOutOfRange(t *testing.T) {
 	}
 }
 
+func TestCreateValidated_PatternAndMultipleOf(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		req  CreateValidatedReq
+		msg  string
+	}{
+		{"pattern", CreateValidatedReq{Sku: strPtr("abc-1234")}, `sku must match pattern ^[A-Z]{3}-\d{4}$`},
+		{"integer multipleOf", CreateValidatedReq{Quantity: intPtr(7)}, "quantity must be a multiple of 5"},
+		{"decimal multipleOf", CreateValidatedReq{Price: floatPtr(1.005)}, "price must be a multiple of 0.01"},
+	}
+	for _, tt := range tests {
+		tt.req.Name, tt.req.Email, tt.req.Status, tt.req.Score = "test", "user@example.com", "active", 50
+		_, err := client.CreateValidated(context.Background(), &tt.req)
+		var apiErr *ginx.ErrWrap
+		if !errors.As(err, &apiErr) || apiErr.Msg != tt.msg {
+			t.Fatalf("%s: err = %v, want %q", tt.name, err, tt.msg)
+		}
+	}
+}
+
+func TestCreateValidated_UnknownField(t *testing.T) {
+	srv, _ := setupServer()
+	defer srv.Close()
+
+	body := `{"name":"test","email":"user@example.com","status":"active","score":50,"nmae":"typo"}`
+	resp, err := http.Post(srv.URL+"/validate", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+	var apiErr *ginx.ErrWrap
+	if err := ginx.ParseResponse(resp.StatusCode, raw, nil); !errors.As(err, &apiErr) || apiErr.HttpCode != http.StatusBadRequest || apiErr.Msg != `unknown field "nmae"` {
+		t.Fatalf("expected unknown field error, got %v (%s)", err, raw)
+	}
+}
+
 func TestListWithDefaults(t *testing.T) {
 	srv, client := setupServer()
 	defer srv.Close()
@@ -124,3 +170,7 @@ func TestListWithDefaults(t *testing.T) {
 }
 
 func strPtr(s string) *string { return &s }
+
+func intPtr(n int) *int { return &n }
+
+func floatPtr(f float64) *float64 { return &f }
@@ -0,0 +1,69 @@
+package dependentrequired
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chendefine/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+func init() { gin.SetMode(gin.TestMode) }
+
+func setupServer() (*httptest.Server, *Client) {
+	r := gin.New()
+	RegisterRoutes(r, &TestService{})
+	srv := httptest.NewServer(r)
+	return srv, NewClient(srv.URL)
+}
+
+func TestCreateBooking_DependentRequired(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  *CreateBookingReq
+		msg  string
+	}{
+		{"no triggers", &CreateBookingReq{Room: "101", GuestNote: ginx.Null[string]()}, ""},
+		{"satisfied", &CreateBookingReq{Room: "101", GuestNote: ginx.Some("late"), CheckIn: strPtr("2026-01-01"), CheckOut: strPtr("2026-01-03"), Coupon: strPtr("X"), Tags: []string{"vip"}}, ""},
+		{"required nullable absent", &CreateBookingReq{Room: "101"}, "guest_note is required"},
+		{"check_out without check_in", &CreateBookingReq{Room: "101", GuestNote: ginx.Null[string](), CheckOut: strPtr("2026-01-03")}, "check_in is required when check_out is present"},
+		{"both rules", &CreateBookingReq{Room: "101", GuestNote: ginx.Null[string](), CheckOut: strPtr("2026-01-03"), Coupon: strPtr("X")},
+			"check_in is required when check_out is present; tags is required when coupon is present"},
+	}
+	for _, tt := range tests {
+		rsp, err := client.CreateBooking(ctx, tt.req)
+		if tt.msg == "" {
+			if err != nil || rsp.ID == nil || *rsp.ID != "booking-101" {
+				t.Fatalf("%s: %+v %v", tt.name, rsp, err)
+			}
+			continue
+		}
+		var apiErr *ginx.ErrWrap
+		if !errors.As(err, &apiErr) || apiErr.HttpCode != http.StatusBadRequest || apiErr.Msg != tt.msg {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestCreatePayment_EmbeddedRefValidate(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	rsp, err := client.CreatePayment(ctx, &CreatePaymentReq{Payment: Payment{Method: "cash"}})
+	if err != nil || *rsp.ID != "payment-cash" {
+		t.Fatalf("cash: %+v %v", rsp, err)
+	}
+	_, err = client.CreatePayment(ctx, &CreatePaymentReq{Payment: Payment{Method: "card", CardNumber: strPtr("4111"), Cvv: strPtr("123")}})
+	var apiErr *ginx.ErrWrap
+	if !errors.As(err, &apiErr) || apiErr.Msg != "billing_zip is required when card_number is present" {
+		t.Fatalf("card without zip: %v", err)
+	}
+}
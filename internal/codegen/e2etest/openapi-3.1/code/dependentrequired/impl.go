@@ -0,0 +1,21 @@
+package dependentrequired
+
+import (
+	"context"
+)
+
+type TestService struct{}
+
+func (s *TestService) CreateBooking(_ context.Context, req *CreateBookingReq) (*CreateBookingRsp, error) {
+	id := "booking-" + req.Room
+	return &CreateBookingRsp{ID: &id}, nil
+}
+
+func (s *TestService) CreatePayment(_ context.Context, req *CreatePaymentReq) (*CreatePaymentRsp, error) {
+	id := "payment-" + req.Method
+	return &CreatePaymentRsp{ID: &id}, nil
+}
+
+var _ ServerInterface = (*TestService)(nil)
+
+func strPtr(s string) *string { return &s }
@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/chendefine/ginx"
 	"github.com/gin-gonic/gin"
 )
 
@@ -28,20 +29,46 @@ func TestGetNullable_NullableTypeArrays(t *testing.T) {
 	if rsp.ID != 7 {
 		t.Errorf("ID = %d, want 7", rsp.ID)
 	}
-	if rsp.Nickname == nil || *rsp.Nickname != "ace" {
-		t.Errorf("Nickname = %v, want \"ace\"", rsp.Nickname)
+	if v, ok := rsp.Nickname.Get(); !ok || v != "ace" {
+		t.Errorf("Nickname = %+v, want \"ace\"", rsp.Nickname)
 	}
-	if rsp.Age == nil || *rsp.Age != 30 {
-		t.Errorf("Age = %v, want 30", rsp.Age)
+	if v, ok := rsp.Age.Get(); !ok || v != 30 {
+		t.Errorf("Age = %+v, want 30", rsp.Age)
 	}
-	if rsp.Flag == nil || !*rsp.Flag {
-		t.Errorf("Flag = %v, want true", rsp.Flag)
+	if v, ok := rsp.Flag.Get(); !ok || !v {
+		t.Errorf("Flag = %+v, want true", rsp.Flag)
 	}
-	if rsp.Score == nil || *rsp.Score != 9.5 {
-		t.Errorf("Score = %v, want 9.5", rsp.Score)
+	// An explicit null stays distinguishable from an absent field.
+	if !rsp.Score.IsSet() || !rsp.Score.IsNull() {
+		t.Errorf("Score = %+v, want null", rsp.Score)
 	}
-	// Nullable array ["array","null"] -> []string (not *any).
-	if len(rsp.Tags) != 2 || rsp.Tags[0] != "a" || rsp.Tags[1] != "b" {
-		t.Errorf("Tags = %v, want [a b]", rsp.Tags)
+	if v, ok := rsp.Tags.Get(); !ok || len(v) != 2 || v[0] != "a" || v[1] != "b" {
+		t.Errorf("Tags = %+v, want [a b]", rsp.Tags)
+	}
+}
+
+func TestPatchNullable_RefBody(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+
+	// age absent keeps the current value, nickname null clears it.
+	rsp, err := client.PatchNullable(context.Background(), &PatchNullableReq{ID: 9, Nickname: ginx.Null[string]()})
+	if err != nil {
+		t.Fatalf("PatchNullable: %v", err)
+	}
+	if rsp.ID != 9 || !rsp.Nickname.IsNull() || rsp.Age.Or(0) != 30 {
+		t.Errorf("null nickname = %+v", rsp)
+	}
+
+	rsp, err = client.PatchNullable(context.Background(), &PatchNullableReq{ID: 9, Age: ginx.Some(41)})
+	if err != nil {
+		t.Fatalf("PatchNullable: %v", err)
+	}
+	if rsp.Nickname.Or("") != "ace" || rsp.Age.Or(0) != 41 {
+		t.Errorf("age only = %+v", rsp)
+	}
+
+	if _, err := client.PatchNullable(context.Background(), &PatchNullableReq{ID: 9, Age: ginx.Some(-1)}); err == nil {
+		t.Error("negative age passed validation")
 	}
 }
@@ -2,6 +2,8 @@ package nullabletypes
 
 import (
 	"context"
+
+	"github.com/chendefine/ginx"
 )
 
 type TestService struct{}
@@ -9,21 +11,35 @@ type TestService struct{}
 func NewTestService() *TestService { return &TestService{} }
 
 // GetNullable returns a sample exercising OpenAPI 3.1 nullable type arrays
-// (["string","null"], ["integer","null"], etc.), which generate pointer fields,
-// plus a nullable array (["array","null"]) which generates []string.
+// (["string","null"], ["integer","null"], etc.), which generate ginx.Optional
+// fields; score is sent as an explicit null.
 func (s *TestService) GetNullable(_ context.Context, _ *GetNullableReq) (*GetNullableRsp, error) {
-	nick := "ace"
-	age := 30
-	flag := true
-	score := 9.5
+	return sample(), nil
+}
+
+// PatchNullable applies a $ref PATCH body: absent fields keep the sample's
+// values, a null nickname clears it.
+func (s *TestService) PatchNullable(_ context.Context, req *PatchNullableReq) (*PatchNullableRsp, error) {
+	rsp := sample()
+	rsp.ID = req.ID
+	if req.Nickname.IsSet() {
+		rsp.Nickname = req.Nickname
+	}
+	if age, ok := req.Age.Get(); ok {
+		rsp.Age = ginx.Some(age)
+	}
+	return rsp, nil
+}
+
+func sample() *NullableSample {
 	return &NullableSample{
 		ID:       7,
-		Nickname: &nick,
-		Age:      &age,
-		Flag:     &flag,
-		Score:    &score,
-		Tags:     []string{"a", "b"},
-	}, nil
+		Nickname: ginx.Some("ace"),
+		Age:      ginx.Some(30),
+		Flag:     ginx.Some(true),
+		Score:    ginx.Null[float64](),
+		Tags:     ginx.Some([]string{"a", "b"}),
+	}
 }
 
 var _ ServerInterface = (*TestService)(nil)
@@ -0,0 +1,111 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chendefine/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+func init() { gin.SetMode(gin.TestMode) }
+
+func setupServer() (*httptest.Server, *Client) {
+	r := gin.New()
+	RegisterRoutes(r, NewTestService())
+	srv := httptest.NewServer(r)
+	return srv, NewClient(srv.URL)
+}
+
+func TestListUsersIter(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+
+	var ids []int
+	role := "member"
+	for u, err := range client.ListUsersIter(context.Background(), &ListUsersReq{PageReq: ginx.PageReq{Limit: 1}, Role: &role}) {
+		if err != nil {
+			t.Fatalf("ListUsersIter: %v", err)
+		}
+		ids = append(ids, u.ID)
+	}
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 3 || ids[2] != 5 {
+		t.Fatalf("ids = %v, want [1 3 5]", ids)
+	}
+}
+
+func TestListUsersLinkHeader(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+
+	page, err := client.ListUsers(context.Background(), &ListUsersReq{PageReq: ginx.PageReq{Limit: 2}})
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(page.Items) != 2 || page.NextCursor == "" {
+		t.Fatalf("page = %+v", page)
+	}
+	resp, err := http.Get(srv.URL + "/users?limit=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if link := resp.Header.Get("Link"); !strings.Contains(link, `rel="next"`) || !strings.Contains(link, "cursor=") {
+		t.Fatalf("Link = %q", link)
+	}
+
+	_, err = client.ListUsers(context.Background(), &ListUsersReq{PageReq: ginx.PageReq{Cursor: "forged"}})
+	var apiErr *ginx.ErrWrap
+	if !errors.As(err, &apiErr) || apiErr.HttpCode != http.StatusBadRequest {
+		t.Fatalf("forged cursor err = %v", err)
+	}
+}
+
+func TestListUsersFieldMask(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+
+	fields := "items.id"
+	page, err := client.ListUsers(context.Background(), &ListUsersReq{PageReq: ginx.PageReq{Limit: 2}, Fields: &fields})
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != 1 || page.Items[0].Role != "" || page.NextCursor != "" {
+		t.Fatalf("masked page = %+v", page)
+	}
+
+	unknown := "items.email"
+	_, err = client.ListUsers(context.Background(), &ListUsersReq{Fields: &unknown})
+	var apiErr *ginx.ErrWrap
+	if !errors.As(err, &apiErr) || apiErr.HttpCode != http.StatusBadRequest {
+		t.Fatalf("unknown field err = %v", err)
+	}
+}
+
+func TestListOrdersIter(t *testing.T) {
+	srv, client := setupServer()
+	defer srv.Close()
+
+	var ids []int
+	for o, err := range client.ListOrdersIter(context.Background(), &ListOrdersReq{PageReq: ginx.PageReq{Limit: 3}}) {
+		if err != nil {
+			t.Fatalf("ListOrdersIter: %v", err)
+		}
+		ids = append(ids, o.ID)
+	}
+	if len(ids) != 7 || ids[6] != 7 {
+		t.Fatalf("ids = %v", ids)
+	}
+
+	page, err := client.ListOrders(context.Background(), &ListOrdersReq{PageReq: ginx.PageReq{Offset: 5}})
+	if err != nil {
+		t.Fatalf("ListOrders: %v", err)
+	}
+	if page.Total == nil || *page.Total != 7 || len(page.Items) != 2 {
+		t.Fatalf("page = %+v", page)
+	}
+}
@@ -0,0 +1,63 @@
+package pagination
+
+import (
+	"context"
+
+	"github.com/chendefine/ginx"
+)
+
+type userCursor struct {
+	After int `json:"after"`
+}
+
+type TestService struct {
+	users  []User
+	orders int
+	codec  *ginx.CursorCodec
+}
+
+func NewTestService() *TestService {
+	svc := &TestService{orders: 7, codec: ginx.NewCursorCodec([]byte("e2e"))}
+	for i := 1; i <= 5; i++ {
+		role := "member"
+		if i%2 == 0 {
+			role = "admin"
+		}
+		svc.users = append(svc.users, User{ID: i, Role: role})
+	}
+	return svc
+}
+
+func (s *TestService) ListUsers(ctx context.Context, req *ListUsersReq) (*ListUsersRsp, error) {
+	var cur userCursor
+	if req.Cursor != "" {
+		if err := s.codec.Decode(req.Cursor, &cur); err != nil {
+			return nil, err
+		}
+	}
+	var items []User
+	last := cur.After
+	for _, u := range s.users {
+		if u.ID <= cur.After || (req.Role != nil && u.Role != *req.Role) {
+			continue
+		}
+		if len(items) == req.Limit {
+			next, err := s.codec.Encode(userCursor{After: last})
+			if err != nil {
+				return nil, err
+			}
+			return ginx.NewCursorPage(items, next), nil
+		}
+		items = append(items, u)
+		last = u.ID
+	}
+	return ginx.NewCursorPage(items, ""), nil
+}
+
+func (s *TestService) ListOrders(ctx context.Context, req *ListOrdersReq) (*ListOrdersRsp, error) {
+	var items []ListOrdersItem
+	for i := req.Offset; i < req.Offset+req.Limit && i < s.orders; i++ {
+		items = append(items, ListOrdersItem{ID: i + 1})
+	}
+	return ginx.NewOffsetPage(items, int64(s.orders)), nil
+}
@@ -2,19 +2,30 @@ package webhooks
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/chendefine/ginx"
+	"github.com/chendefine/ginx/webhook"
 	"github.com/gin-gonic/gin"
+	"resty.dev/v3"
 )
 
 func init() { gin.SetMode(gin.TestMode) }
 
 func setupServer() (*httptest.Server, *Client) {
+	srv, _, client := setupSignedServer()
+	return srv, client
+}
+
+func setupSignedServer() (*httptest.Server, *TestService, *Client) {
+	svc := NewTestService()
 	r := gin.New()
-	RegisterRoutes(r, NewTestService())
+	RegisterRoutes(r, svc)
 	srv := httptest.NewServer(r)
-	return srv, NewClient(srv.URL)
+	return srv, svc, NewClient(srv.URL)
 }
 
 func TestHandleOrderCreated_WebhookReceiver(t *testing.T) {
@@ -33,3 +44,32 @@ func TestHandleOrderCreated_WebhookReceiver(t *testing.T) {
 		t.Fatalf("expected received=true, got %+v", rsp)
 	}
 }
+
+func TestHandlePaymentSettled_SignedWebhook(t *testing.T) {
+	srv, svc, client := setupSignedServer()
+	defer srv.Close()
+
+	// Unsigned deliveries are rejected before the handler runs.
+	err := client.HandlePaymentSettled(context.Background(), &HandlePaymentSettledReq{PaymentID: "pay_1"})
+	var ew *ginx.ErrWrap
+	if !errors.As(err, &ew) || ew.HttpCode != http.StatusUnauthorized {
+		t.Fatalf("unsigned delivery: err = %v", err)
+	}
+	// The signature is checked before binding, so an unsigned invalid body is 401, not 400.
+	err = client.HandlePaymentSettled(context.Background(), &HandlePaymentSettledReq{})
+	if !errors.As(err, &ew) || ew.HttpCode != http.StatusUnauthorized {
+		t.Fatalf("unsigned invalid delivery: err = %v", err)
+	}
+
+	signer, err := webhook.NewSigner(webhook.StandardWebhooks, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := NewClient(srv.URL, func(c *resty.Client) { c.SetTransport(signer.Transport(nil)) })
+	if err := signed.HandlePaymentSettled(context.Background(), &HandlePaymentSettledReq{PaymentID: "pay_2"}); err != nil {
+		t.Fatalf("signed delivery: %v", err)
+	}
+	if len(svc.settled) != 1 || svc.settled[0] != "pay_2" {
+		t.Fatalf("settled = %v, want [pay_2]", svc.settled)
+	}
+}
@@ -2,11 +2,29 @@ package webhooks
 
 import (
 	"context"
+	"encoding/base64"
+	"sync"
+
+	"github.com/chendefine/ginx/webhook"
 )
 
-type TestService struct{}
+// testSecret is the Standard Webhooks secret shared with the signing client.
+var testSecret = "whsec_" + base64.StdEncoding.EncodeToString([]byte("e2e-webhook-secret"))
+
+type TestService struct {
+	verifier *webhook.Verifier
+
+	mu      sync.Mutex
+	settled []string
+}
 
-func NewTestService() *TestService { return &TestService{} }
+func NewTestService() *TestService {
+	v, err := webhook.NewVerifier(webhook.StandardWebhooks, []string{testSecret})
+	if err != nil {
+		panic(err)
+	}
+	return &TestService{verifier: v}
+}
 
 // HandleOrderCreated is an inbound webhook receiver generated from an OpenAPI
 // 3.1 top-level `webhooks` entry. The route is synthesized as
@@ -17,4 +35,15 @@ func (s *TestService) HandleOrderCreated(_ context.Context, req *HandleOrderCrea
 	return &HandleOrderCreatedRsp{Received: &received}, nil
 }
 
+// HandlePaymentSettled only runs after x-ginx-webhook-signature verification
+// has accepted the delivery.
+func (s *TestService) HandlePaymentSettled(_ context.Context, req *HandlePaymentSettledReq) (*struct{}, error) {
+	s.mu.Lock()
+	s.settled = append(s.settled, req.PaymentID)
+	s.mu.Unlock()
+	return nil, nil
+}
+
+func (s *TestService) HandlePaymentSettledVerifier() *webhook.Verifier { return s.verifier }
+
 var _ ServerInterface = (*TestService)(nil)
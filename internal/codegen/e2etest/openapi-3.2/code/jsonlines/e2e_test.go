@@ -89,3 +89,24 @@ func TestIngestBatch_NDJSONResponse(t *testing.T) {
 		}
 	}
 }
+
+func TestTailLogs_CompressedStream(t *testing.T) {
+	r := gin.New()
+	RegisterRoutes(ginx.New(ginx.WithCompression(ginx.CompressionMinSize(1))).Wrap(r), NewTestService())
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	stream, err := NewClient(srv.URL).TailLogs(context.Background(), &TailLogsReq{Source: "app"})
+	if err != nil {
+		t.Fatalf("TailLogs: %v", err)
+	}
+	defer stream.Close()
+
+	records, err := recvAll(stream)
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	if len(records) != 3 || records[2]["msg"] != "line 3" {
+		t.Fatalf("records = %v", records)
+	}
+}
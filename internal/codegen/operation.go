@@ -8,12 +8,14 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
 type OperationDef struct {
 	Name             string
+	OperationID      string
 	Comment          string
 	Method           string
 	Path             string
@@ -22,6 +24,14 @@ type OperationDef struct {
 	IsJSONLines      bool
 	IsNoBody         bool
 	SuccessStatus    int
+	Timeout          time.Duration
+	Pagination       string // "cursor" / "offset", from x-ginx-pagination
+	PageItemType     string
+	FieldMask        bool   // x-ginx-fields
+	ClosedBody       bool   // JSON request body schema sets additionalProperties: false
+	PatchKind        string // "merge" / "json", from a PATCH patch-document request body
+	PatchResource    string // resource type the patch document applies to
+	WebhookSignature string // "hmac-sha256" / "standard-webhooks", from x-ginx-webhook-signature
 	ExpectedStatuses []int
 	ResponseMode     string
 	RspTypeName      string
@@ -112,7 +122,11 @@ func buildOperationDef(method, path string, pathItem *openapi3.PathItem, op *ope
 	} else if err := validateOperationResponses(opName, method, path, op); err != nil {
 		return OperationDef{}, nil, err
 	}
-	reqStruct, reqExtra := buildRequestStruct(opName, pathItem, op, imports, seen)
+	pagination, err := operationPagination(op)
+	if err != nil {
+		return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %w", method, path, opName, err)
+	}
+	reqStruct, reqExtra := buildRequestStruct(opName, pathItem, op, pagination != "", strings.EqualFold(method, http.MethodPatch), imports, seen)
 
 	sse := isSSEOperation(op)
 	jl := isJSONLinesOperation(op)
@@ -133,11 +147,33 @@ func buildOperationDef(method, path string, pathItem *openapi3.PathItem, op *ope
 	if responseMode == "variants" && (sse || jl) {
 		return OperationDef{}, nil, fmt.Errorf("%s %s (%s): x-ginx-response-mode=variants does not support streaming responses", method, path, opName)
 	}
+	fieldMask, err := operationFieldMask(op)
+	if err != nil {
+		return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %w", method, path, opName, err)
+	}
+	if fieldMask {
+		if sse || jl || responseMode == "variants" {
+			return OperationDef{}, nil, fmt.Errorf("%s %s (%s): x-ginx-fields requires a single JSON success response", method, path, opName)
+		}
+		addFieldsParam(reqStruct)
+	}
+	if pagination != "" && (sse || jl || responseMode == "variants") {
+		return OperationDef{}, nil, fmt.Errorf("%s %s (%s): x-ginx-pagination requires a single JSON success response", method, path, opName)
+	}
+	timeout, err := operationTimeout(op)
+	if err != nil {
+		return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %w", method, path, opName, err)
+	}
+	webhookSignature, err := operationWebhookSignature(op)
+	if err != nil {
+		return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %w", method, path, opName, err)
+	}
 	rspTypeName := "struct{}"
 
 	var rspDef *TypeDef
 	var rspExtra []TypeDef
 	var variants []ResponseVariantDef
+	var pageItemType string
 	if responseMode == "variants" {
 		rspTypeName = opName + "Response"
 		variants, rspExtra = buildResponseVariants(opName, op, cfg.ShouldUnwrapEnvelope(), imports, seen)
@@ -147,7 +183,29 @@ func buildOperationDef(method, path string, pathItem *openapi3.PathItem, op *ope
 		if err := validateFileResponseContract(opName, method, path, op, rspTypeName); err != nil {
 			return OperationDef{}, nil, err
 		}
-		rspDef, rspExtra = buildResponseType(opName, op, cfg.ShouldUnwrapEnvelope(), imports, seen)
+		if pagination != "" {
+			rspDef, rspExtra, pageItemType, err = buildPageResponseType(opName, op, cfg.ShouldUnwrapEnvelope(), imports, seen)
+			if err != nil {
+				return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %w", method, path, opName, err)
+			}
+		} else {
+			rspDef, rspExtra = buildResponseType(opName, op, cfg.ShouldUnwrapEnvelope(), imports, seen)
+		}
+	}
+	patchKind, err := patchDocumentKind(method, op)
+	if err != nil {
+		return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %w", method, path, opName, err)
+	}
+	var patchResource string
+	if patchKind != "" {
+		if sse || jl || responseMode == "variants" || pagination != "" {
+			return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %s request bodies require a single JSON success response", method, path, opName, patchContentTypes[patchKind])
+		}
+		patchResource = patchResourceType(op, patchKind, rspTypeName, opName)
+		if patchResource == "" {
+			return OperationDef{}, nil, fmt.Errorf("%s %s (%s): %s request bodies need a $ref body schema or a JSON success response naming the patched resource", method, path, opName, patchContentTypes[patchKind])
+		}
+		addPatchField(reqStruct, patchKind, imports)
 	}
 	expectedStatuses := expectedResponseStatuses(op, successStatus)
 	if responseMode == "variants" {
@@ -156,6 +214,7 @@ func buildOperationDef(method, path string, pathItem *openapi3.PathItem, op *ope
 
 	return OperationDef{
 		Name:             opName,
+		OperationID:      op.OperationID,
 		Comment:          operationComment(op),
 		Method:           method,
 		Path:             path,
@@ -164,6 +223,14 @@ func buildOperationDef(method, path string, pathItem *openapi3.PathItem, op *ope
 		IsJSONLines:      jl,
 		IsNoBody:         responseMode != "variants" && (strings.EqualFold(method, http.MethodHead) || successStatus == http.StatusNoContent),
 		SuccessStatus:    successStatus,
+		Timeout:          timeout,
+		Pagination:       pagination,
+		PageItemType:     pageItemType,
+		FieldMask:        fieldMask,
+		ClosedBody:       closedJSONBody(op),
+		PatchKind:        patchKind,
+		PatchResource:    patchResource,
+		WebhookSignature: webhookSignature,
 		ExpectedStatuses: expectedStatuses,
 		ResponseMode:     responseMode,
 		RspTypeName:      rspTypeName,
@@ -173,13 +240,14 @@ func buildOperationDef(method, path string, pathItem *openapi3.PathItem, op *ope
 	}, append(reqExtra, rspExtra...), nil
 }
 
-func buildRequestStruct(opName string, pathItem *openapi3.PathItem, op *openapi3.Operation, imports map[string]bool, seen map[string]bool) (*StructDef, []TypeDef) {
+func buildRequestStruct(opName string, pathItem *openapi3.PathItem, op *openapi3.Operation, paginated, patch bool, imports map[string]bool, seen map[string]bool) (*StructDef, []TypeDef) {
 	reqName := opName + "Req"
 	var fields []FieldDef
 	var embeds []string
 	var extraTypes []TypeDef
 	var bodyContentType string
 	var aliasTarget string
+	var dependentRequired []DependentRequiredRule
 
 	allParams := mergeParams(pathItem.Parameters, op.Parameters)
 	for _, paramRef := range allParams {
@@ -187,6 +255,9 @@ func buildRequestStruct(opName string, pathItem *openapi3.PathItem, op *openapi3
 			continue
 		}
 		param := paramRef.Value
+		if paginated && param.In == "query" && pageQueryParams[param.Name] {
+			continue
+		}
 		fieldName := ToCamelCase(param.Name)
 		fieldType := resolveParamType(param, imports)
 		required := param.Required
@@ -243,7 +314,16 @@ func buildRequestStruct(opName string, pathItem *openapi3.PathItem, op *openapi3
 			extraTypes = append(extraTypes, formExtra...)
 		} else if mt := body.Content.Get("application/json"); mt != nil && mt.Schema != nil {
 			bodyContentType = "application/json"
-			if mt.Schema.Ref != "" {
+			if mt.Schema.Ref != "" && patch && mt.Schema.Value != nil && len(mt.Schema.Value.Properties) > 0 {
+				// A PATCH body that references an object schema is flattened
+				// like an inline one so that its optional properties become
+				// ginx.Optional; the component type keeps its *T fields for
+				// other operations.
+				bodyFields, bodyExtra := flattenBodyFields(reqName, mt.Schema, patch, imports, seen)
+				dependentRequired = append(requiredPresenceRules(mt.Schema.Value, bodyFields), dependentRequiredRules(mt.Schema.Value, bodyFields)...)
+				fields = append(fields, bodyFields...)
+				extraTypes = append(extraTypes, bodyExtra...)
+			} else if mt.Schema.Ref != "" {
 				refType := refToTypeName(mt.Schema.Ref)
 				if len(fields) == 0 {
 					aliasTarget = refType
@@ -253,7 +333,8 @@ func buildRequestStruct(opName string, pathItem *openapi3.PathItem, op *openapi3
 			} else if mt.Schema.Value != nil {
 				schema := mt.Schema.Value
 				if (schema.Type != nil && schema.Type.Is("object")) || len(schema.Properties) > 0 {
-					bodyFields, bodyExtra := flattenBodyFields(reqName, mt.Schema, imports, seen)
+					bodyFields, bodyExtra := flattenBodyFields(reqName, mt.Schema, patch, imports, seen)
+					dependentRequired = append(requiredPresenceRules(schema, bodyFields), dependentRequiredRules(schema, bodyFields)...)
 					fields = append(fields, bodyFields...)
 					extraTypes = append(extraTypes, bodyExtra...)
 				} else {
@@ -280,20 +361,26 @@ func buildRequestStruct(opName string, pathItem *openapi3.PathItem, op *openapi3
 		}
 	}
 
+	if paginated && aliasTarget != "" {
+		embeds = append(embeds, aliasTarget)
+		aliasTarget = ""
+	}
 	if len(fields) == 0 && len(embeds) == 0 && aliasTarget == "" {
-		return &StructDef{Name: reqName}, nil
+		return &StructDef{Name: reqName, Paginated: paginated}, nil
 	}
 
 	return &StructDef{
-		Name:            reqName,
-		Fields:          fields,
-		Embeds:          embeds,
-		AliasTarget:     aliasTarget,
-		BodyContentType: bodyContentType,
+		Name:              reqName,
+		Fields:            fields,
+		Embeds:            embeds,
+		AliasTarget:       aliasTarget,
+		Paginated:         paginated,
+		BodyContentType:   bodyContentType,
+		DependentRequired: dependentRequired,
 	}, extraTypes
 }
 
-func flattenBodyFields(parentName string, schemaRef *openapi3.SchemaRef, imports map[string]bool, seen map[string]bool) ([]FieldDef, []TypeDef) {
+func flattenBodyFields(parentName string, schemaRef *openapi3.SchemaRef, patch bool, imports map[string]bool, seen map[string]bool) ([]FieldDef, []TypeDef) {
 	if schemaRef == nil || schemaRef.Value == nil {
 		return nil, nil
 	}
@@ -315,6 +402,12 @@ func flattenBodyFields(parentName string, schemaRef *openapi3.SchemaRef, imports
 		extraTypes = append(extraTypes, extra...)
 
 		required := requiredSet[propName]
+		if optionalBodyField(patch, required, propRef) {
+			f := optionalField(fieldName, fieldType, propName, propRef, imports)
+			f.Source = fieldSourceBody
+			fields = append(fields, f)
+			continue
+		}
 		if !required && !isNilable(fieldType) {
 			fieldType = "*" + fieldType
 		}
@@ -338,6 +431,40 @@ func flattenBodyFields(parentName string, schemaRef *openapi3.SchemaRef, imports
 	return fields, extraTypes
 }
 
+// optionalBodyField reports whether an object property becomes
+// ginx.Optional[T], which tells an absent field from an explicit null: every
+// optional property of a PATCH body, and nullable properties (3.0
+// `nullable: true`, 3.1 `type: [T, "null"]`) of request bodies and component
+// schemas. Such fields carry `omitzero` so clients omit them when unset, and
+// drop `default` and `required`: validator rules only see the value. A
+// required one keeps its presence check in the generated Validate (see
+// requiredPresenceRules).
+func optionalBodyField(patch, required bool, ref *openapi3.SchemaRef) bool {
+	if patch && !required {
+		return true
+	}
+	if ref == nil || ref.Value == nil {
+		return false
+	}
+	return ref.Value.Nullable || typeIs(ref.Value, "null")
+}
+
+// optionalField builds the ginx.Optional[T] field for a property selected by
+// optionalBodyField.
+func optionalField(fieldName, fieldType, propName string, ref *openapi3.SchemaRef, imports map[string]bool) FieldDef {
+	imports["github.com/chendefine/ginx"] = true
+	tags := []Tag{{Key: "json", Value: propName + ",omitzero"}}
+	if binding := buildBindingRules(false, ref); binding != "" {
+		tags = append(tags, Tag{Key: "binding", Value: binding})
+	}
+	return FieldDef{
+		Name:    fieldName,
+		Type:    "ginx.Optional[" + fieldType + "]",
+		Tags:    tags,
+		Comment: schemaDescription(ref),
+	}
+}
+
 func buildFormDataFields(parentName string, schemaRef *openapi3.SchemaRef, imports map[string]bool, seen map[string]bool, allowFiles bool) ([]FieldDef, []TypeDef) {
 	if schemaRef == nil || schemaRef.Value == nil {
 		return nil, nil
@@ -635,6 +762,222 @@ func operationResponseMode(op *openapi3.Operation) (string, error) {
 	}
 }
 
+// operationTimeout reads x-ginx-timeout: a Go duration string ("5s",
+// "1m30s") or a number of seconds. For SSE / JSON Lines operations the value
+// is rendered as an idle timeout between sends rather than a total deadline.
+func operationTimeout(op *openapi3.Operation) (time.Duration, error) {
+	v, ok := op.Extensions["x-ginx-timeout"]
+	if !ok {
+		return 0, nil
+	}
+	var d time.Duration
+	switch t := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(strings.TrimSpace(t))
+		if err != nil {
+			return 0, fmt.Errorf("x-ginx-timeout=%q is not a valid duration", t)
+		}
+		d = parsed
+	case float64:
+		d = time.Duration(t * float64(time.Second))
+	case int:
+		d = time.Duration(t) * time.Second
+	default:
+		return 0, fmt.Errorf("x-ginx-timeout must be a duration string or a number of seconds")
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("x-ginx-timeout must be positive")
+	}
+	return d, nil
+}
+
+// webhookSignatureSchemes maps x-ginx-webhook-signature values to the
+// webhook package constants rendered into route options.
+var webhookSignatureSchemes = map[string]string{
+	"hmac-sha256":       "webhook.HMACSHA256",
+	"standard-webhooks": "webhook.StandardWebhooks",
+}
+
+// operationWebhookSignature reads x-ginx-webhook-signature, which must name a
+// scheme supported by the ginx/webhook package.
+func operationWebhookSignature(op *openapi3.Operation) (string, error) {
+	v, ok := op.Extensions["x-ginx-webhook-signature"]
+	if !ok {
+		return "", nil
+	}
+	scheme, _ := v.(string)
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	if _, ok := webhookSignatureSchemes[scheme]; !ok {
+		return "", fmt.Errorf("x-ginx-webhook-signature=%v is unsupported; use hmac-sha256 or standard-webhooks", v)
+	}
+	return scheme, nil
+}
+
+// pageQueryParams are bound by the embedded ginx.PageReq of a paginated
+// request, so the spec's own declarations of them are not regenerated.
+var pageQueryParams = map[string]bool{"cursor": true, "offset": true, "limit": true}
+
+// operationPagination reads x-ginx-pagination, which must be "cursor" or
+// "offset".
+func operationPagination(op *openapi3.Operation) (string, error) {
+	v, ok := op.Extensions["x-ginx-pagination"]
+	if !ok {
+		return "", nil
+	}
+	mode, _ := v.(string)
+	switch mode = strings.ToLower(strings.TrimSpace(mode)); mode {
+	case "cursor", "offset":
+		return mode, nil
+	default:
+		return "", fmt.Errorf("x-ginx-pagination=%v is unsupported; use cursor or offset", v)
+	}
+}
+
+// buildPageResponseType maps a paginated success response onto ginx.Page[T].
+// The response schema must be an object whose items property is an array; T
+// is derived from the array element schema.
+func buildPageResponseType(opName string, op *openapi3.Operation, unwrap bool, imports map[string]bool, seen map[string]bool) (*TypeDef, []TypeDef, string, error) {
+	_, responseRef := selectSuccessResponse(op.Responses)
+	if responseRef == nil || responseRef.Value == nil {
+		return nil, nil, "", fmt.Errorf("x-ginx-pagination requires a JSON success response")
+	}
+	mt := responseRef.Value.Content.Get("application/json")
+	if mt == nil || mt.Schema == nil {
+		return nil, nil, "", fmt.Errorf("x-ginx-pagination requires a JSON success response")
+	}
+	effective := effectiveResponseSchema(mt.Schema, unwrap)
+	var items *openapi3.SchemaRef
+	if effective != nil && effective.Value != nil {
+		items = flattenAllOf(effective.Value).Properties["items"]
+	}
+	if items == nil || items.Value == nil || !typeIs(items.Value, "array") || items.Value.Items == nil {
+		return nil, nil, "", fmt.Errorf("x-ginx-pagination response must have an items array property")
+	}
+	itemType, extra := resolveFieldType(opName+"Item", items.Value.Items, imports, seen)
+	rsp := TypeDef{Alias: &AliasDef{
+		Name:       opName + "Rsp",
+		TargetType: "ginx.Page[" + itemType + "]",
+		Comment:    schemaDescription(effective),
+	}}
+	return &rsp, extra, itemType, nil
+}
+
+// operationFieldMask reads the boolean x-ginx-fields hint.
+func operationFieldMask(op *openapi3.Operation) (bool, error) {
+	v, ok := op.Extensions["x-ginx-fields"]
+	if !ok {
+		return false, nil
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("x-ginx-fields must be a boolean")
+	}
+	return b, nil
+}
+
+// closedJSONBody reports whether the JSON request body schema forbids
+// undeclared properties. It follows buildRequestStruct in preferring a
+// multipart body, which ginx does not decode as JSON.
+func closedJSONBody(op *openapi3.Operation) bool {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return false
+	}
+	content := op.RequestBody.Value.Content
+	if mt := content.Get("multipart/form-data"); mt != nil && mt.Schema != nil && mt.Schema.Value != nil {
+		return false
+	}
+	mt := content.Get("application/json")
+	if mt == nil || mt.Schema == nil || mt.Schema.Value == nil {
+		return false
+	}
+	has := mt.Schema.Value.AdditionalProperties.Has
+	return has != nil && !*has
+}
+
+var patchContentTypes = map[string]string{
+	"merge": "application/merge-patch+json",
+	"json":  "application/json-patch+json",
+}
+
+// patchDocumentKind reports which patch document format a PATCH operation's
+// request body uses. A body that also accepts application/json stays an
+// ordinary JSON route, since a ginx patch route accepts a single content type.
+func patchDocumentKind(method string, op *openapi3.Operation) (string, error) {
+	if !strings.EqualFold(method, http.MethodPatch) || op.RequestBody == nil || op.RequestBody.Value == nil {
+		return "", nil
+	}
+	content := op.RequestBody.Value.Content
+	if content.Get("application/json") != nil {
+		return "", nil
+	}
+	merge := content.Get(patchContentTypes["merge"]) != nil
+	jsonPatch := content.Get(patchContentTypes["json"]) != nil
+	switch {
+	case merge && jsonPatch:
+		return "", fmt.Errorf("request body declares both %s and %s; ginx patch routes accept one format", patchContentTypes["merge"], patchContentTypes["json"])
+	case merge:
+		return "merge", nil
+	case jsonPatch:
+		return "json", nil
+	}
+	return "", nil
+}
+
+// patchResourceType picks the Go type a patch document is applied to: the
+// $ref schema of a merge patch body, otherwise the JSON success response.
+func patchResourceType(op *openapi3.Operation, kind, rspTypeName, opName string) string {
+	if kind == "merge" {
+		if mt := op.RequestBody.Value.Content.Get(patchContentTypes["merge"]); mt != nil && mt.Schema != nil && mt.Schema.Ref != "" {
+			return refToTypeName(mt.Schema.Ref)
+		}
+	}
+	if rspTypeName == opName+"Rsp" {
+		return rspTypeName
+	}
+	return ""
+}
+
+// addPatchField gives the client a field for the raw patch document. The
+// server reads the body through ginx.MergePatch / ginx.JSONPatch, so the field
+// is excluded from JSON binding.
+func addPatchField(st *StructDef, kind string, imports map[string]bool) {
+	field := FieldDef{
+		Name:    "Patch",
+		Type:    "map[string]any",
+		Tags:    []Tag{{Key: "json", Value: "-"}},
+		Comment: "JSON Merge Patch (RFC 7396) document sent as the request body.",
+	}
+	if kind == "json" {
+		field.Type = "[]ginx.PatchOperation"
+		field.Comment = "JSON Patch (RFC 6902) operations sent as the request body."
+		imports["github.com/chendefine/ginx"] = true
+	}
+	st.Fields = append(st.Fields, field)
+	st.BodyContentType = patchContentTypes[kind]
+}
+
+// addFieldsParam documents the fields query parameter consumed by
+// ginx.FieldMask, unless the spec already declares it. The server reads the
+// query directly; the field exists so clients can send it.
+func addFieldsParam(st *StructDef) {
+	for _, f := range st.Fields {
+		if f.Source == fieldSourceQuery && tagValue(f, "form") == "fields" {
+			return
+		}
+	}
+	if st.AliasTarget != "" {
+		st.Embeds = append(st.Embeds, st.AliasTarget)
+		st.AliasTarget = ""
+	}
+	st.Fields = append(st.Fields, FieldDef{
+		Name:    "Fields",
+		Type:    "*string",
+		Tags:    []Tag{{Key: "form", Value: "fields"}},
+		Comment: "Comma-separated JSON field paths to include in the response, e.g. id,profile.avatar.",
+		Source:  fieldSourceQuery,
+	})
+}
+
 func buildResponseVariants(opName string, op *openapi3.Operation, unwrap bool, imports map[string]bool, seen map[string]bool) ([]ResponseVariantDef, []TypeDef) {
 	if op == nil || op.Responses == nil {
 		return nil, nil
@@ -36,6 +36,23 @@ type StructDef struct {
 	Embeds          []string
 	AliasTarget     string
 	BodyContentType string
+	// Paginated embeds ginx.PageReq, which owns the cursor/offset/limit query
+	// parameters of an x-ginx-pagination operation.
+	Paginated bool
+	// DependentRequired renders as a Validate(ctx) method, which the ginx
+	// runtime calls on request types after binding tag validation. It also
+	// carries the presence checks of required ginx.Optional body fields.
+	DependentRequired []DependentRequiredRule
+}
+
+// DependentRequiredRule is one dependentRequired check: when Trigger is
+// present, Required must be too; an empty Trigger makes Required
+// unconditional. Cond is a Go condition on receiver r that holds when the
+// rule is violated.
+type DependentRequiredRule struct {
+	Trigger  string
+	Required string
+	Cond     string
 }
 
 type EnumDef struct {
@@ -163,6 +180,10 @@ func resolveObject(name string, schema *openapi3.Schema, imports map[string]bool
 		additionalTypes = append(additionalTypes, extra...)
 
 		required := requiredSet[propName]
+		if optionalBodyField(false, required, propRef) {
+			fields = append(fields, optionalField(fieldName, fieldType, propName, propRef, imports))
+			continue
+		}
 		if !required && !isNilable(fieldType) {
 			fieldType = "*" + fieldType
 		}
@@ -184,9 +205,10 @@ func resolveObject(name string, schema *openapi3.Schema, imports map[string]bool
 	}
 
 	result := []TypeDef{{Struct: &StructDef{
-		Name:    name,
-		Comment: schema.Description,
-		Fields:  fields,
+		Name:              name,
+		Comment:           schema.Description,
+		Fields:            fields,
+		DependentRequired: append(requiredPresenceRules(schema, fields), dependentRequiredRules(schema, fields)...),
 	}}}
 	return append(result, additionalTypes...)
 }
@@ -197,6 +219,7 @@ func resolveAllOf(name string, schema *openapi3.Schema, imports map[string]bool,
 		Description: schema.Description,
 	}
 	merged.Required = append(merged.Required, schema.Required...)
+	mergeDependentRequired(merged, schema.DependentRequired)
 
 	var embeds []string
 	for _, ref := range schema.AllOf {
@@ -212,6 +235,7 @@ func resolveAllOf(name string, schema *openapi3.Schema, imports map[string]bool,
 			merged.Properties[k] = v
 		}
 		merged.Required = append(merged.Required, ref.Value.Required...)
+		mergeDependentRequired(merged, ref.Value.DependentRequired)
 		if merged.Description == "" {
 			merged.Description = ref.Value.Description
 		}
@@ -234,6 +258,15 @@ func resolveAllOf(name string, schema *openapi3.Schema, imports map[string]bool,
 	return types
 }
 
+func mergeDependentRequired(dst *openapi3.Schema, src map[string][]string) {
+	for k, v := range src {
+		if dst.DependentRequired == nil {
+			dst.DependentRequired = make(map[string][]string)
+		}
+		dst.DependentRequired[k] = append(dst.DependentRequired[k], v...)
+	}
+}
+
 func schemaDescription(ref *openapi3.SchemaRef) string {
 	if ref == nil || ref.Value == nil {
 		return ""
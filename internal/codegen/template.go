@@ -3,8 +3,10 @@ package codegen
 import (
 	"embed"
 	"fmt"
+	"strconv"
 	"strings"
 	"text/template"
+	"time"
 )
 
 //go:embed templates/*.tmpl
@@ -27,6 +29,8 @@ func init() {
 		"formBodyFields":     filterFormBodyFields,
 		"tagValue":           tagValue,
 		"isPointerType":      isPointerType,
+		"isOptionalType":     isOptionalType,
+		"hasOptionalFields":  hasOptionalFields,
 		"fmtValue":           fmtValue,
 		"fmtDerefValue":      fmtDerefValue,
 		"clientRspType":      clientRspType,
@@ -39,6 +43,8 @@ func init() {
 		"hasSSEOps":          hasSSEOps,
 		"hasRedirectOps":     hasRedirectOps,
 		"statusArgs":         statusArgs,
+		"routeOptions":       routeOptions,
+		"goString":           goStringLiteral,
 	}
 	tmpl = template.Must(template.New("").Funcs(funcMap).ParseFS(templateFS, "templates/*.tmpl"))
 }
@@ -210,10 +216,13 @@ func filterFormBodyFields(req *StructDef) []FieldDef {
 	return result
 }
 
+// tagValue returns the name part of the key tag, without options such as
+// ",omitzero".
 func tagValue(f FieldDef, key string) string {
 	for _, t := range f.Tags {
 		if t.Key == key {
-			return t.Value
+			name, _, _ := strings.Cut(t.Value, ",")
+			return name
 		}
 	}
 	return ""
@@ -223,6 +232,19 @@ func isPointerType(f FieldDef) bool {
 	return len(f.Type) > 0 && f.Type[0] == '*'
 }
 
+func isOptionalType(f FieldDef) bool {
+	return strings.HasPrefix(f.Type, "ginx.Optional[")
+}
+
+func hasOptionalFields(fields []FieldDef) bool {
+	for _, f := range fields {
+		if isOptionalType(f) {
+			return true
+		}
+	}
+	return false
+}
+
 func fmtValue(f FieldDef) string {
 	baseType := f.Type
 	if len(baseType) > 0 && baseType[0] == '*' {
@@ -350,6 +372,61 @@ func statusArgs(statuses []int) string {
 	return strings.Join(parts, ", ")
 }
 
+// routeOptions renders the trailing RouteOption argument of a generated
+// Register call. Spec-derived options are appended after the caller's opts so
+// the OpenAPI contract wins over generic defaults.
+func routeOptions(op OperationDef) string {
+	var extra []string
+	if !op.IsSSE && !op.IsJSONLines && op.SuccessStatus >= 201 && op.SuccessStatus <= 299 && op.RspTypeName != "ginx.FileRsp" {
+		extra = append(extra, fmt.Sprintf("ginx.SuccessStatus(%d)", op.SuccessStatus))
+	}
+	if op.OperationID != "" {
+		extra = append(extra, fmt.Sprintf("ginx.OperationID(%q)", op.OperationID))
+	}
+	if op.FieldMask {
+		extra = append(extra, "ginx.FieldMask()")
+	}
+	if op.ClosedBody {
+		extra = append(extra, "ginx.DisallowUnknownFields(true)")
+	}
+	if op.WebhookSignature != "" {
+		scheme := webhookSignatureSchemes[op.WebhookSignature]
+		extra = append(extra, fmt.Sprintf("webhook.VerifySignature(%s, s.%sVerifier())", scheme, op.Name),
+			fmt.Sprintf("ginx.RouteInterceptor(webhook.Interceptor(%s, s.%sVerifier()))", scheme, op.Name))
+	}
+	if op.Timeout > 0 {
+		if op.IsSSE || op.IsJSONLines {
+			extra = append(extra, "ginx.IdleTimeout("+durationLiteral(op.Timeout)+")")
+		} else {
+			extra = append(extra, "ginx.Timeout("+durationLiteral(op.Timeout)+")")
+		}
+	}
+	if len(extra) == 0 {
+		return "opts..."
+	}
+	return "append(append([]ginx.RouteOption(nil), opts...), " + strings.Join(extra, ", ") + ")..."
+}
+
+// durationLiteral renders d as the shortest exact "n * time.Unit" expression.
+func durationLiteral(d time.Duration) string {
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+		{time.Microsecond, "time.Microsecond"},
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * %s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("time.Duration(%d)", int64(d))
+}
+
 func zeroReturn(op OperationDef) string {
 	switch clientRspType(op) {
 	case "":
@@ -385,6 +462,7 @@ type typesTemplateData struct {
 	Imports           []string
 	Types             []TypeDef
 	Operations        []OperationDef
+	Patterns          []PatternDef
 }
 
 type serverTemplateData struct {
@@ -407,6 +485,7 @@ type combinedTemplateData struct {
 	Imports           []string
 	Types             []TypeDef
 	Operations        []OperationDef
+	Patterns          []PatternDef
 	GenerateServer    bool
 	GenerateClient    bool
 	ServerName        string
@@ -466,3 +545,12 @@ func titleCase(s string) string {
 	}
 	return strings.ToUpper(s[:1]) + s[1:]
 }
+
+// goStringLiteral renders s as a raw string literal when possible, which keeps
+// regular expressions readable, and as a quoted literal otherwise.
+func goStringLiteral(s string) string {
+	if strconv.CanBackquote(s) {
+		return "`" + s + "`"
+	}
+	return strconv.Quote(s)
+}
@@ -1,8 +1,14 @@
 package codegen
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
@@ -77,6 +83,23 @@ func buildBindingRules(required bool, schemaRef *openapi3.SchemaRef) string {
 		rules = append(rules, fmt.Sprintf("max=%d", *schema.MaxLength))
 	}
 
+	// pattern renders as pattern=<hash>; the generated init registers the
+	// regular expression under that name via ginx.RegisterPattern, because a
+	// validator tag parameter cannot hold commas, pipes or arbitrary bytes.
+	// OpenAPI patterns are ECMA-262 while the runtime uses Go's RE2 syntax;
+	// spec validation already rejects patterns RE2 cannot compile
+	// (lookarounds, backreferences), the check here only keeps the generated
+	// init from panicking.
+	if schema.Pattern != "" && patternApplies(schema) {
+		if _, err := regexp.Compile(schema.Pattern); err == nil {
+			rules = append(rules, "pattern="+patternName(schema.Pattern))
+		}
+	}
+
+	if schema.MultipleOf != nil && (typeIs(schema, "integer") || typeIs(schema, "number")) {
+		rules = append(rules, "multiple_of="+strconv.FormatFloat(*schema.MultipleOf, 'f', -1, 64))
+	}
+
 	if schema.MinItems != 0 {
 		rules = append(rules, fmt.Sprintf("min=%d", schema.MinItems))
 	}
@@ -129,6 +152,67 @@ func formatToValidator(format string) string {
 	}
 }
 
+// patternApplies reports whether a string schema maps to a Go string, so the
+// pattern rule never lands on time.Time or []byte fields.
+func patternApplies(schema *openapi3.Schema) bool {
+	if !typeIs(schema, "string") {
+		return false
+	}
+	goType, _ := MapType("string", schema.Format)
+	return goType == "string"
+}
+
+// PatternDef is a regular expression registered by the generated init.
+type PatternDef struct {
+	Name string
+	Expr string
+}
+
+// patternSources maps pattern names back to their expressions. The name is a
+// content hash, so sharing the map across Generate calls is safe.
+var patternSources sync.Map
+
+// patternName returns the stable name of expr used in pattern=<name>.
+func patternName(expr string) string {
+	sum := sha256.Sum256([]byte(expr))
+	name := hex.EncodeToString(sum[:6])
+	patternSources.Store(name, expr)
+	return name
+}
+
+// collectPatterns returns the patterns referenced by binding tags in types,
+// sorted by name.
+func collectPatterns(types []TypeDef) []PatternDef {
+	seen := make(map[string]bool)
+	var defs []PatternDef
+	for _, td := range types {
+		if td.Struct == nil {
+			continue
+		}
+		for _, f := range td.Struct.Fields {
+			for _, tag := range f.Tags {
+				if tag.Key != "binding" {
+					continue
+				}
+				for _, rule := range strings.Split(tag.Value, ",") {
+					name, ok := strings.CutPrefix(rule, "pattern=")
+					if !ok || seen[name] {
+						continue
+					}
+					expr, ok := patternSources.Load(name)
+					if !ok {
+						continue
+					}
+					seen[name] = true
+					defs = append(defs, PatternDef{Name: name, Expr: expr.(string)})
+				}
+			}
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
 func extensionBinding(extensions map[string]any) string {
 	v, ok := extensions["x-binding"]
 	if !ok {
@@ -140,3 +224,92 @@ func extensionBinding(extensions map[string]any) string {
 	}
 	return s
 }
+
+// dependentRequiredRules turns the schema's dependentRequired keyword into
+// checks over the generated fields. A rule is dropped when either property is
+// missing from fields, or when the dependent field cannot be absent (a
+// required, non-pointer field).
+func dependentRequiredRules(schema *openapi3.Schema, fields []FieldDef) []DependentRequiredRule {
+	if schema == nil || len(schema.DependentRequired) == 0 {
+		return nil
+	}
+	byJSON := make(map[string]FieldDef, len(fields))
+	for _, f := range fields {
+		if name := tagValue(f, "json"); name != "" && name != "-" {
+			byJSON[name] = f
+		}
+	}
+	triggers := make([]string, 0, len(schema.DependentRequired))
+	for name := range schema.DependentRequired {
+		triggers = append(triggers, name)
+	}
+	sort.Strings(triggers)
+
+	var rules []DependentRequiredRule
+	for _, trigger := range triggers {
+		tf, ok := byJSON[trigger]
+		if !ok {
+			continue
+		}
+		for _, dep := range schema.DependentRequired[trigger] {
+			df, ok := byJSON[dep]
+			if !ok {
+				continue
+			}
+			absent := absenceExpr(df)
+			if absent == "" {
+				continue
+			}
+			cond := absent
+			if present := presenceExpr(tf); present != "" {
+				cond = present + " && " + absent
+			}
+			rules = append(rules, DependentRequiredRule{Trigger: trigger, Required: dep, Cond: cond})
+		}
+	}
+	return rules
+}
+
+// requiredPresenceRules returns presence checks for required properties that
+// flattenBodyFields turned into ginx.Optional. Their binding tag drops
+// `required` (validator only sees the value, and an explicit null is
+// allowed), so the generated Validate rejects the absent case instead.
+func requiredPresenceRules(schema *openapi3.Schema, fields []FieldDef) []DependentRequiredRule {
+	if schema == nil || len(schema.Required) == 0 {
+		return nil
+	}
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	var rules []DependentRequiredRule
+	for _, f := range fields {
+		if name := tagValue(f, "json"); isOptionalType(f) && required[name] {
+			rules = append(rules, DependentRequiredRule{Required: name, Cond: absenceExpr(f)})
+		}
+	}
+	return rules
+}
+
+// presenceExpr returns a Go condition on receiver r that holds when f was
+// sent, or "" when f is always present.
+func presenceExpr(f FieldDef) string {
+	switch {
+	case isOptionalType(f):
+		return "r." + f.Name + ".IsSet()"
+	case isPointerType(f) || isNilable(f.Type):
+		return "r." + f.Name + " != nil"
+	}
+	return ""
+}
+
+// absenceExpr is the negation of presenceExpr.
+func absenceExpr(f FieldDef) string {
+	switch {
+	case isOptionalType(f):
+		return "!r." + f.Name + ".IsSet()"
+	case isPointerType(f) || isNilable(f.Type):
+		return "r." + f.Name + " == nil"
+	}
+	return ""
+}
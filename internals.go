@@ -2,7 +2,6 @@ package ginx
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -30,131 +29,319 @@ func register[Req, Rsp any](r gin.IRoutes, method, path string, fn HandlerFunc[R
 
 	var reqZero Req
 	reqType := reflect.TypeOf(reqZero)
-	plan := buildBindingPlan(reqType)
+	checkPreconditionType(cfg, reqType)
+	plan := routePlan(cfg, reqType)
+
+	info := cfg.route
+	info.Method = method
+	info.Path = path
+	info.ReqType = reqType
+	info.RspType = reflect.TypeOf((*Rsp)(nil)).Elem()
+	cfg.route = info
+	if cfg.fieldMask {
+		cfg.fieldTree = fieldTreeOf(info.RspType)
+	}
+	if isSafeMethod(method) {
+		cfg.idempotency = nil
+	}
 
 	handler := makeHandler(cfg, plan, fn)
-	router.Handle(method, path, handler)
+	router.Handle(method, path, func(gc *gin.Context) { handler(ginExchangeOf(gc)) })
 
 	engine.mu.RLock()
 	hooks := engine.onRegister
 	engine.mu.RUnlock()
-	if len(hooks) > 0 {
-		info := RegisterInfo{
-			Method:  method,
-			Path:    path,
-			ReqType: reqType,
-			RspType: reflect.TypeOf((*Rsp)(nil)).Elem(),
-		}
-		for _, h := range hooks {
-			h(info)
-		}
+	for _, h := range hooks {
+		h(info)
 	}
 }
 
-func makeHandler[Req, Rsp any](cfg resolved, plan *bindingPlan, fn HandlerFunc[Req, Rsp]) gin.HandlerFunc {
-	return func(gc *gin.Context) {
-		var req Req
+// serveFunc 处理单次请求, gin 路由与 HTTPHandler 共用.
+type serveFunc func(x exchange)
 
-		if !plan.isEmpty {
-			if plan.hasDefaults {
-				_ = defaults.Set(&req)
-			}
-			if err := bindRequest(gc, cfg, plan, &req); err != nil {
-				writeBindingError(gc, cfg, plan, err)
-				return
-			}
-			if plan.hasBinding {
-				if err := binding.Validator.ValidateStruct(&req); err != nil {
-					writeBindingError(gc, cfg, plan, err)
-					return
-				}
+func makeHandler[Req, Rsp any](cfg resolved, plan *bindingPlan, fn HandlerFunc[Req, Rsp]) serveFunc {
+	if cfg.requestIDGen == nil && cfg.compression == nil {
+		return makeObservedHandler(cfg, plan, fn)
+	}
+	inner := makeObservedHandler(cfg, plan, fn)
+	return func(x exchange) {
+		if cfg.requestIDGen != nil {
+			assignRequestID(x, cfg.requestIDGen)
+		}
+		if cfg.compression != nil {
+			if cw := beginCompression(x, cfg.compression); cw != nil {
+				defer cw.finish(x)
 			}
 		}
+		inner(x)
+	}
+}
 
-		ctx := acquireContext(gc)
-		defer releaseContext(ctx)
+func makeObservedHandler[Req, Rsp any](cfg resolved, plan *bindingPlan, fn HandlerFunc[Req, Rsp]) serveFunc {
+	if len(cfg.observers) > 0 {
+		return func(x exchange) {
+			observe(x, cfg, func(obs *observation) {
+				serveRequest(x, cfg, plan, fn, obs)
+			})
+		}
+	}
+	return func(x exchange) {
+		serveRequest(x, cfg, plan, fn, nil)
+	}
+}
 
-		rsp, err := invokeHandler(ctx, &req, cfg.interceptors, fn)
+func serveRequest[Req, Rsp any](x exchange, cfg resolved, plan *bindingPlan, fn HandlerFunc[Req, Rsp], obs *observation) {
+	var req Req
+	if obs != nil {
+		obs.outcome.Req = &req
+	}
+	if cfg.codec != nil {
+		x.Set(codecKey{}, cfg.codec)
+	}
 
-		if gc.IsAborted() {
+	if err := limitBody(x, cfg.limits); err != nil {
+		writeBindingError(x, cfg, plan, err)
+		return
+	}
+	if cfg.rawBodyBytes > 0 {
+		if err := keepRawBody(x, cfg.rawBodyBytes); err != nil {
+			writeBindingError(x, cfg, plan, err)
 			return
 		}
-		if err != nil {
-			if errors.Is(err, errResponseHandled) {
+		if len(cfg.rawBodyChecks) > 0 && !checkRawBody(x, cfg) {
+			return
+		}
+	}
+	if !plan.isEmpty {
+		if plan.hasDefaults {
+			_ = defaults.Set(&req)
+		}
+		if err := bindRequest(x, cfg, plan, &req); err != nil {
+			writeBindingError(x, cfg, plan, err)
+			return
+		}
+		if plan.hasBinding {
+			if err := cfg.validator.validateStruct(&req); err != nil {
+				writeBindingError(x, cfg, plan, err)
 				return
 			}
+		}
+	}
+	bindPageReq(x, cfg.pageLimits, &req)
+
+	// 与 Method.Call 一致, Validate 与 handler 共用带截止时间的 ctx.
+	ctx, cancel := withHandlerDeadline(acquireContext(x), x, cfg)
+	defer cancel()
+	defer releaseContext(ctx)
+
+	if plan.validateHook {
+		if err := runValidateHook(ctx, &req); err != nil {
+			writeBindingError(x, cfg, plan, err)
+			return
+		}
+	}
+	if cfg.fieldTree != nil {
+		if err := selectFields(x, cfg.fieldTree); err != nil {
+			writeBindingError(x, cfg, plan, err)
+			return
+		}
+	}
+
+	if cfg.conditional && requiresPrecondition(x) {
+		writeError(ctx, cfg, Error(cfg.invalidArgCode, "precondition required").Status(http.StatusPreconditionRequired))
+		return
+	}
+	if cfg.precondition != nil && isConditionalWrite(x.Request().Method) {
+		if err := cfg.precondition(ctx, &req); err != nil {
 			writeError(ctx, cfg, err)
 			return
 		}
-		writeSuccess(ctx, cfg, rsp)
 	}
+	if cfg.idempotency != nil {
+		scope, handled := beginIdempotency(ctx, x, cfg, &req)
+		if handled {
+			return
+		}
+		if scope != nil {
+			defer scope.release(ctx, x)
+			serveHandler(ctx, x, cfg, fn, &req, obs)
+			scope.commit(ctx, x)
+			return
+		}
+	}
+	serveHandler(ctx, x, cfg, fn, &req, obs)
+}
+
+func serveHandler[Req, Rsp any](ctx context.Context, x exchange, cfg resolved, fn HandlerFunc[Req, Rsp], req *Req, obs *observation) {
+	rsp, err := invokeHandler(ctx, req, cfg.interceptors, fn)
+	if obs != nil && rsp != nil {
+		obs.outcome.Rsp = rsp
+	}
+
+	if x.IsAborted() {
+		return
+	}
+	// 忽略 ctx 的 handler 在超时之后返回成功, 结果已无意义, 仍按超时响应.
+	if err == nil && errors.Is(context.Cause(ctx), ErrTimeout) {
+		err = ErrTimeout
+	}
+	if err != nil {
+		if errors.Is(err, errResponseHandled) {
+			return
+		}
+		if isTimeout(ctx, err) {
+			err = ErrTimeout
+		}
+		writeError(ctx, cfg, err)
+		return
+	}
+	writeSuccess(ctx, cfg, rsp)
 }
 
 // bindRequest 按 plan + Content-Type 选择性执行绑定, 只返回非校验错误;
 // 校验错误由后续 ValidateStruct 统一处理(保证多源字段都校验到).
-func bindRequest[Req any](gc *gin.Context, cfg resolved, plan *bindingPlan, req *Req) error {
-	if plan.hasHeader {
-		if err := gc.ShouldBindHeader(req); err != nil && !isValidationError(err) {
+func bindRequest[Req any](x exchange, cfg resolved, plan *bindingPlan, req *Req) error {
+	// 程序按结构体布局编译, Req 为指针等类型时全部回退到 gin binder.
+	obj := structPointer(req)
+	r := x.Request()
+	if obj != nil && plan.prog != nil {
+		var srcs [srcCount]bindSource
+		if plan.hasHeader {
+			srcs[srcHeader] = mapSource(r.Header)
+		}
+		if plan.hasCookie {
+			srcs[srcCookie] = mapSource(cookieValues(r))
+		}
+		if plan.hasURI {
+			srcs[srcURI] = uriSource(x)
+		}
+		if plan.hasForm {
+			srcs[srcForm] = formSource(x)
+		}
+		if err := plan.prog.run(obj, srcs[:]...); err != nil {
 			return err
 		}
+	} else if err := bindParams(x, plan, req); err != nil {
+		return err
 	}
-	if plan.hasCookie {
-		if err := bindCookies(gc, req); err != nil && !isValidationError(err) {
+	ct := contentType(r)
+	switch {
+	case plan.hasJSON && isJSONContentType(ct) && !cfg.patchDocument:
+		if err := bindJSON(r, cfg, req); err != nil && !isValidationError(err) {
+			return err
+		}
+	case plan.hasForm && isContentType(ct, "application/x-www-form-urlencoded"):
+		if err := binding.FormPost.Bind(r, req); err != nil && !isValidationError(err) {
+			return err
+		}
+	case plan.hasForm && isContentType(ct, "multipart/form-data"):
+		if err := checkMultipart(r, cfg.limits); err != nil {
+			return err
+		}
+		if err := binding.FormMultipart.Bind(r, req); err != nil && !isValidationError(err) {
 			return err
 		}
 	}
-	if plan.hasURI {
-		if err := gc.ShouldBindUri(req); err != nil && !isValidationError(err) {
+	// 自定义来源最后绑定, 覆盖 query / body 中的同名字段.
+	if len(plan.sources) > 0 && obj != nil {
+		if err := bindSources(x, plan, obj); err != nil {
 			return err
 		}
 	}
-	if plan.hasForm {
-		if err := gc.ShouldBindQuery(req); err != nil && !isValidationError(err) {
+	return nil
+}
+
+// bindParams 按合并程序相同的来源顺序依次调用 gin binder, 用于无法编译程序的 Req.
+// 校验在之后统一进行, 这里忽略 binder 自带校验的错误.
+func bindParams(x exchange, plan *bindingPlan, req any) error {
+	r := x.Request()
+	if plan.hasHeader {
+		if err := binding.Header.Bind(r, req); err != nil && !isValidationError(err) {
 			return err
 		}
 	}
-	ct := gc.ContentType()
-	switch {
-	case plan.hasJSON && isJSONContentType(ct):
-		if err := bindJSON(gc, cfg, req); err != nil && !isValidationError(err) {
+	if plan.hasCookie {
+		if err := binding.MapFormWithTag(req, cookieValues(r), "cookie"); err != nil && !isValidationError(err) {
 			return err
 		}
-	case plan.hasForm && isContentType(ct, "application/x-www-form-urlencoded"):
-		if err := gc.ShouldBindWith(req, binding.FormPost); err != nil && !isValidationError(err) {
+	}
+	if plan.hasURI {
+		if err := binding.Uri.BindUri(uriValues(x, plan), req); err != nil && !isValidationError(err) {
 			return err
 		}
-	case plan.hasForm && isContentType(ct, "multipart/form-data"):
-		if err := gc.ShouldBindWith(req, binding.FormMultipart); err != nil && !isValidationError(err) {
+	}
+	if plan.hasForm {
+		if err := binding.Query.Bind(r, req); err != nil && !isValidationError(err) {
 			return err
 		}
 	}
 	return nil
 }
 
-func bindCookies(gc *gin.Context, obj any) error {
+func cookieValues(r *http.Request) map[string][]string {
 	values := make(map[string][]string)
-	if gc != nil && gc.Request != nil {
-		for _, cookie := range gc.Request.Cookies() {
+	if r != nil {
+		for _, cookie := range r.Cookies() {
 			value, _ := url.QueryUnescape(cookie.Value)
 			values[cookie.Name] = append(values[cookie.Name], value)
 		}
 	}
-	return binding.MapFormWithTag(obj, values, "cookie")
+	return values
 }
 
-func bindJSON[Req any](gc *gin.Context, cfg resolved, req *Req) error {
-	if gc.Request == nil || gc.Request.Body == nil {
+// uriValues 收集路径参数供回退的 gin binder 使用: gin 路由取全部参数, 与 ShouldBindUri 一致,
+// 其它请求只能按 plan 中的 uri tag 名逐个取值.
+func uriValues(x exchange, plan *bindingPlan) map[string][]string {
+	if gx, ok := x.(ginExchange); ok {
+		values := make(map[string][]string, len(gx.gc.Params))
+		for _, p := range gx.gc.Params {
+			values[p.Key] = []string{p.Value}
+		}
+		return values
+	}
+	values := make(map[string][]string, len(plan.uriNames))
+	for _, name := range plan.uriNames {
+		if v, ok := x.Param(name); ok {
+			values[name] = []string{v}
+		}
+	}
+	return values
+}
+
+func bindJSON[Req any](r *http.Request, cfg resolved, req *Req) error {
+	if r == nil || r.Body == nil {
 		return nil
 	}
-	decoder := json.NewDecoder(gc.Request.Body)
+	var body io.Reader = r.Body
+	var depth *jsonDepthReader
+	if cfg.limits.maxJSONDepth > 0 {
+		depth = &jsonDepthReader{r: body, max: cfg.limits.maxJSONDepth}
+		body = depth
+	}
+	var seen *unknownFieldReader
+	if cfg.disallowUnknown {
+		seen = &unknownFieldReader{r: body}
+		body = seen
+	}
+	decoder := cfg.jsonCodec().NewDecoder(body)
 	if cfg.jsonDecoderUseNumber {
 		decoder.UseNumber()
 	}
+	if cfg.disallowUnknown {
+		decoder.DisallowUnknownFields()
+	}
 	if err := decoder.Decode(req); err != nil {
+		// 部分 encoding/json 实现会把读取错误改写为 unexpected EOF, 这里以 reader 记录的为准.
+		if depth != nil && depth.err != nil {
+			return depth.err
+		}
 		if errors.Is(err, io.EOF) {
 			// 空 body: 由后续 validator 处理 required 字段
 			return nil
 		}
+		if seen != nil {
+			return unknownFieldError(err, reflect.TypeFor[Req](), seen.buf.Bytes())
+		}
 		return err
 	}
 	if cfg.strictJSONBody {
@@ -319,16 +506,11 @@ var validationMessages = map[string]validationMessage{
 	"file":             {suffix: " must be a valid file path"},
 	"filepath":         {suffix: " must be a valid file path"},
 	"cron":             {suffix: " must be a valid cron expression"},
+	"multiple_of":      {suffix: " must be a multiple of ", withParam: true},
 }
 
 func sanitizeValidationError(err error, fieldNameMap map[string]string) string {
-	return FormatValidationError(err, func(fe validator.FieldError) string {
-		field := fe.Field()
-		if tagName, ok := fieldNameMap[field]; ok && tagName != "" {
-			return tagName
-		}
-		return field
-	})
+	return (*engineValidator)(nil).formatError(err, fieldNameMap)
 }
 
 // ValidationFieldNamer 将 validator 字段错误映射为对外展示字段名.
@@ -337,29 +519,40 @@ type ValidationFieldNamer func(validator.FieldError) string
 // FormatValidationError 使用 ginx 内置 validation 文案格式化 validator 错误.
 // namer 为 nil 时使用 Go 字段名; 调用方可在 WithValidationErrorHandler 中复用该函数做字段名映射.
 func FormatValidationError(err error, namer ValidationFieldNamer) string {
+	return formatValidationError(err, namer, nil)
+}
+
+// formatValidationError 同 FormatValidationError, custom 为 RegisterValidation 登记的 tag 文案.
+func formatValidationError(err error, namer ValidationFieldNamer, custom map[string]string) string {
 	var ve validator.ValidationErrors
 	if !errors.As(err, &ve) || len(ve) == 0 {
 		return err.Error()
 	}
 
 	if len(ve) == 1 {
-		return formatFieldError(ve[0], namer)
+		return formatFieldError(ve[0], namer, custom)
 	}
 
 	msgs := make([]string, len(ve))
 	for i, fe := range ve {
-		msgs[i] = formatFieldError(fe, namer)
+		msgs[i] = formatFieldError(fe, namer, custom)
 	}
 	return strings.Join(msgs, "; ")
 }
 
-func formatFieldError(fe validator.FieldError, namer ValidationFieldNamer) string {
+func formatFieldError(fe validator.FieldError, namer ValidationFieldNamer, custom map[string]string) string {
 	field := fe.Field()
 	if namer != nil {
 		if name := namer(fe); name != "" {
 			field = name
 		}
 	}
+	if message, ok := custom[fe.Tag()]; ok {
+		return field + " " + strings.ReplaceAll(message, "{param}", fe.Param())
+	}
+	if fe.Tag() == "pattern" {
+		return field + " must match pattern " + patternExpr(fe.Param())
+	}
 	if message, ok := validationMessages[fe.Tag()]; ok {
 		if message.withParam {
 			return field + message.suffix + fe.Param()
@@ -407,33 +600,75 @@ func invokeHandler[Req, Rsp any](ctx context.Context, req *Req, interceptors []I
 	return rsp, nil
 }
 
-func writeBindingError(gc *gin.Context, cfg resolved, plan *bindingPlan, err error) {
+func writeBindingError(x exchange, cfg resolved, plan *bindingPlan, err error) {
 	status := defaultBadReqStatus
 	if cfg.alwaysOK {
 		status = http.StatusOK
 	}
-	if isValidationError(err) && cfg.validationHandler != nil {
-		ctx := acquireContext(gc)
+	obs := observationOf(x)
+	stage := StageBinding
+	code := cfg.invalidArgCode
+	var ew *ErrWrap
+	var hook *validateHookError
+	if errors.As(err, &hook) {
+		err = hook.err
+		stage = StageValidation
+		if errors.As(err, &ew) {
+			code = ew.Code
+		}
+	} else if isValidationError(err) {
+		stage = StageValidation
+	}
+	obs.fail(stage, err, code)
+	if stage == StageValidation && cfg.validationHandler != nil {
+		ctx := acquireContext(x)
 		defer releaseContext(ctx)
 		if s, body := cfg.validationHandler(ctx, err); s > 0 {
 			if cfg.alwaysOK {
 				s = http.StatusOK
 			}
-			cfg.jsonRenderer(gc, s, body)
-			gc.Abort()
+			obs.fail(stage, err, bodyCode(body, code))
+			renderJSON(x, cfg, s, body)
+			x.Abort()
 			return
 		}
 	}
 	msg := err.Error()
 	if isValidationError(err) {
-		msg = sanitizeValidationError(err, plan.fieldNameMap)
+		msg = cfg.validator.formatError(err, plan.fieldNameMap)
+	} else if ew != nil {
+		msg = ew.Msg
+		if ew.HttpCode > 0 && !cfg.alwaysOK {
+			status = ew.HttpCode
+		}
+	} else if s, m := limitError(err); s > 0 {
+		msg = m
+		if !cfg.alwaysOK {
+			status = s
+		}
 	}
-	cfg.jsonRenderer(gc, status, successBody{Code: cfg.invalidArgCode, Msg: msg})
-	gc.Abort()
+	renderJSON(x, cfg, status, successBody{Code: code, Msg: msg, RequestID: RequestID(requestContext(x))})
+	x.Abort()
+}
+
+// builtinError 把内置哨兵错误转换为对应 code 的 *ErrWrap, 其它错误原样返回.
+func builtinError(cfg resolved, err error) error {
+	var pe *PatchError
+	switch {
+	case errors.As(err, &pe):
+		return Error(cfg.invalidArgCode, pe.Msg).Status(pe.Status)
+	case errors.Is(err, ErrPreconditionFailed):
+		return Error(cfg.invalidArgCode, err.Error()).Status(http.StatusPreconditionFailed)
+	case errors.Is(err, ErrTimeout):
+		return Error(cfg.timeoutCode, ErrTimeout.Error()).Status(cfg.timeoutStatus)
+	case errors.Is(err, ErrInvalidCursor):
+		return Error(cfg.invalidArgCode, ErrInvalidCursor.Error()).Status(http.StatusBadRequest)
+	}
+	return err
 }
 
 func writeError(ctx context.Context, cfg resolved, err error) {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
 		return
 	}
@@ -442,13 +677,21 @@ func writeError(ctx context.Context, cfg resolved, err error) {
 		status = http.StatusOK
 	}
 
+	obs := observationOf(x)
+	err = builtinError(cfg, err)
 	var ew *ErrWrap
 	if errors.As(err, &ew) {
 		if !cfg.alwaysOK && ew.HttpCode > 100 && ew.HttpCode < 600 {
 			status = ew.HttpCode
 		}
-		cfg.jsonRenderer(gc, status, ew)
-		gc.Abort()
+		obs.fail(StageHandler, err, ew.Code)
+		if id := RequestID(ctx); id != "" {
+			cp := *ew
+			cp.RequestID = id
+			ew = &cp
+		}
+		renderJSON(x, cfg, status, ew)
+		x.Abort()
 		return
 	}
 
@@ -457,25 +700,43 @@ func writeError(ctx context.Context, cfg resolved, err error) {
 			if cfg.alwaysOK {
 				s = http.StatusOK
 			}
-			cfg.jsonRenderer(gc, s, body)
-			gc.Abort()
+			obs.fail(StageHandler, err, bodyCode(body, cfg.internalErrorCode))
+			renderJSON(x, cfg, s, body)
+			x.Abort()
 			return
 		}
 	}
+	obs.fail(StageHandler, err, cfg.internalErrorCode)
 
-	msg := err.Error()
-	if !cfg.exposeInternalError {
-		msg = cfg.internalErrorMessage
-		if msg == "" {
-			msg = http.StatusText(http.StatusInternalServerError)
+	msg := internalMessage(cfg, err)
+	renderJSON(x, cfg, status, successBody{Code: cfg.internalErrorCode, Msg: msg, RequestID: RequestID(ctx)})
+	x.Abort()
+}
+
+// renderJSON 写出 JSON 响应: gin 路由上使用 WithJSONRenderer 设置的渲染器, 否则按路由的 Codec 编码.
+func renderJSON(x exchange, cfg resolved, status int, body any) {
+	if cfg.jsonRenderer != nil {
+		if gx, ok := x.(ginExchange); ok {
+			cfg.jsonRenderer(gx.gin(), status, body)
+			return
 		}
 	}
-	cfg.jsonRenderer(gc, status, successBody{Code: cfg.internalErrorCode, Msg: msg})
-	gc.Abort()
+	renderTo(x, status, codecRender{codec: cfg.jsonCodec(), body: body})
+}
+
+// internalMessage 返回内部错误对外展示的消息, 遵循 WithExposeInternalError.
+func internalMessage(cfg resolved, err error) string {
+	if cfg.exposeInternalError {
+		return err.Error()
+	}
+	if cfg.internalErrorMessage == "" {
+		return http.StatusText(http.StatusInternalServerError)
+	}
+	return cfg.internalErrorMessage
 }
 
 func writeSuccess(ctx context.Context, cfg resolved, rsp any) {
-	gc, ok := GinContext(ctx)
+	x, ok := exchangeOf(ctx)
 	if !ok {
 		return
 	}
@@ -489,10 +750,10 @@ func writeSuccess(ctx context.Context, cfg resolved, rsp any) {
 			if cfg.alwaysOK {
 				status = http.StatusOK
 			}
-			gc.Status(status)
+			x.Writer().WriteHeader(status)
 			return
 		}
-		writeJSONSuccess(ctx, gc, cfg, status, body)
+		writeJSONSuccess(ctx, x, cfg, status, body)
 		return
 	}
 	if rsp != nil {
@@ -502,29 +763,35 @@ func writeSuccess(ctx context.Context, cfg resolved, rsp any) {
 				status = http.StatusOK
 			}
 			var err error
-			if sr, ok := rsp.(statusResponse); ok && status != 0 {
-				err = sr.writeToStatus(gc, status)
+			if er, ok := rsp.(exchangeResponse); ok {
+				err = er.writeResponse(x, status)
+			} else if gx, ok := x.(ginExchange); ok {
+				err = r.WriteTo(gx.gin())
 			} else {
-				err = r.WriteTo(gc)
+				writeError(ctx, cfg, fmt.Errorf("ginx: %T.WriteTo requires a gin route", rsp))
+				return
 			}
 			if err != nil {
-				_ = gc.Error(err)
+				x.Error(err)
 			}
 			return
 		}
 	}
-	writeJSONSuccess(ctx, gc, cfg, cfg.successStatus, rsp)
+	writeJSONSuccess(ctx, x, cfg, cfg.successStatus, rsp)
 }
 
-func writeJSONSuccess(ctx context.Context, gc *gin.Context, cfg resolved, fixedStatus int, rsp any) {
+func writeJSONSuccess(ctx context.Context, x exchange, cfg resolved, fixedStatus int, rsp any) {
 	if fixedStatus == http.StatusNoContent && !cfg.alwaysOK {
-		gc.Status(http.StatusNoContent)
+		x.Writer().WriteHeader(http.StatusNoContent)
 		return
 	}
 	status := http.StatusOK
 	body := rsp
+	if cfg.fieldTree != nil {
+		body = maskFields(x, cfg.jsonCodec(), rsp)
+	}
 	if cfg.dataWrap {
-		status, body = cfg.successHandler(ctx, rsp)
+		status, body = cfg.successHandler(ctx, body)
 		if status <= 0 {
 			status = http.StatusOK
 		}
@@ -535,27 +802,50 @@ func writeJSONSuccess(ctx context.Context, gc *gin.Context, cfg resolved, fixedS
 	if cfg.alwaysOK {
 		status = http.StatusOK
 	}
-	if gc.Request.Method == http.MethodHead || status == http.StatusNoContent {
-		gc.Status(status)
+	writePageHeaders(x, rsp)
+	if cfg.conditional && applyValidators(x, cfg.jsonCodec(), status, rsp) {
+		writeNotModified(x)
+		return
+	}
+	if x.Request().Method == http.MethodHead || status == http.StatusNoContent {
+		x.Writer().WriteHeader(status)
 		return
 	}
-	cfg.jsonRenderer(gc, status, body)
+	renderJSON(x, cfg, status, body)
 }
 
 // bindingPlan 由 register 时一次反射扫描得出, hot path 按 plan 选择性调用绑定器,
 // 避免对空 tag 的结构体做无意义的反射遍历.
 type bindingPlan struct {
-	hasHeader    bool              // 存在 `header:"..."`
-	hasCookie    bool              // 存在 `cookie:"..."`
-	hasURI       bool              // 存在 `uri:"..."`
-	hasForm      bool              // 存在 `form:"..."`, 用于 query/form-post/multipart
-	hasJSON      bool              // 存在 `json:"..."` 有效 name 或含嵌套结构
-	hasDefaults  bool              // 存在 `default:"..."`, 决定是否调用 defaults.Set
-	hasBinding   bool              // 存在 `binding:"..."`, 决定是否调用 ValidateStruct
-	isEmpty      bool              // Req 结构体零字段, 完全跳过绑定
-	fieldNameMap map[string]string // Go 字段名 -> tag 名, 用于校验错误提示, 优先级: json > form > uri > header > cookie
+	hasHeader     bool              // 存在 `header:"..."`
+	hasCookie     bool              // 存在 `cookie:"..."`
+	hasURI        bool              // 存在 `uri:"..."`
+	uriNames      []string          // uri tag 名, 供 HTTPHandler 从 r.PathValue 取值
+	hasForm       bool              // 存在 `form:"..."`, 用于 query/form-post/multipart
+	hasJSON       bool              // 存在 `json:"..."` 有效 name 或含嵌套结构
+	hasDefaults   bool              // 存在 `default:"..."`, 决定是否调用 defaults.Set
+	hasBinding    bool              // 存在 `binding:"..."`, 决定是否调用 ValidateStruct
+	isEmpty       bool              // Req 结构体零字段, 完全跳过绑定
+	validateHook  bool              // *Req 实现 RequestValidator, 在 tag 校验后调用 Validate
+	optionalTypes []reflect.Type    // 出现的 Optional[T] 类型, 供 Engine 私有 validator 注册
+	fieldNameMap  map[string]string // Go 字段名 -> tag 名, 用于校验错误提示, 优先级: json > form > uri > header > cookie > 自定义来源
+	sources       []BindingSource   // Req 用到的 Engine 自定义来源, 由 routePlan 按路由填充
+	sourceProg    *bindProgram      // 自定义来源合并后的赋值程序, 指令按下标从 sources 取值
+
+	// header / cookie / uri / form 合并后的赋值程序, 指令来源见 src* 常量;
+	// nil 表示有来源无法编译, 全部回退到 gin binder
+	prog *bindProgram
 }
 
+// 合并程序中内置来源的下标, 也是绑定顺序: 同一字段在多个来源中出现时后者覆盖前者.
+const (
+	srcHeader = iota
+	srcCookie
+	srcURI
+	srcForm
+	srcCount
+)
+
 var planCache sync.Map // reflect.Type -> *bindingPlan
 
 // buildBindingPlan 递归扫描 struct 字段(忽略匿名/嵌入不变), 返回 plan.
@@ -575,13 +865,35 @@ func buildBindingPlan(t reflect.Type) *bindingPlan {
 	}
 	plan := &bindingPlan{fieldNameMap: make(map[string]string)}
 	scanType(t, plan, map[reflect.Type]struct{}{})
+	plan.validateHook = reflect.PointerTo(t).Implements(requestValidatorType)
+	if plan.hasBinding {
+		registerGlobalRules()
+	}
 	if t.NumField() == 0 {
 		plan.isEmpty = true
 	}
+	if plan.hasHeader || plan.hasCookie || plan.hasURI || plan.hasForm {
+		plan.prog = compileParamProgram(t, plan)
+	}
 	planCache.Store(t, plan)
 	return plan
 }
 
+// srcTags 是各内置来源对应的 struct tag.
+var srcTags = [srcCount]string{srcHeader: "header", srcCookie: "cookie", srcURI: "uri", srcForm: "form"}
+
+// compileParamProgram 把 t 用到的内置来源按 src* 顺序编译进同一个程序, 任一来源不支持时返回 nil.
+func compileParamProgram(t reflect.Type, plan *bindingPlan) *bindProgram {
+	used := [srcCount]bool{srcHeader: plan.hasHeader, srcCookie: plan.hasCookie, srcURI: plan.hasURI, srcForm: plan.hasForm}
+	p := &bindProgram{}
+	for src, tag := range srcTags {
+		if used[src] && !p.compileStruct(t, 0, tag, uint8(src)) {
+			return nil
+		}
+	}
+	return p
+}
+
 func scanType(t reflect.Type, plan *bindingPlan, seen map[reflect.Type]struct{}) {
 	for t.Kind() == reflect.Pointer {
 		t = t.Elem()
@@ -628,6 +940,9 @@ func scanType(t reflect.Type, plan *bindingPlan, seen map[reflect.Type]struct{})
 		}
 		if v, ok := f.Tag.Lookup("uri"); ok {
 			plan.hasURI = true
+			if name, _, _ := strings.Cut(v, ","); name != "" && name != "-" {
+				plan.uriNames = append(plan.uriNames, name)
+			}
 			if !mapped && v != "" {
 				plan.fieldNameMap[f.Name] = v
 				mapped = true
@@ -653,11 +968,16 @@ func scanType(t reflect.Type, plan *bindingPlan, seen map[reflect.Type]struct{})
 			plan.hasBinding = true
 		}
 
-		// 嵌套结构体(命名或匿名)继续扫描
+		// 嵌套结构体(命名或匿名)及切片、map 的元素结构体继续扫描
 		ft := f.Type
-		for ft.Kind() == reflect.Pointer {
+		for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array || ft.Kind() == reflect.Map {
 			ft = ft.Elem()
 		}
+		if ft.Implements(optionalValueType) {
+			registerOptionalType(ft)
+			plan.optionalTypes = append(plan.optionalTypes, ft)
+			continue
+		}
 		if ft.Kind() == reflect.Struct {
 			scanType(ft, plan, seen)
 		}
@@ -0,0 +1,256 @@
+// Package jsonrpc 把 ginx handler 暴露为 JSON-RPC 2.0 方法.
+//
+//	srv := jsonrpc.NewServer(engine)
+//	jsonrpc.Register(srv, "user.get", GetUser)
+//	jsonrpc.Register(srv, "user.create", CreateUser, ginx.Timeout(3*time.Second))
+//	r.POST("/rpc", srv.Handle)
+//
+// handler 与 REST 路由共用同一套默认值、binding 校验、Engine / 路由级拦截器和超时.
+// params 只支持按名称传参 (JSON 对象), 直接解码到 Req. 支持批量请求与通知;
+// 全部为通知的请求返回 204 No Content.
+//
+// 错误映射:
+//   - 请求体不是合法 JSON: -32700 Parse error
+//   - 请求体超出 Engine 的 MaxBodyBytes / MaxJSONDepth: -32600, HTTP 413 / 400
+//   - 不是合法的 JSON-RPC 请求对象: -32600 Invalid Request
+//   - 方法未注册: -32601 Method not found
+//   - params 解码或校验失败: -32602 Invalid params, data 为 {"code","msg"}
+//   - handler 返回 *ginx.ErrWrap: code / message 取自 ErrWrap
+//   - handler 返回 *jsonrpc.Error: 原样返回
+//   - 其它错误: -32603 Internal error, data 为 {"code","msg"}, msg 遵循 WithExposeInternalError
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/chendefine/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+// JSON-RPC 2.0 预定义错误码.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// CodeServerError 是 handler 返回 *ginx.ErrWrap 时使用的实现定义错误码, 业务 code 放在 data 中.
+const CodeServerError = -32000
+
+// Version 是协议版本号, 请求与响应的 jsonrpc 字段固定为该值.
+const Version = "2.0"
+
+// Error 是 JSON-RPC 错误对象. handler 也可以直接返回 *Error 自定义错误码与 data.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Error 实现 error 接口.
+func (e *Error) Error() string { return fmt.Sprintf("jsonrpc: %d %s", e.Code, e.Message) }
+
+// Server 保存已注册的方法, Handle 可直接挂到 gin 路由上.
+type Server struct {
+	engine  *ginx.Engine
+	mu      sync.RWMutex
+	methods map[string]*ginx.Method
+}
+
+// NewServer 创建 Server, 方法按 e 的配置执行; e 为 nil 时使用 ginx.Default().
+func NewServer(e *ginx.Engine) *Server {
+	if e == nil {
+		e = ginx.Default()
+	}
+	return &Server{engine: e, methods: make(map[string]*ginx.Method)}
+}
+
+// Register 把 fn 注册为名为 name 的方法. opts 中只有 RouteInterceptor / Timeout 等
+// 与 HTTP 无关的路由选项生效. name 为空、以 "rpc." 开头 (协议保留) 或重复注册时 panic.
+func Register[Req, Rsp any](s *Server, name string, fn ginx.HandlerFunc[Req, Rsp], opts ...ginx.RouteOption) {
+	if name == "" || strings.HasPrefix(name, "rpc.") {
+		panic(fmt.Sprintf("jsonrpc: invalid method name %q", name))
+	}
+	m := ginx.NewMethod(s.engine, fn, opts...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.methods[name]; ok {
+		panic(fmt.Sprintf("jsonrpc: method %q already registered", name))
+	}
+	s.methods[name] = m
+}
+
+// Methods 返回已注册的方法, 供生成文档等使用.
+func (s *Server) Methods() map[string]*ginx.Method {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]*ginx.Method, len(s.methods))
+	for k, v := range s.methods {
+		out[k] = v
+	}
+	return out
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+var null = json.RawMessage("null")
+
+// Handle 处理一次 HTTP 请求中的单个或批量调用. 批量调用按顺序依次执行.
+// 请求与响应信封均使用 Engine 的 Codec 编解码. 请求体受 Engine 的 WithMaxBodyBytes /
+// WithMaxJSONDepth 约束, 超出时以对应的 HTTP 状态 (413 / 400) 返回 -32600 Invalid Request.
+func (s *Server) Handle(gc *gin.Context) {
+	codec := s.engine.Codec()
+	body, err := s.engine.ReadBody(gc)
+	if err != nil {
+		var ew *ginx.ErrWrap
+		if errors.As(err, &ew) && ew.HttpCode > 0 {
+			writeStatus(gc, codec, ew.HttpCode, errorResponse(null, &Error{Code: CodeInvalidRequest, Message: ew.Msg}))
+			return
+		}
+		write(gc, codec, errorResponse(null, &Error{Code: CodeParseError, Message: "Parse error"}))
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := codec.Unmarshal(body, &batch); err != nil {
+			write(gc, codec, errorResponse(null, &Error{Code: CodeParseError, Message: "Parse error"}))
+			return
+		}
+		if len(batch) == 0 {
+			write(gc, codec, errorResponse(null, &Error{Code: CodeInvalidRequest, Message: "Invalid Request"}))
+			return
+		}
+		var out []*response
+		for _, raw := range batch {
+			if rsp := s.serve(gc, codec, raw); rsp != nil {
+				out = append(out, rsp)
+			}
+		}
+		if len(out) == 0 {
+			gc.Status(http.StatusNoContent)
+			return
+		}
+		write(gc, codec, out)
+		return
+	}
+	if !json.Valid(body) {
+		write(gc, codec, errorResponse(null, &Error{Code: CodeParseError, Message: "Parse error"}))
+		return
+	}
+	rsp := s.serve(gc, codec, body)
+	if rsp == nil {
+		gc.Status(http.StatusNoContent)
+		return
+	}
+	write(gc, codec, rsp)
+}
+
+// serve 执行单个调用; 通知返回 nil.
+func (s *Server) serve(gc *gin.Context, codec ginx.Codec, raw json.RawMessage) *response {
+	var req request
+	if err := codec.Unmarshal(raw, &req); err != nil || req.JSONRPC != Version || req.Method == "" || !validID(req.ID) {
+		id := null
+		if err == nil && validID(req.ID) && req.ID != nil {
+			id = req.ID
+		}
+		return errorResponse(id, &Error{Code: CodeInvalidRequest, Message: "Invalid Request"})
+	}
+	notify := req.ID == nil
+
+	s.mu.RLock()
+	m, ok := s.methods[req.Method]
+	s.mu.RUnlock()
+	var (
+		result any
+		err    error
+	)
+	if ok {
+		result, err = m.Call(gc, req.Params)
+	} else {
+		err = &Error{Code: CodeMethodNotFound, Message: "Method not found"}
+	}
+	if notify {
+		return nil
+	}
+	if err != nil {
+		return errorResponse(req.ID, toError(err))
+	}
+	b, err := codec.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, &Error{Code: CodeInternalError, Message: "Internal error"})
+	}
+	return &response{JSONRPC: Version, Result: b, ID: req.ID}
+}
+
+// write 以 200 写出 JSON-RPC 响应.
+func write(gc *gin.Context, codec ginx.Codec, v any) {
+	writeStatus(gc, codec, http.StatusOK, v)
+}
+
+func writeStatus(gc *gin.Context, codec ginx.Codec, status int, v any) {
+	b, err := codec.Marshal(v)
+	if err != nil {
+		_ = gc.Error(err)
+		gc.Status(http.StatusInternalServerError)
+		return
+	}
+	gc.Data(status, "application/json; charset=utf-8", b)
+}
+
+// toError 把 Method.Call 的错误转换为 JSON-RPC 错误对象.
+func toError(err error) *Error {
+	var re *Error
+	if errors.As(err, &re) {
+		return re
+	}
+	var ce *ginx.CallError
+	if errors.As(err, &ce) {
+		data := ginx.Error(ce.Code, ce.Msg)
+		if ce.Stage == ginx.StageHandler {
+			return &Error{Code: CodeInternalError, Message: "Internal error", Data: data}
+		}
+		return &Error{Code: CodeInvalidParams, Message: "Invalid params", Data: data}
+	}
+	var ew *ginx.ErrWrap
+	if errors.As(err, &ew) {
+		return &Error{Code: CodeServerError, Message: "Server error", Data: ginx.Error(ew.Code, ew.Msg)}
+	}
+	return &Error{Code: CodeInternalError, Message: "Internal error"}
+}
+
+// validID 判断 id 是否缺省或为字符串 / 数字 / null.
+func validID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+func errorResponse(id json.RawMessage, e *Error) *response {
+	return &response{JSONRPC: Version, Error: e, ID: id}
+}
@@ -0,0 +1,237 @@
+package jsonrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chendefine/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type addReq struct {
+	A int `json:"a" binding:"required"`
+	B int `json:"b" default:"10"`
+}
+
+type addRsp struct {
+	Sum int `json:"sum"`
+}
+
+type emptyReq struct{}
+
+func newTestServer(t *testing.T, calls *[]string) *gin.Engine {
+	t.Helper()
+	e := ginx.New(
+		ginx.WithExposeInternalError(false),
+		ginx.WithInterceptor(func(ctx context.Context, req any, next func() (any, error)) (any, error) {
+			*calls = append(*calls, ginx.GetHeader(ctx, "X-Caller"))
+			return next()
+		}),
+	)
+	srv := NewServer(e)
+	Register(srv, "add", func(ctx context.Context, req *addReq) (*addRsp, error) {
+		return &addRsp{Sum: req.A + req.B}, nil
+	})
+	Register(srv, "fail", func(ctx context.Context, req *emptyReq) (*addRsp, error) {
+		return nil, ginx.Error(4004, "not found")
+	})
+	Register(srv, "boom", func(ctx context.Context, req *emptyReq) (*addRsp, error) {
+		return nil, errors.New("db down")
+	})
+	Register(srv, "custom", func(ctx context.Context, req *emptyReq) (*addRsp, error) {
+		return nil, &Error{Code: -32001, Message: "rate limited", Data: map[string]int{"retry": 3}}
+	})
+	Register(srv, "slow", func(ctx context.Context, req *emptyReq) (*addRsp, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, ginx.Timeout(10*time.Millisecond))
+	Register(srv, "nothing", func(ctx context.Context, req *emptyReq) (*addRsp, error) {
+		return nil, nil
+	})
+	r := gin.New()
+	r.POST("/rpc", srv.Handle)
+	return r
+}
+
+func post(r http.Handler, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Caller", "svc")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestServer(t *testing.T) {
+	var calls []string
+	r := newTestServer(t, &calls)
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"call", `{"jsonrpc":"2.0","method":"add","params":{"a":1},"id":1}`, `{"jsonrpc":"2.0","result":{"sum":11},"id":1}`},
+		{"string id", `{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":"x"}`, `{"jsonrpc":"2.0","result":{"sum":3},"id":"x"}`},
+		{"null result", `{"jsonrpc":"2.0","method":"nothing","id":2}`, `{"jsonrpc":"2.0","result":null,"id":2}`},
+		{"validation", `{"jsonrpc":"2.0","method":"add","params":{},"id":3}`, `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"code":1,"msg":"a is required"}},"id":3}`},
+		{"positional params", `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":4}`, `"code":-32602`},
+		{"errwrap", `{"jsonrpc":"2.0","method":"fail","id":5}`, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"Server error","data":{"code":4004,"msg":"not found"}},"id":5}`},
+		{"internal", `{"jsonrpc":"2.0","method":"boom","id":6}`, `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error","data":{"code":2,"msg":"Internal Server Error"}},"id":6}`},
+		{"custom", `{"jsonrpc":"2.0","method":"custom","id":7}`, `{"jsonrpc":"2.0","error":{"code":-32001,"message":"rate limited","data":{"retry":3}},"id":7}`},
+		{"timeout", `{"jsonrpc":"2.0","method":"slow","id":8}`, `{"jsonrpc":"2.0","error":{"code":-32000,"message":"Server error","data":{"code":3,"msg":"request timeout"}},"id":8}`},
+		{"not found", `{"jsonrpc":"2.0","method":"nope","id":9}`, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":9}`},
+		{"bad version", `{"jsonrpc":"1.0","method":"add","id":10}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":10}`},
+		{"bad id", `{"jsonrpc":"2.0","method":"add","id":{}}`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{"parse error", `{"jsonrpc":`, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`},
+		{"empty batch", `[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`},
+		{"batch", `[{"jsonrpc":"2.0","method":"add","params":{"a":1},"id":1},{"jsonrpc":"2.0","method":"add","params":{"a":5}},1,{"jsonrpc":"2.0","method":"fail","id":2}]`,
+			`[{"jsonrpc":"2.0","result":{"sum":11},"id":1},{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null},{"jsonrpc":"2.0","error":{"code":-32000,"message":"Server error","data":{"code":4004,"msg":"not found"}},"id":2}]`},
+	}
+	for _, tt := range tests {
+		w := post(r, tt.body)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.want) {
+			t.Fatalf("%s = %d %s, want %s", tt.name, w.Code, w.Body.String(), tt.want)
+		}
+	}
+
+	calls = nil
+	for _, body := range []string{
+		`{"jsonrpc":"2.0","method":"add","params":{"a":1}}`,
+		`[{"jsonrpc":"2.0","method":"add","params":{"a":1}},{"jsonrpc":"2.0","method":"boom"}]`,
+	} {
+		if w := post(r, body); w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+			t.Fatalf("notification %s = %d %s", body, w.Code, w.Body.String())
+		}
+	}
+	if len(calls) != 3 || calls[0] != "svc" {
+		t.Fatalf("interceptor calls = %v", calls)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	srv := NewServer(nil)
+	fn := func(ctx context.Context, req *emptyReq) (*addRsp, error) { return nil, nil }
+	Register(srv, "a", fn)
+	for _, name := range []string{"", "rpc.discover", "a"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("Register(%q) did not panic", name)
+				}
+			}()
+			Register(srv, name, fn)
+		}()
+	}
+	if len(srv.Methods()) != 1 {
+		t.Fatalf("methods = %v", srv.Methods())
+	}
+}
+
+type whoamiReq struct {
+	UserID string `ctx:"user_id" binding:"required"`
+}
+
+func TestBindingSourceOverridesParams(t *testing.T) {
+	e := ginx.New()
+	e.RegisterBindingSource("ctx", func(gc *gin.Context, key string) ([]string, bool) {
+		v, ok := gc.Get(key)
+		if !ok {
+			return nil, false
+		}
+		return []string{v.(string)}, true
+	})
+	srv := NewServer(e)
+	Register(srv, "whoami", func(ctx context.Context, req *whoamiReq) (*whoamiReq, error) { return req, nil })
+	r := gin.New()
+	r.POST("/rpc", func(gc *gin.Context) {
+		if gc.GetHeader("X-Caller") != "" {
+			gc.Set("user_id", "alice")
+		}
+		srv.Handle(gc)
+	})
+
+	w := post(r, `{"jsonrpc":"2.0","id":1,"method":"whoami","params":{"UserID":"mallory"}}`)
+	if !strings.Contains(w.Body.String(), `"result":{"UserID":"alice"}`) {
+		t.Fatalf("params overrode source: %s", w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"whoami","params":{"UserID":"mallory"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), `"code":-32602`) {
+		t.Fatalf("missing source should fail validation: %s", w.Body.String())
+	}
+}
+
+// countingCodec 包装 ginx.StdCodec 并记录调用次数.
+type countingCodec struct {
+	marshal, unmarshal int
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshal++
+	return ginx.StdCodec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshal++
+	return ginx.StdCodec.Unmarshal(data, v)
+}
+
+func (c *countingCodec) NewDecoder(r io.Reader) ginx.Decoder { return ginx.StdCodec.NewDecoder(r) }
+
+func TestEngineCodec(t *testing.T) {
+	codec := &countingCodec{}
+	srv := NewServer(ginx.New(ginx.WithCodec(codec)))
+	Register(srv, "add", func(ctx context.Context, req *addReq) (*addRsp, error) {
+		return &addRsp{Sum: req.A + req.B}, nil
+	})
+	r := gin.New()
+	r.POST("/rpc", srv.Handle)
+
+	w := post(r, `[{"jsonrpc":"2.0","id":1,"method":"add","params":{"a":1}},{"jsonrpc":"2.0","id":2,"method":"add","params":{"a":2}}]`)
+	if !strings.Contains(w.Body.String(), `"result":{"sum":11}`) || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("batch = %v %s", w.Header(), w.Body.String())
+	}
+	// 批量数组 + 2 个信封; 2 个 result + 响应数组.
+	if codec.unmarshal != 3 || codec.marshal != 3 {
+		t.Fatalf("codec calls = %+v", *codec)
+	}
+}
+
+func TestBodyLimits(t *testing.T) {
+	srv := NewServer(ginx.New(ginx.WithMaxBodyBytes(128), ginx.WithMaxJSONDepth(4)))
+	Register(srv, "add", func(ctx context.Context, req *addReq) (*addRsp, error) {
+		return &addRsp{Sum: req.A + req.B}, nil
+	})
+	r := gin.New()
+	r.POST("/rpc", srv.Handle)
+
+	tests := []struct {
+		name, body string
+		status     int
+		want       string
+	}{
+		{"ok", `{"jsonrpc":"2.0","id":1,"method":"add","params":{"a":1}}`, http.StatusOK, `"result":{"sum":11}`},
+		{"too large", `{"jsonrpc":"2.0","id":1,"method":"add","params":{"a":1,"pad":"` + strings.Repeat("x", 128) + `"}}`, http.StatusRequestEntityTooLarge, `"code":-32600,"message":"request body exceeds 128 bytes"`},
+		{"too deep", `{"jsonrpc":"2.0","id":1,"method":"add","params":{"a":[[[[1]]]]}}`, http.StatusBadRequest, `"code":-32600,"message":"JSON nesting too deep`},
+	}
+	for _, tt := range tests {
+		w := post(r, tt.body)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) {
+			t.Fatalf("%s = %d %s", tt.name, w.Code, w.Body.String())
+		}
+	}
+}
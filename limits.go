@@ -0,0 +1,254 @@
+package ginx
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartMemory 与 gin binding.FormMultipart 保持一致.
+const multipartMemory = 32 << 20
+
+// requestLimits 是请求体限制, 零值表示不限制.
+type requestLimits struct {
+	maxBodyBytes          int64
+	maxJSONDepth          int
+	maxMultipartFiles     int
+	maxMultipartPartBytes int64
+}
+
+// WithMaxBodyBytes 设置所有路由的默认请求体上限, 超出时返回 413; n <= 0 表示不限制.
+func WithMaxBodyBytes(n int64) EngineOption {
+	return func(e *Engine) { e.limits.maxBodyBytes = n }
+}
+
+// WithMaxJSONDepth 设置 JSON body 默认最大嵌套深度, 超出时返回 400; n <= 0 表示不限制.
+func WithMaxJSONDepth(n int) EngineOption {
+	return func(e *Engine) { e.limits.maxJSONDepth = n }
+}
+
+// WithMultipartLimits 设置 multipart/form-data 默认的文件数与单个文件大小上限,
+// 超出时返回 413; 参数 <= 0 表示该项不限制.
+func WithMultipartLimits(maxFiles int, maxPartBytes int64) EngineOption {
+	return func(e *Engine) {
+		e.limits.maxMultipartFiles = maxFiles
+		e.limits.maxMultipartPartBytes = maxPartBytes
+	}
+}
+
+// MaxBodyBytes 覆盖当前路由的请求体上限; n <= 0 表示该路由不限制.
+func MaxBodyBytes(n int64) RouteOption {
+	return func(c *routeConfig) { c.maxBodyBytes = &n }
+}
+
+// MaxJSONDepth 覆盖当前路由的 JSON 最大嵌套深度; n <= 0 表示该路由不限制.
+func MaxJSONDepth(n int) RouteOption {
+	return func(c *routeConfig) { c.maxJSONDepth = &n }
+}
+
+// MultipartLimits 覆盖当前路由的 multipart 文件数与单个文件大小上限.
+func MultipartLimits(maxFiles int, maxPartBytes int64) RouteOption {
+	return func(c *routeConfig) {
+		c.maxMultipartFiles = &maxFiles
+		c.maxMultipartPartBytes = &maxPartBytes
+	}
+}
+
+// bodyTooLargeError 表示请求体超出上限, 渲染为 413.
+type bodyTooLargeError struct {
+	msg string
+}
+
+func (e *bodyTooLargeError) Error() string { return e.msg }
+
+// errJSONTooDeep 由 jsonDepthReader 返回, 渲染为 400.
+var errJSONTooDeep = errors.New("JSON nesting too deep")
+
+// limitBody 按 maxBodyBytes 包装请求体; Content-Length 已超出时直接返回错误.
+func limitBody(x exchange, limits requestLimits) error {
+	n := limits.maxBodyBytes
+	r := x.Request()
+	if n <= 0 || r == nil || r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	if r.ContentLength > n {
+		return &bodyTooLargeError{msg: fmt.Sprintf("request body exceeds %d bytes", n)}
+	}
+	r.Body = http.MaxBytesReader(x.Writer(), r.Body, n)
+	return nil
+}
+
+// checkMultipart 边读边校验 multipart 表单的文件数与单个文件大小, 超出时立即停止读取.
+// 原始 part 经限流后重新编码交给 multipart.Reader.ReadForm, 解析结果留在
+// Request.MultipartForm 上, 之后的 gin 绑定直接复用.
+func checkMultipart(r *http.Request, limits requestLimits) error {
+	if limits.maxMultipartFiles <= 0 && limits.maxMultipartPartBytes <= 0 {
+		return nil
+	}
+	src, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	var walkErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		walkErr = copyMultipart(mw, src, limits)
+		if walkErr == nil {
+			walkErr = mw.Close()
+		}
+		pw.CloseWithError(walkErr)
+	}()
+	form, err := multipart.NewReader(pr, mw.Boundary()).ReadForm(multipartMemory)
+	// ReadForm 提前失败时让写端以同一错误退出.
+	_ = pr.CloseWithError(err)
+	<-done
+	if walkErr != nil || err != nil {
+		if form != nil {
+			_ = form.RemoveAll()
+		}
+		return cmp.Or(walkErr, err)
+	}
+
+	// 与 Request.ParseMultipartForm 一致, 表单值同时并入 Form / PostForm.
+	if err := r.ParseForm(); err != nil {
+		_ = form.RemoveAll()
+		return err
+	}
+	if r.PostForm == nil {
+		r.PostForm = make(url.Values)
+	}
+	for k, v := range form.Value {
+		r.Form[k] = append(r.Form[k], v...)
+		r.PostForm[k] = append(r.PostForm[k], v...)
+	}
+	r.MultipartForm = form
+	return nil
+}
+
+// copyMultipart 把 src 的每个 part 复制到 dst, 文件数或单个文件大小超出限制时返回 413 错误.
+func copyMultipart(dst *multipart.Writer, src *multipart.Reader, limits requestLimits) error {
+	files := 0
+	for {
+		part, err := src.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		w, err := dst.CreatePart(part.Header)
+		if err != nil {
+			return err
+		}
+		if part.FileName() == "" {
+			if _, err := io.Copy(w, part); err != nil {
+				return err
+			}
+			continue
+		}
+		if files++; limits.maxMultipartFiles > 0 && files > limits.maxMultipartFiles {
+			return &bodyTooLargeError{msg: fmt.Sprintf("multipart form exceeds %d files", limits.maxMultipartFiles)}
+		}
+		var rd io.Reader = part
+		if limits.maxMultipartPartBytes > 0 {
+			rd = io.LimitReader(part, limits.maxMultipartPartBytes+1)
+		}
+		n, err := io.Copy(w, rd)
+		if err != nil {
+			return err
+		}
+		if limits.maxMultipartPartBytes > 0 && n > limits.maxMultipartPartBytes {
+			return &bodyTooLargeError{msg: fmt.Sprintf("multipart file %q exceeds %d bytes", part.FormName(), limits.maxMultipartPartBytes)}
+		}
+	}
+}
+
+// ReadBody 按 e 的 WithMaxBodyBytes / WithMaxJSONDepth 读取完整的 JSON 请求体, 供 ginx/jsonrpc 等
+// 自行解析请求体的传输复用. 超出限制时返回 *ErrWrap: 过大为 413, 嵌套过深为 400, code 为 invalidArgCode.
+func (e *Engine) ReadBody(gc *gin.Context) ([]byte, error) {
+	e.mu.RLock()
+	limits, code := e.limits, e.invalidArgCode
+	e.mu.RUnlock()
+	if gc.Request == nil || gc.Request.Body == nil {
+		return nil, nil
+	}
+	if err := limitBody(ginExchangeOf(gc), limits); err != nil {
+		status, msg := limitError(err)
+		return nil, Error(code, msg).Status(status)
+	}
+	var body io.Reader = gc.Request.Body
+	if limits.maxJSONDepth > 0 {
+		body = &jsonDepthReader{r: body, max: limits.maxJSONDepth}
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		if status, msg := limitError(err); status > 0 {
+			return nil, Error(code, msg).Status(status)
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// limitError 返回请求体限制类错误对应的 HTTP 状态码与提示, 其它错误返回 0.
+func limitError(err error) (int, string) {
+	var tooLarge *bodyTooLargeError
+	var maxBytes *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge, tooLarge.msg
+	case errors.As(err, &maxBytes):
+		return http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytes.Limit)
+	case errors.Is(err, errJSONTooDeep):
+		return http.StatusBadRequest, err.Error()
+	}
+	return 0, ""
+}
+
+// jsonDepthReader 在 json.Decoder 读取的同时扫描对象/数组嵌套深度,
+// 超出 max 时返回 errJSONTooDeep, 避免深层嵌套消耗大量内存与栈.
+type jsonDepthReader struct {
+	r        io.Reader
+	max      int
+	depth    int
+	inString bool
+	escaped  bool
+	err      error
+}
+
+func (d *jsonDepthReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	for i, c := range p[:n] {
+		switch {
+		case d.inString:
+			switch {
+			case d.escaped:
+				d.escaped = false
+			case c == '\\':
+				d.escaped = true
+			case c == '"':
+				d.inString = false
+			}
+		case c == '"':
+			d.inString = true
+		case c == '{' || c == '[':
+			d.depth++
+			if d.depth > d.max {
+				d.err = fmt.Errorf("%w: exceeds max depth %d", errJSONTooDeep, d.max)
+				return i, d.err
+			}
+		case c == '}' || c == ']':
+			d.depth--
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,137 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type limitReq struct {
+	Data any `json:"data"`
+}
+
+type limitUploadReq struct {
+	Files []*multipart.FileHeader `form:"files"`
+	Note  string                  `form:"note"`
+}
+
+// countingReader 记录已被读取的字节数.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func postJSON(r http.Handler, path, body string, chunked bool) *httptest.ResponseRecorder {
+	var rd io.Reader = strings.NewReader(body)
+	if chunked {
+		// 隐藏长度, 走 MaxBytesReader 而不是 Content-Length 预检.
+		rd = io.MultiReader(rd)
+	}
+	req := httptest.NewRequest(http.MethodPost, path, rd)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestBodyLimits(t *testing.T) {
+	r := gin.New()
+	g := New(WithMaxBodyBytes(64), WithMaxJSONDepth(4)).Wrap(r)
+	echo := func(ctx context.Context, req *limitReq) (*limitReq, error) { return req, nil }
+	POST(g, "/default", echo)
+	POST(g, "/big", echo, MaxBodyBytes(1<<20), MaxJSONDepth(0))
+	POST(g, "/raw", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		b, err := io.ReadAll(Request(ctx).Body)
+		if err != nil {
+			return nil, err
+		}
+		return &simpleRsp{Message: string(b)}, nil
+	})
+
+	large := `{"data":"` + strings.Repeat("x", 100) + `"}`
+	deep := `{"data":` + strings.Repeat("[", 10) + strings.Repeat("]", 10) + `}`
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		status  int
+		msg     string
+	}{
+		{"ok", "/default", `{"data":[[1]]}`, false, http.StatusOK, ""},
+		{"content-length", "/default", large, false, http.StatusRequestEntityTooLarge, "request body exceeds 64 bytes"},
+		{"streamed", "/default", large, true, http.StatusRequestEntityTooLarge, "request body exceeds 64 bytes"},
+		{"too deep", "/default", deep, false, http.StatusBadRequest, "JSON nesting too deep"},
+		{"brackets in strings", "/default", `{"data":"[[[[[[[[{{{{"}`, false, http.StatusOK, ""},
+		{"route override", "/big", large, false, http.StatusOK, ""},
+		{"route depth override", "/big", deep, false, http.StatusOK, ""},
+		{"handler reads", "/raw", large, true, http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postJSON(r, tt.path, tt.body, tt.chunked)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.msg != "" && (!strings.Contains(w.Body.String(), tt.msg) || !strings.Contains(w.Body.String(), `"code":1`)) {
+				t.Fatalf("body = %s, want msg %q", w.Body.String(), tt.msg)
+			}
+		})
+	}
+}
+
+func TestMultipartLimits(t *testing.T) {
+	r := gin.New()
+	var got int
+	var note string
+	POST(New(WithMultipartLimits(2, 10)).Wrap(r), "/upload", func(ctx context.Context, req *limitUploadReq) (*simpleRsp, error) {
+		got, note = len(req.Files), req.Note
+		return &simpleRsp{}, nil
+	})
+
+	var body *countingReader
+	upload := func(files ...string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		_ = mw.WriteField("note", "hi")
+		for i, content := range files {
+			fw, _ := mw.CreateFormFile("files", "f"+string(rune('a'+i)))
+			_, _ = fw.Write([]byte(content))
+		}
+		_ = mw.Close()
+		body = &countingReader{r: &buf}
+		req := httptest.NewRequest(http.MethodPost, "/upload", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := upload("a", "b"); w.Code != http.StatusOK || got != 2 || note != "hi" {
+		t.Fatalf("within limits = %d (files %d, note %q): %s", w.Code, got, note, w.Body.String())
+	}
+	if w := upload("a", "b", "c"); w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), "exceeds 2 files") {
+		t.Fatalf("too many files = %d %s", w.Code, w.Body.String())
+	}
+	if w := upload("a", strings.Repeat("x", 11)); w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), "exceeds 10 bytes") {
+		t.Fatalf("part too large = %d %s", w.Code, w.Body.String())
+	}
+	// 超限后立即停止读取, 剩余的 part 不会被缓冲或落盘.
+	huge := strings.Repeat("x", 8<<20)
+	if w := upload("a", huge, huge); w.Code != http.StatusRequestEntityTooLarge || body.n > 1<<20 {
+		t.Fatalf("streaming = %d, read %d bytes: %s", w.Code, body.n, w.Body.String())
+	}
+}
@@ -0,0 +1,145 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/creasty/defaults"
+	"github.com/gin-gonic/gin"
+)
+
+// Method 是脱离 REST 路由的 handler 调用入口, 供 ginx/jsonrpc 等其它传输复用
+// Engine 的默认值、binding 校验、拦截器、超时与错误码配置.
+type Method struct {
+	reqType reflect.Type
+	rspType reflect.Type
+	call    func(gc *gin.Context, params []byte) (any, error)
+}
+
+// CallError 是 Method.Call 返回的非业务错误: 参数解码/校验失败 (Stage 为
+// StageBinding / StageValidation), 或 handler 返回的非 *ErrWrap 错误 (StageHandler).
+// Msg 已按 Engine 配置处理: 校验错误使用 json tag 字段名, 内部错误遵循 WithExposeInternalError.
+type CallError struct {
+	Stage ErrorStage
+	Code  int
+	Msg   string
+	Err   error
+}
+
+// Error 实现 error 接口.
+func (e *CallError) Error() string { return e.Msg }
+
+// Unwrap 返回原始错误.
+func (e *CallError) Unwrap() error { return e.Err }
+
+// NewMethod 按 e 与路由选项把 fn 封装为 Method, e 为 nil 时使用默认 Engine.
+// 只有 RouteInterceptor / Timeout 等与 HTTP 无关的路由选项生效.
+func NewMethod[Req, Rsp any](e *Engine, fn HandlerFunc[Req, Rsp], opts ...RouteOption) *Method {
+	if e == nil {
+		e = defaultEngine
+	}
+	cfg := e.resolveRoute(opts)
+	var reqZero Req
+	plan := routePlan(cfg, reflect.TypeOf(reqZero))
+	return &Method{
+		reqType: reflect.TypeOf(reqZero),
+		rspType: reflect.TypeOf((*Rsp)(nil)).Elem(),
+		call: func(gc *gin.Context, params []byte) (any, error) {
+			return callMethod(gc, cfg, plan, fn, params)
+		},
+	}
+}
+
+// ReqType 返回 handler 的 Req 类型.
+func (m *Method) ReqType() reflect.Type { return m.reqType }
+
+// RspType 返回 handler 的 Rsp 类型.
+func (m *Method) RspType() reflect.Type { return m.rspType }
+
+// Call 把 JSON 对象 params 解码为 Req (空或 null 表示无参数), 填充默认值并校验后
+// 经拦截器链执行 handler. 返回的 error 为 *CallError 或 *ErrWrap;
+// ErrTimeout / ErrInvalidCursor 等内置错误按 REST 相同的 code 转换为 *ErrWrap.
+func (m *Method) Call(gc *gin.Context, params []byte) (any, error) {
+	return m.call(gc, params)
+}
+
+func callMethod[Req, Rsp any](gc *gin.Context, cfg resolved, plan *bindingPlan, fn HandlerFunc[Req, Rsp], params []byte) (any, error) {
+	x := ginExchangeOf(gc)
+	var req Req
+	if plan.hasDefaults {
+		_ = defaults.Set(&req)
+	}
+	if params = bytes.TrimSpace(params); len(params) > 0 && !bytes.Equal(params, []byte("null")) {
+		decoder := cfg.jsonCodec().NewDecoder(bytes.NewReader(params))
+		if cfg.jsonDecoderUseNumber {
+			decoder.UseNumber()
+		}
+		if cfg.disallowUnknown {
+			decoder.DisallowUnknownFields()
+		}
+		if err := decoder.Decode(&req); err != nil {
+			if cfg.disallowUnknown {
+				err = unknownFieldError(err, reflect.TypeFor[Req](), params)
+			}
+			return nil, &CallError{Stage: StageBinding, Code: cfg.invalidArgCode, Msg: err.Error(), Err: err}
+		}
+	}
+	// 与 REST 一致, 自定义来源覆盖 params 中的同名字段.
+	if obj := structPointer(&req); len(plan.sources) > 0 && obj != nil {
+		if err := bindSources(x, plan, obj); err != nil {
+			return nil, &CallError{Stage: StageBinding, Code: cfg.invalidArgCode, Msg: err.Error(), Err: err}
+		}
+	}
+	if plan.hasBinding {
+		if err := cfg.validator.validateStruct(&req); err != nil {
+			stage := StageBinding
+			msg := err.Error()
+			if isValidationError(err) {
+				stage = StageValidation
+				msg = cfg.validator.formatError(err, plan.fieldNameMap)
+			}
+			return nil, &CallError{Stage: stage, Code: cfg.invalidArgCode, Msg: msg, Err: err}
+		}
+	}
+
+	ctx, cancel := withHandlerDeadline(acquireContext(x), x, cfg)
+	defer cancel()
+	defer releaseContext(ctx)
+
+	if plan.validateHook {
+		if err := runValidateHook(ctx, &req); err != nil {
+			err = errors.Unwrap(err)
+			var ew *ErrWrap
+			if errors.As(err, &ew) {
+				return nil, ew
+			}
+			msg := err.Error()
+			if isValidationError(err) {
+				msg = cfg.validator.formatError(err, plan.fieldNameMap)
+			}
+			return nil, &CallError{Stage: StageValidation, Code: cfg.invalidArgCode, Msg: msg, Err: err}
+		}
+	}
+
+	rsp, err := invokeHandler(ctx, &req, cfg.interceptors, fn)
+	if err == nil && errors.Is(context.Cause(ctx), ErrTimeout) {
+		err = ErrTimeout
+	}
+	if err != nil {
+		if isTimeout(ctx, err) {
+			err = ErrTimeout
+		}
+		err = builtinError(cfg, err)
+		var ew *ErrWrap
+		if errors.As(err, &ew) {
+			return nil, ew
+		}
+		return nil, &CallError{Stage: StageHandler, Code: cfg.internalErrorCode, Msg: internalMessage(cfg, err), Err: err}
+	}
+	if rsp == nil {
+		return nil, nil
+	}
+	return rsp, nil
+}
@@ -0,0 +1,121 @@
+package ginx
+
+import (
+	"errors"
+	"net/http"
+)
+
+var errHandlerPanic = errors.New("ginx: handler panicked")
+
+type observationKey struct{}
+
+// observation 收集单次请求的 Outcome, 仅在路由配置了 Observer 时创建并挂到请求上,
+// 以便 writeError / 流式 sender 等分散的写出路径记录结果.
+type observation struct {
+	outcome      Outcome
+	internalCode int
+}
+
+func observationOf(x exchange) *observation {
+	if x == nil {
+		return nil
+	}
+	v, ok := x.Get(observationKey{})
+	if !ok {
+		return nil
+	}
+	obs, _ := v.(*observation)
+	return obs
+}
+
+func (o *observation) fail(stage ErrorStage, err error, code int) {
+	if o == nil {
+		return
+	}
+	o.outcome.Stage = stage
+	o.outcome.Err = err
+	o.outcome.Code = code
+}
+
+func (o *observation) internalErrorCode() int {
+	if o == nil {
+		return 0
+	}
+	return o.internalCode
+}
+
+func (o *observation) sent() {
+	if o != nil {
+		o.outcome.Events++
+	}
+}
+
+// observe 依次启动 Observer, 把派生出的 context 写回请求, 再执行 serve.
+// 响应写出后按相反顺序调用各 done; serve panic 时先以 500 结束观察再继续向上传播.
+func observe(x exchange, cfg resolved, serve func(*observation)) {
+	obs := &observation{internalCode: cfg.internalErrorCode}
+	x.Set(observationKey{}, obs)
+
+	// 传入携带当前请求的 context, Observer 可用 GetHeader / Request 读取请求.
+	ctx := acquireContext(x)
+	info := cfg.route
+	r := x.Request()
+	if cfg.routeFromRequest && r != nil {
+		// net/http 适配的 handler 可挂在多个模式上, 方法与路径按本次请求填充.
+		info.Method = r.Method
+		info.Path = patternPath(r.Pattern)
+	}
+	dones := make([]func(Outcome), 0, len(cfg.observers))
+	for _, o := range cfg.observers {
+		next, done := o(ctx, info)
+		if next != nil {
+			ctx = next
+		}
+		if done != nil {
+			dones = append(dones, done)
+		}
+	}
+	if r != nil && ctx != r.Context() {
+		x.SetRequest(r.WithContext(ctx))
+	}
+
+	panicking := true
+	defer func() {
+		w := x.Writer()
+		obs.outcome.Status = w.Status()
+		if panicking {
+			obs.fail(StageHandler, errHandlerPanic, cfg.internalErrorCode)
+			if !w.Written() {
+				obs.outcome.Status = http.StatusInternalServerError
+			}
+		}
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](obs.outcome)
+		}
+	}()
+	serve(obs)
+	panicking = false
+}
+
+// bodyCode 尽量从自定义错误处理器返回的 body 中取出业务 code, 取不到时使用 fallback.
+func bodyCode(body any, fallback int) int {
+	switch b := body.(type) {
+	case *ErrWrap:
+		if b != nil {
+			return b.Code
+		}
+	case ErrWrap:
+		return b.Code
+	case successBody:
+		return b.Code
+	case *successBody:
+		if b != nil {
+			return b.Code
+		}
+	case map[string]any:
+		if code, ok := b["code"].(int); ok {
+			return code
+		}
+	}
+	return fallback
+}
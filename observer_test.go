@@ -0,0 +1,178 @@
+package ginx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type observerKey struct{}
+
+func recordingObserver(infos *[]RegisterInfo, outs *[]Outcome) Observer {
+	return func(ctx context.Context, info RegisterInfo) (context.Context, func(Outcome)) {
+		*infos = append(*infos, info)
+		return context.WithValue(ctx, observerKey{}, info.OperationID), func(out Outcome) {
+			*outs = append(*outs, out)
+		}
+	}
+}
+
+func TestObserverOutcomes(t *testing.T) {
+	var infos []RegisterInfo
+	var outs []Outcome
+	e := New(WithObserver(recordingObserver(&infos, &outs)))
+	r := gin.New()
+	g := e.Group(r, "/v1")
+
+	GET(g, "/items/:id", func(ctx context.Context, req *uriReq) (*simpleRsp, error) {
+		if v, _ := ctx.Value(observerKey{}).(string); v != "getItem" {
+			t.Errorf("observer context not propagated, got %q", v)
+		}
+		if req.ID == "missing" {
+			return nil, Error(40401, "not found").Status(404)
+		}
+		if req.ID == "boom" {
+			return nil, errors.New("boom")
+		}
+		return &simpleRsp{Message: req.ID}, nil
+	}, OperationID("getItem"))
+	GET(g, "/query", func(ctx context.Context, req *queryReq) (*simpleRsp, error) {
+		return &simpleRsp{}, nil
+	})
+	POST(g, "/json", func(ctx context.Context, req *emailJSONReq) (*simpleRsp, error) {
+		return &simpleRsp{}, nil
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		code   int
+		stage  ErrorStage
+	}{
+		{"success", http.MethodGet, "/v1/items/1", "", 200, 0, StageNone},
+		{"handler ErrWrap", http.MethodGet, "/v1/items/missing", "", 404, 40401, StageHandler},
+		{"handler error", http.MethodGet, "/v1/items/boom", "", 500, 2, StageHandler},
+		{"validation", http.MethodGet, "/v1/query?page=0", "", 400, 1, StageValidation},
+		{"binding", http.MethodPost, "/v1/json", "{", 400, 1, StageBinding},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outs = outs[:0]
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if len(outs) != 1 {
+				t.Fatalf("outcomes = %d, want 1", len(outs))
+			}
+			out := outs[0]
+			if out.Status != tt.status || w.Code != tt.status {
+				t.Fatalf("status = %d (recorder %d), want %d", out.Status, w.Code, tt.status)
+			}
+			if out.Code != tt.code || out.Stage != tt.stage {
+				t.Fatalf("code/stage = %d/%q, want %d/%q", out.Code, out.Stage, tt.code, tt.stage)
+			}
+			if (out.Err != nil) != (tt.stage != StageNone) {
+				t.Fatalf("err = %v, stage %q", out.Err, tt.stage)
+			}
+			if out.Req == nil {
+				t.Fatal("expected bound request in outcome")
+			}
+		})
+	}
+
+	if infos[0].OperationID != "getItem" || infos[0].Method != http.MethodGet || infos[0].Path != "/items/:id" {
+		t.Fatalf("unexpected RegisterInfo: %+v", infos[0])
+	}
+}
+
+func TestObserverOrderAndRouteObserver(t *testing.T) {
+	var calls []string
+	mk := func(name string) Observer {
+		return func(ctx context.Context, info RegisterInfo) (context.Context, func(Outcome)) {
+			calls = append(calls, "start:"+name)
+			return nil, func(Outcome) { calls = append(calls, "done:"+name) }
+		}
+	}
+	e := New(WithObserver(mk("engine")))
+	r := gin.New()
+	GET(e.Wrap(r), "/ok", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		calls = append(calls, "handler")
+		return &simpleRsp{}, nil
+	}, RouteObserver(mk("route")))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	want := "start:engine,start:route,handler,done:route,done:engine"
+	if got := strings.Join(calls, ","); got != want {
+		t.Fatalf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestObserverStreamEvents(t *testing.T) {
+	var infos []RegisterInfo
+	var outs []Outcome
+	e := New(WithObserver(recordingObserver(&infos, &outs)))
+	r := gin.New()
+	SSE(e.Wrap(r), "/sse", func(ctx context.Context, req *simpleReq, send Sender) error {
+		for i := 0; i < 3; i++ {
+			if err := send(Event{Data: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	JSONLines(e.Wrap(r), http.MethodGet, "/ndjson", func(ctx context.Context, req *simpleReq, send JSONLinesSender) error {
+		if err := send(map[string]int{"n": 1}); err != nil {
+			return err
+		}
+		return errors.New("upstream closed")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sse", nil))
+	if len(outs) != 1 || outs[0].Events != 3 || outs[0].Err != nil {
+		t.Fatalf("unexpected SSE outcome: %+v", outs)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ndjson", nil))
+	out := outs[1]
+	if out.Events != 1 || out.Stage != StageHandler || out.Code != 2 || out.Status != 200 {
+		t.Fatalf("unexpected JSON Lines outcome: %+v", out)
+	}
+}
+
+func TestObserverPanic(t *testing.T) {
+	var infos []RegisterInfo
+	var outs []Outcome
+	e := New(WithObserver(recordingObserver(&infos, &outs)))
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	GET(e.Wrap(r), "/panic", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if len(outs) != 1 || outs[0].Status != 500 || outs[0].Stage != StageHandler || outs[0].Code != 2 {
+		t.Fatalf("unexpected panic outcome: %+v", outs)
+	}
+}
@@ -0,0 +1,135 @@
+package ginx
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	validator "github.com/go-playground/validator/v10"
+)
+
+// Optional 区分请求字段的三种状态: 未出现、显式 null、有值, 用于 PATCH 等部分更新语义.
+// 零值表示未出现.
+//
+//	type PatchUserReq struct {
+//		ID       int                   `uri:"id"`
+//		Nickname ginx.Optional[string] `json:"nickname,omitzero" binding:"omitempty,max=32"`
+//	}
+//
+// JSON 中出现 null 为 Null, 出现其它值为有值; query / form 中出现该键即视为已设置,
+// 非 string 类型的空值视为 Null. 校验规则作用于值本身, 未出现或 Null 时按空值处理,
+// 因此约束规则应前置 omitempty. 编码时未出现与 Null 都输出 null, 配合 omitzero
+// 省略未出现的字段.
+type Optional[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// Some 返回有值的 Optional.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{value: v, set: true}
+}
+
+// Null 返回显式 null 的 Optional.
+func Null[T any]() Optional[T] {
+	return Optional[T]{set: true, null: true}
+}
+
+// IsSet 报告字段是否出现 (含显式 null).
+func (o Optional[T]) IsSet() bool { return o.set }
+
+// IsNull 报告字段是否为显式 null.
+func (o Optional[T]) IsNull() bool { return o.null }
+
+// IsZero 报告字段是否未出现, 供 encoding/json 的 omitzero 使用.
+func (o Optional[T]) IsZero() bool { return !o.set }
+
+// Get 返回字段的值, 未出现或为 null 时 ok 为 false.
+func (o Optional[T]) Get() (v T, ok bool) {
+	return o.value, o.set && !o.null
+}
+
+// Or 返回字段的值, 未出现或为 null 时返回 def.
+func (o Optional[T]) Or(def T) T {
+	if v, ok := o.Get(); ok {
+		return v
+	}
+	return def
+}
+
+// Ptr 返回值的指针, 未出现或为 null 时返回 nil.
+func (o Optional[T]) Ptr() *T {
+	if v, ok := o.Get(); ok {
+		return &v
+	}
+	return nil
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if v, ok := o.Get(); ok {
+		return json.Marshal(v)
+	}
+	return []byte("null"), nil
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	*o = Optional[T]{set: true}
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		o.null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.value)
+}
+
+// UnmarshalParam 实现 binding.BindUnmarshaler, 按 gin 的 form 规则转换 param.
+func (o *Optional[T]) UnmarshalParam(param string) error {
+	*o = Optional[T]{set: true}
+	if param == "" && reflect.TypeFor[T]().Kind() != reflect.String {
+		o.null = true
+		return nil
+	}
+	var holder struct {
+		V T `form:"v"`
+	}
+	if err := binding.MapFormWithTag(&holder, map[string][]string{"v": {param}}, "form"); err != nil {
+		return err
+	}
+	o.value = holder.V
+	return nil
+}
+
+// validationValue 返回交给 validator 校验的值, 未出现或为 null 时为 nil.
+func (o Optional[T]) validationValue() any {
+	if v, ok := o.Get(); ok {
+		return v
+	}
+	return nil
+}
+
+type optionalValue interface {
+	validationValue() any
+}
+
+var (
+	optionalValueType = reflect.TypeFor[optionalValue]()
+	optionalTypes     sync.Map // reflect.Type -> struct{}
+)
+
+// registerOptionalType 让 gin 默认 validator 校验 Optional 内部的值.
+// 在路由注册时由 scanType 调用; binding.Validator 被替换为其它实现时不做处理.
+func registerOptionalType(t reflect.Type) {
+	if _, loaded := optionalTypes.LoadOrStore(t, struct{}{}); loaded {
+		return
+	}
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(optionalValidationValue, reflect.Zero(t).Interface())
+	}
+}
+
+// optionalValidationValue 是 Optional 类型的 validator.CustomTypeFunc.
+func optionalValidationValue(field reflect.Value) any {
+	return field.Interface().(optionalValue).validationValue()
+}
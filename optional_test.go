@@ -0,0 +1,135 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type optionalPatchReq struct {
+	ID       int                   `uri:"id"`
+	Nickname Optional[string]      `json:"nickname,omitzero" binding:"omitempty,max=3"`
+	Age      Optional[int]         `json:"age,omitzero" binding:"omitempty,gte=1"`
+	Tags     Optional[[]string]    `json:"tags,omitzero"`
+	Score    Optional[float64]     `form:"score" binding:"omitempty,lte=10"`
+	Email    Optional[string]      `form:"email" binding:"required,email"`
+	Profile  Optional[optionalSub] `json:"profile,omitzero"`
+}
+
+type optionalSub struct {
+	City string `json:"city" binding:"required"`
+}
+
+func optionalState[T any](o Optional[T]) string {
+	switch {
+	case !o.IsSet():
+		return "unset"
+	case o.IsNull():
+		return "null"
+	}
+	v, _ := o.Get()
+	return fmt.Sprint(v)
+}
+
+func TestOptionalBinding(t *testing.T) {
+	r := gin.New()
+	PATCH(r, "/users/:id", func(ctx context.Context, req *optionalPatchReq) (*map[string]string, error) {
+		return &map[string]string{
+			"nickname": optionalState(req.Nickname),
+			"age":      optionalState(req.Age),
+			"tags":     optionalState(req.Tags),
+			"score":    optionalState(req.Score),
+			"email":    optionalState(req.Email),
+			"profile":  optionalState(req.Profile),
+		}, nil
+	})
+
+	tests := []struct {
+		query, body string
+		status      int
+		want        map[string]string
+	}{
+		{"?email=a@b.c", `{}`, http.StatusOK, map[string]string{
+			"nickname": "unset", "age": "unset", "tags": "unset", "score": "unset", "email": "a@b.c", "profile": "unset"}},
+		{"?email=a@b.c&score=", `{"nickname":null,"age":3,"tags":["x"],"profile":{"city":"sh"}}`, http.StatusOK, map[string]string{
+			"nickname": "null", "age": "3", "tags": "[x]", "score": "null", "email": "a@b.c", "profile": "{sh}"}},
+		{"?email=a@b.c&score=2.5", `{"nickname":"","age":null,"tags":null}`, http.StatusOK, map[string]string{
+			"nickname": "", "age": "null", "tags": "null", "score": "2.5", "email": "a@b.c", "profile": "unset"}},
+		{"?email=a@b.c", `{"nickname":"long"}`, http.StatusBadRequest, nil},
+		{"?email=a@b.c", `{"age":-1}`, http.StatusBadRequest, nil},
+		{"?email=a@b.c&score=11", `{}`, http.StatusBadRequest, nil},
+		{"?email=a@b.c&score=x", `{}`, http.StatusBadRequest, nil},
+		{"?email=a@b.c", `{"age":"3"}`, http.StatusBadRequest, nil},
+		{"?email=a@b.c", `{"profile":{}}`, http.StatusBadRequest, nil},
+		{"", `{}`, http.StatusBadRequest, nil},
+		{"?email=", `{}`, http.StatusBadRequest, nil},
+		{"?email=bad", `{}`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/users/1"+tt.query, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("%s %s: %d %s", tt.query, tt.body, w.Code, w.Body.String())
+		}
+		if tt.want == nil {
+			continue
+		}
+		var rsp struct {
+			Data map[string]string `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &rsp)
+		if fmt.Sprint(rsp.Data) != fmt.Sprint(tt.want) {
+			t.Fatalf("%s %s:\ngot  %v\nwant %v", tt.query, tt.body, rsp.Data, tt.want)
+		}
+	}
+}
+
+type optionalListReq struct {
+	Items []optionalItem `json:"items" binding:"dive"`
+}
+
+type optionalItem struct {
+	Note Optional[string] `json:"note,omitzero" binding:"omitempty,max=3"`
+}
+
+func TestOptionalInSliceElements(t *testing.T) {
+	r := gin.New()
+	POST(r, "/items", func(ctx context.Context, req *optionalListReq) (*simpleRsp, error) {
+		return &simpleRsp{}, nil
+	})
+	for body, status := range map[string]int{
+		`{"items":[{"note":"ok"},{"note":null},{}]}`: http.StatusOK,
+		`{"items":[{"note":"long"}]}`:                http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Fatalf("%s: %d %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestOptionalJSON(t *testing.T) {
+	req := optionalPatchReq{Nickname: Some("a"), Age: Null[int]()}
+	b, err := json.Marshal(req)
+	if err != nil || string(b) != `{"ID":0,"nickname":"a","age":null,"Score":null,"Email":null}` {
+		t.Fatalf("marshal = %s %v", b, err)
+	}
+	if req.Nickname.Or("x") != "a" || req.Age.Or(7) != 7 || req.Age.Ptr() != nil || *req.Nickname.Ptr() != "a" {
+		t.Fatal("accessors")
+	}
+	var o Optional[int]
+	if err := json.Unmarshal([]byte(` null `), &o); err != nil || !o.IsSet() || !o.IsNull() {
+		t.Fatalf("null = %+v %v", o, err)
+	}
+}
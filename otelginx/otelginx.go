@@ -0,0 +1,142 @@
+// Package otelginx 基于 ginx.Observer 提供 OpenTelemetry tracing 集成.
+//
+// 服务端:
+//
+//	e := ginx.New(ginx.WithObserver(otelginx.Observer()))
+//	api := e.Wrap(r)
+//
+// 客户端 (codegen 生成的 resty client):
+//
+//	c := petapi.NewClient(baseURL, otelginx.ClientOption())
+//
+// 每条 ginx 路由的每次请求对应一个 server span, 记录路由模板、operationId、
+// 业务 code、失败阶段 (binding / validation / handler) 以及 SSE / JSON Lines 已写出的记录数.
+package otelginx
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/chendefine/ginx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+// ScopeName 是本包创建 Tracer 时使用的 instrumentation scope.
+const ScopeName = "github.com/chendefine/ginx/otelginx"
+
+// span 属性名. HTTP 相关沿用 OpenTelemetry 语义约定, ginx.* 为本包扩展.
+const (
+	AttrHTTPMethod     = attribute.Key("http.request.method")
+	AttrHTTPRoute      = attribute.Key("http.route")
+	AttrHTTPStatusCode = attribute.Key("http.response.status_code")
+	AttrOperationID    = attribute.Key("ginx.operation_id")
+	AttrCode           = attribute.Key("ginx.code")
+	AttrErrorStage     = attribute.Key("ginx.error.stage")
+	AttrStreamEvents   = attribute.Key("ginx.stream.events")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	propagators    propagation.TextMapPropagator
+}
+
+// Option 配置 Observer / ClientOption.
+type Option func(*config)
+
+// WithTracerProvider 指定 TracerProvider, 默认使用 otel.GetTracerProvider().
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) { c.tracerProvider = tp }
+}
+
+// WithPropagators 指定传播器, 默认使用 otel.GetTextMapPropagator().
+func WithPropagators(p propagation.TextMapPropagator) Option {
+	return func(c *config) { c.propagators = p }
+}
+
+func newConfig(opts []Option) config {
+	c := config{}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.tracerProvider == nil {
+		c.tracerProvider = otel.GetTracerProvider()
+	}
+	if c.propagators == nil {
+		c.propagators = otel.GetTextMapPropagator()
+	}
+	return c
+}
+
+// Observer 返回一个为每次请求创建 server span 的 ginx.Observer.
+//
+// span 名优先使用 operationId (见 ginx.OperationID), 否则为 "METHOD /route/template".
+// 上游 trace context 按配置的传播器 (通常为 W3C traceparent) 从请求头中提取.
+// 请求以错误结束 (Outcome.Err 非 nil) 时 span 状态为 Error.
+func Observer(opts ...Option) ginx.Observer {
+	cfg := newConfig(opts)
+	tracer := cfg.tracerProvider.Tracer(ScopeName)
+	return func(ctx context.Context, info ginx.RegisterInfo) (context.Context, func(ginx.Outcome)) {
+		route := info.Path
+		if gc, ok := ginx.GinContext(ctx); ok {
+			if fp := gc.FullPath(); fp != "" {
+				route = fp
+			}
+		}
+		if req := ginx.Request(ctx); req != nil {
+			ctx = cfg.propagators.Extract(ctx, propagation.HeaderCarrier(req.Header))
+		}
+
+		name := info.OperationID
+		if name == "" {
+			name = info.Method + " " + route
+		}
+		attrs := []attribute.KeyValue{
+			AttrHTTPMethod.String(info.Method),
+			AttrHTTPRoute.String(route),
+		}
+		if info.OperationID != "" {
+			attrs = append(attrs, AttrOperationID.String(info.OperationID))
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+
+		return ctx, func(out ginx.Outcome) {
+			span.SetAttributes(
+				AttrHTTPStatusCode.Int(out.Status),
+				AttrCode.Int(out.Code),
+			)
+			if out.Events > 0 {
+				span.SetAttributes(AttrStreamEvents.Int(out.Events))
+			}
+			if out.Err != nil {
+				span.SetAttributes(AttrErrorStage.String(string(out.Stage)))
+				span.RecordError(out.Err)
+				span.SetStatus(codes.Error, out.Err.Error())
+			} else if out.Status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(out.Status))
+			}
+			span.End()
+		}
+	}
+}
+
+// ClientOption 返回一个 resty client 配置函数, 可直接传给 codegen 生成的 NewClient.
+// 它在每个请求发出前把 ctx 中的 trace context 注入请求头, 使客户端与服务端 span 相连.
+//
+// 注意: 生成的 SSE 方法使用独立的 resty.SSESource, 不经过该 client, 不会自动注入.
+func ClientOption(opts ...Option) func(*resty.Client) {
+	cfg := newConfig(opts)
+	return func(c *resty.Client) {
+		c.AddRequestMiddleware(func(_ *resty.Client, r *resty.Request) error {
+			cfg.propagators.Inject(r.Context(), propagation.HeaderCarrier(r.Header))
+			return nil
+		})
+	}
+}
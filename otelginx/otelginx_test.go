@@ -0,0 +1,173 @@
+package otelginx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chendefine/ginx"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type getItemReq struct {
+	ID string `uri:"id" binding:"required,min=2"`
+}
+
+type itemRsp struct {
+	ID string `json:"id"`
+}
+
+type emptyReq struct{}
+
+func newTestServer(t *testing.T) (*gin.Engine, *tracetest.InMemoryExporter, []Option) {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	opts := []Option{
+		WithTracerProvider(tp),
+		WithPropagators(propagation.TraceContext{}),
+	}
+
+	e := ginx.New(ginx.WithObserver(Observer(opts...)))
+	r := gin.New()
+	g := e.Group(r, "/api")
+	ginx.GET(g, "/items/:id", func(ctx context.Context, req *getItemReq) (*itemRsp, error) {
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			t.Error("handler context does not carry the server span")
+		}
+		if req.ID == "xx" {
+			return nil, ginx.Error(40401, "item not found").Status(http.StatusNotFound)
+		}
+		return &itemRsp{ID: req.ID}, nil
+	}, ginx.OperationID("getItem"))
+	ginx.SSE(g, "/events", func(ctx context.Context, req *emptyReq, send ginx.Sender) error {
+		for i := 0; i < 2; i++ {
+			if err := send(ginx.Event{Data: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	ginx.JSONLines(g, http.MethodPost, "/export", func(ctx context.Context, req *emptyReq, send ginx.JSONLinesSender) error {
+		_ = send(map[string]int{"n": 1})
+		_ = send(map[string]int{"n": 2})
+		return errors.New("export aborted")
+	})
+	return r, exp, opts
+}
+
+func attrs(s tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(s.Attributes))
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestObserverSpans(t *testing.T) {
+	r, exp, _ := newTestServer(t)
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		spanName  string
+		route     string
+		status    int64
+		code      int64
+		stage     string
+		events    int64
+		errStatus bool
+	}{
+		{"success", http.MethodGet, "/api/items/42", "getItem", "/api/items/:id", 200, 0, "", 0, false},
+		{"handler error", http.MethodGet, "/api/items/xx", "getItem", "/api/items/:id", 404, 40401, "handler", 0, true},
+		{"validation error", http.MethodGet, "/api/items/x", "getItem", "/api/items/:id", 400, 1, "validation", 0, true},
+		{"sse", http.MethodGet, "/api/events", "GET /api/events", "/api/events", 200, 0, "", 2, false},
+		{"ndjson aborted", http.MethodPost, "/api/export", "POST /api/export", "/api/export", 200, 2, "handler", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exp.Reset()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			spans := exp.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("spans = %d, want 1", len(spans))
+			}
+			s := spans[0]
+			if s.Name != tt.spanName || s.SpanKind != trace.SpanKindServer {
+				t.Fatalf("span = %q (%v), want %q server", s.Name, s.SpanKind, tt.spanName)
+			}
+			a := attrs(s)
+			if got := a[AttrHTTPRoute].AsString(); got != tt.route {
+				t.Fatalf("http.route = %q, want %q", got, tt.route)
+			}
+			if got := a[AttrHTTPStatusCode].AsInt64(); got != tt.status {
+				t.Fatalf("status = %d, want %d", got, tt.status)
+			}
+			if got := a[AttrCode].AsInt64(); got != tt.code {
+				t.Fatalf("ginx.code = %d, want %d", got, tt.code)
+			}
+			if got := a[AttrErrorStage].AsString(); got != tt.stage {
+				t.Fatalf("ginx.error.stage = %q, want %q", got, tt.stage)
+			}
+			if got := a[AttrStreamEvents].AsInt64(); got != tt.events {
+				t.Fatalf("ginx.stream.events = %d, want %d", got, tt.events)
+			}
+			if (s.Status.Code == codes.Error) != tt.errStatus {
+				t.Fatalf("span status = %v, want error=%v", s.Status, tt.errStatus)
+			}
+		})
+	}
+}
+
+func TestClientPropagation(t *testing.T) {
+	r, exp, opts := newTestServer(t)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	exp.Reset()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	ctx, parent := tp.Tracer("client").Start(context.Background(), "client-call")
+
+	c := resty.New().SetBaseURL(srv.URL)
+	defer c.Close()
+	ClientOption(opts...)(c)
+	resp, err := c.R().SetContext(ctx).Get("/api/items/42")
+	parent.End()
+	if err != nil || resp.StatusCode() != http.StatusOK {
+		t.Fatalf("request failed: %v (status %d)", err, resp.StatusCode())
+	}
+
+	var server tracetest.SpanStub
+	for _, s := range exp.GetSpans() {
+		if s.Name == "getItem" {
+			server = s
+		}
+	}
+	if !server.Parent.IsValid() {
+		t.Fatal("server span has no remote parent")
+	}
+	if server.Parent.TraceID() != parent.SpanContext().TraceID() || server.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("server parent = %v, want client span %v", server.Parent, parent.SpanContext())
+	}
+	if !server.Parent.IsRemote() {
+		t.Fatal("expected remote parent span context")
+	}
+}
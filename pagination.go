@@ -0,0 +1,275 @@
+package ginx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor 表示分页游标无法解码或签名不匹配, 渲染为 400 与 invalidArgCode.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageReq 是分页请求参数, 嵌入到 Req 中使用:
+//
+//	type ListUsersReq struct {
+//	    ginx.PageReq
+//	    Status string `form:"status"`
+//	}
+//
+// Cursor 非空时为游标分页, 否则按 Offset 偏移分页. 绑定后 Limit 会按
+// WithPageLimits 归一化: 未传使用默认值, 超出上限时截断.
+type PageReq struct {
+	Cursor string `form:"cursor"`
+	Offset int    `form:"offset" binding:"omitempty,min=0"`
+	Limit  int    `form:"limit" binding:"omitempty,min=0"`
+}
+
+// Values 返回非零字段对应的 query 参数, 供客户端拼接请求.
+func (p PageReq) Values() url.Values {
+	q := make(url.Values)
+	if p.Cursor != "" {
+		q.Set("cursor", p.Cursor)
+	}
+	if p.Offset > 0 {
+		q.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Limit > 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return q
+}
+
+func (p *PageReq) pageRequest() *PageReq { return p }
+
+// Page 是分页响应. 作为 Rsp 返回时 ginx 会自动输出 RFC 8288 Link 头,
+// Total 非 nil 时同时输出 X-Total-Count.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// NewCursorPage 创建游标分页响应, next 为空表示最后一页.
+func NewCursorPage[T any](items []T, next string) *Page[T] {
+	return &Page[T]{Items: nonNilItems(items), NextCursor: next}
+}
+
+// NewOffsetPage 创建偏移分页响应, total 为满足条件的总条数.
+func NewOffsetPage[T any](items []T, total int64) *Page[T] {
+	return &Page[T]{Items: nonNilItems(items), Total: &total}
+}
+
+func (p *Page[T]) pageInfo() (count int, next string, total *int64) {
+	return len(p.Items), p.NextCursor, p.Total
+}
+
+// nonNilItems 保证空页编码为 [] 而不是 null.
+func nonNilItems[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+type pageRequester interface{ pageRequest() *PageReq }
+
+type pager interface {
+	pageInfo() (count int, next string, total *int64)
+}
+
+type pageReqKey struct{}
+
+// pageLimits 是 PageReq.Limit 的默认值与上限, <= 0 表示不设置.
+type pageLimits struct {
+	defaultLimit int
+	maxLimit     int
+}
+
+// WithPageLimits 设置 PageReq.Limit 的默认值与上限; 默认 20 / 100, 参数 <= 0 表示该项不生效.
+func WithPageLimits(defaultLimit, maxLimit int) EngineOption {
+	return func(e *Engine) { e.pageLimits = pageLimits{defaultLimit: defaultLimit, maxLimit: maxLimit} }
+}
+
+// bindPageReq 归一化 Req 中嵌入的 PageReq, 并记录到请求上供生成 Link 头.
+func bindPageReq(x exchange, limits pageLimits, req any) {
+	pr, ok := req.(pageRequester)
+	if !ok {
+		return
+	}
+	p := pr.pageRequest()
+	if p.Limit <= 0 {
+		p.Limit = limits.defaultLimit
+	}
+	if limits.maxLimit > 0 && p.Limit > limits.maxLimit {
+		p.Limit = limits.maxLimit
+	}
+	x.Set(pageReqKey{}, *p)
+}
+
+// writePageHeaders 为分页响应输出 Link 与 X-Total-Count. 链接使用相对 URI,
+// 保留原请求的其它 query 参数.
+func writePageHeaders(x exchange, rsp any) {
+	pg, ok := rsp.(pager)
+	r := x.Request()
+	if !ok || r == nil || r.URL == nil {
+		return
+	}
+	h := x.Writer().Header()
+	count, next, total := pg.pageInfo()
+	if total != nil {
+		h.Set("X-Total-Count", strconv.FormatInt(*total, 10))
+	}
+	var req PageReq
+	if v, ok := x.Get(pageReqKey{}); ok {
+		req = v.(PageReq)
+	}
+
+	var links []string
+	if next != "" || req.Cursor != "" {
+		links = append(links, pageLink(r.URL, "first", nil))
+		if next != "" {
+			links = append(links, pageLink(r.URL, "next", map[string]string{"cursor": next}))
+		}
+	} else if req.Limit > 0 {
+		offset := func(n int) map[string]string { return map[string]string{"offset": strconv.Itoa(n)} }
+		links = append(links, pageLink(r.URL, "first", nil))
+		if req.Offset > 0 {
+			links = append(links, pageLink(r.URL, "prev", offset(max(req.Offset-req.Limit, 0))))
+		}
+		more := count >= req.Limit
+		if total != nil {
+			more = int64(req.Offset+count) < *total
+		}
+		if more && count > 0 {
+			links = append(links, pageLink(r.URL, "next", offset(req.Offset+count)))
+		}
+		if total != nil && *total > 0 {
+			links = append(links, pageLink(r.URL, "last", offset(int((*total-1)/int64(req.Limit))*req.Limit)))
+		}
+	}
+	if len(links) > 0 {
+		h.Set("Link", strings.Join(links, ", "))
+	}
+}
+
+func pageLink(u *url.URL, rel string, set map[string]string) string {
+	q := u.Query()
+	q.Del("cursor")
+	q.Del("offset")
+	for k, v := range set {
+		q.Set(k, v)
+	}
+	ref := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return "<" + ref.String() + `>; rel="` + rel + `"`
+}
+
+// CursorCodec 把游标状态编码为不透明字符串, 以 HMAC-SHA256 签名防篡改.
+// 内容只做 base64url 编码而不加密, 不要在游标中放敏感数据.
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec 使用 key 创建 CursorCodec; key 为空时 panic.
+func NewCursorCodec(key []byte) *CursorCodec {
+	if len(key) == 0 {
+		panic("ginx: cursor key must not be empty")
+	}
+	return &CursorCodec{key: append([]byte(nil), key...)}
+}
+
+// Encode 把 v 编码为 JSON 并签名.
+func (c *CursorCodec) Encode(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode 校验签名后把游标解码到 v, 任何失败都返回 ErrInvalidCursor.
+func (c *CursorCodec) Decode(cursor string, v any) error {
+	data, sig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(data)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	mac, err := enc.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, c.sign(payload)) {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, c.key)
+	h.Write(payload)
+	return h.Sum(nil)[:16]
+}
+
+// CursorPages 从 start 开始按 NextCursor 逐页拉取, 逐条产出 item;
+// fetch 出错时产出该错误后结束. 供生成的客户端迭代器使用.
+func CursorPages[T any](ctx context.Context, start PageReq, fetch func(context.Context, PageReq) (*Page[T], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		req := start
+		for {
+			page, err := fetch(ctx, req)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			// 服务端返回相同游标时停止, 避免死循环.
+			if page.NextCursor == "" || page.NextCursor == req.Cursor {
+				return
+			}
+			req.Cursor = page.NextCursor
+		}
+	}
+}
+
+// OffsetPages 从 start 开始按 Offset 逐页拉取, 逐条产出 item; 遇到空页、
+// 不足 Limit 的页或到达 Total 时结束.
+func OffsetPages[T any](ctx context.Context, start PageReq, fetch func(context.Context, PageReq) (*Page[T], error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		req := start
+		req.Cursor = ""
+		for {
+			page, err := fetch(ctx, req)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			n := len(page.Items)
+			req.Offset += n
+			if n == 0 || (req.Limit > 0 && n < req.Limit) || (page.Total != nil && int64(req.Offset) >= *page.Total) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type listItemsReq struct {
+	PageReq
+	Tag string `form:"tag"`
+}
+
+type itemCursor struct {
+	After int `json:"after"`
+}
+
+func pageGet(r http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestOffsetPagination(t *testing.T) {
+	var got PageReq
+	r := gin.New()
+	GET(New(WithPageLimits(2, 3)).Wrap(r), "/items", func(ctx context.Context, req *listItemsReq) (*Page[int], error) {
+		got = req.PageReq
+		var items []int
+		for i := req.Offset; i < req.Offset+req.Limit && i < 7; i++ {
+			items = append(items, i)
+		}
+		return NewOffsetPage(items, 7), nil
+	})
+
+	w := pageGet(r, "/items?tag=a&offset=2")
+	if got.Limit != 2 || got.Offset != 2 {
+		t.Fatalf("normalized req = %+v", got)
+	}
+	if w.Header().Get("X-Total-Count") != "7" {
+		t.Fatalf("X-Total-Count = %q", w.Header().Get("X-Total-Count"))
+	}
+	want := `</items?tag=a>; rel="first", </items?offset=0&tag=a>; rel="prev", </items?offset=4&tag=a>; rel="next", </items?offset=6&tag=a>; rel="last"`
+	if link := w.Header().Get("Link"); link != want {
+		t.Fatalf("Link = %s\nwant   %s", link, want)
+	}
+	if !strings.Contains(w.Body.String(), `"items":[2,3],"total":7`) {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+
+	w = pageGet(r, "/items?offset=6&limit=50")
+	if got.Limit != 3 || strings.Contains(w.Header().Get("Link"), `rel="next"`) {
+		t.Fatalf("last page: limit %d, Link %s", got.Limit, w.Header().Get("Link"))
+	}
+	if w := pageGet(r, "/items?offset=-1"); w.Code != http.StatusBadRequest {
+		t.Fatalf("negative offset = %d", w.Code)
+	}
+	if w := pageGet(r, "/items?offset=9"); !strings.Contains(w.Body.String(), `"items":[]`) {
+		t.Fatalf("empty page = %s", w.Body.String())
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+	r := gin.New()
+	GET(New().Wrap(r), "/items", func(ctx context.Context, req *listItemsReq) (*Page[int], error) {
+		var cur itemCursor
+		if req.Cursor != "" {
+			if err := codec.Decode(req.Cursor, &cur); err != nil {
+				return nil, err
+			}
+		}
+		var items []int
+		for i := cur.After; i < cur.After+req.Limit && i < 5; i++ {
+			items = append(items, i)
+		}
+		var next string
+		if end := cur.After + len(items); end < 5 {
+			next, _ = codec.Encode(itemCursor{After: end})
+		}
+		return NewCursorPage(items, next), nil
+	})
+
+	w := pageGet(r, "/items?limit=2&tag=b")
+	var body struct {
+		Data Page[int] `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Data.NextCursor == "" {
+		t.Fatalf("first page = %s (%v)", w.Body.String(), err)
+	}
+	want := `</items?limit=2&tag=b>; rel="first", </items?cursor=` + body.Data.NextCursor + `&limit=2&tag=b>; rel="next"`
+	if link := w.Header().Get("Link"); link != want {
+		t.Fatalf("Link = %s\nwant   %s", link, want)
+	}
+	if w.Header().Get("X-Total-Count") != "" {
+		t.Fatal("cursor page should not report a total")
+	}
+
+	tampered := strings.Replace(body.Data.NextCursor, body.Data.NextCursor[:2], "xx", 1)
+	forged, _ := NewCursorCodec([]byte("other")).Encode(itemCursor{After: 4})
+	for _, c := range []string{tampered, "garbage", forged} {
+		w := pageGet(r, "/items?cursor="+c)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"msg":"invalid cursor"`) {
+			t.Fatalf("cursor %q = %d %s", c, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPageIterators(t *testing.T) {
+	data := []string{"a", "b", "c", "d", "e"}
+	var calls int
+	cursorFetch := func(ctx context.Context, p PageReq) (*Page[string], error) {
+		calls++
+		start, _ := strconv.Atoi(p.Cursor)
+		end := min(start+p.Limit, len(data))
+		next := ""
+		if end < len(data) {
+			next = strconv.Itoa(end)
+		}
+		return NewCursorPage(data[start:end], next), nil
+	}
+	var got []string
+	for item, err := range CursorPages(context.Background(), PageReq{Limit: 2}, cursorFetch) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, item)
+	}
+	if strings.Join(got, "") != "abcde" || calls != 3 {
+		t.Fatalf("cursor iteration = %v after %d calls", got, calls)
+	}
+
+	offsetFetch := func(ctx context.Context, p PageReq) (*Page[string], error) {
+		end := min(p.Offset+p.Limit, len(data))
+		return NewOffsetPage(data[p.Offset:end], int64(len(data))), nil
+	}
+	got = got[:0]
+	for item, err := range OffsetPages(context.Background(), PageReq{Limit: 2, Offset: 1}, offsetFetch) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, item)
+		if item == "d" {
+			break
+		}
+	}
+	if strings.Join(got, "") != "bcd" {
+		t.Fatalf("offset iteration = %v", got)
+	}
+
+	boom := errors.New("boom")
+	for _, err := range OffsetPages(context.Background(), PageReq{}, func(context.Context, PageReq) (*Page[string], error) { return nil, boom }) {
+		if !errors.Is(err, boom) {
+			t.Fatalf("err = %v", err)
+		}
+	}
+}
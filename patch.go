@@ -0,0 +1,554 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MergePatch / JSONPatch 路由要求的请求 Content-Type.
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// PatchLoader 读取被修补资源的当前状态. 返回的错误按 handler 错误渲染 (如 404).
+type PatchLoader[Req, Resource any] func(ctx context.Context, req *Req) (*Resource, error)
+
+// PatchHandler 接收修补并校验通过后的资源, 负责持久化并返回响应.
+type PatchHandler[Req, Resource, Rsp any] func(ctx context.Context, req *Req, patched *Resource) (*Rsp, error)
+
+// PatchError 是 MergePatch / JSONPatch 路由应用 patch 失败的错误, 渲染为 Status 与 invalidArgCode:
+// 415 Content-Type 不符, 400 patch 文档格式错误, 409 test 操作不成立,
+// 422 路径不存在或修补结果无法解码为 Resource / 未通过校验.
+type PatchError struct {
+	Status int
+	Msg    string
+}
+
+func (e *PatchError) Error() string { return e.Msg }
+
+// PatchOperation 是 JSON Patch (RFC 6902) 的一个操作. 编码时 add / replace / test
+// 总是输出 value (包括 null), 其它操作省略.
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	type base struct {
+		Op   string `json:"op"`
+		Path string `json:"path"`
+		From string `json:"from,omitempty"`
+	}
+	b := base{Op: o.Op, Path: o.Path, From: o.From}
+	switch o.Op {
+	case "add", "replace", "test":
+		return StdCodec.Marshal(struct {
+			base
+			Value any `json:"value"`
+		}{b, o.Value})
+	}
+	return StdCodec.Marshal(b)
+}
+
+// MergePatch 注册 JSON Merge Patch (RFC 7396) 路由. 请求体须为 application/merge-patch+json;
+// Req 只从 path / query / header / cookie 绑定, 不读取 body. 流程为: 解析 patch, 调用 load
+// 取当前资源, 在其 JSON 表示上应用 patch, 解码为新的 Resource (拒绝未知字段) 并按其
+// binding tag 重新校验, 最后交给 fn. Resource 中 json:"-" 的字段不会保留.
+// Resource 实现 ETagger / LastModifier 时, load 之后按其当前值执行 Precondition, 不满足时返回 412.
+func MergePatch[Req, Resource, Rsp any](r gin.IRoutes, path string, load PatchLoader[Req, Resource], fn PatchHandler[Req, Resource, Rsp], opts ...RouteOption) {
+	registerPatch(r, path, MergePatchContentType, parseMergePatch, load, fn, opts)
+}
+
+// JSONPatch 注册 JSON Patch (RFC 6902) 路由, 请求体须为 application/json-patch+json.
+// 其余流程与 MergePatch 一致; 操作按顺序应用, 任一失败则整体失败.
+func JSONPatch[Req, Resource, Rsp any](r gin.IRoutes, path string, load PatchLoader[Req, Resource], fn PatchHandler[Req, Resource, Rsp], opts ...RouteOption) {
+	registerPatch(r, path, JSONPatchContentType, parseJSONPatch, load, fn, opts)
+}
+
+// patchRoute 标记 patch 路由, body 是 patch 文档, 不绑定到 Req.
+func patchRoute() RouteOption {
+	return func(c *routeConfig) { c.patchDocument = true }
+}
+
+// patchFunc 把 patch 应用到资源的通用 JSON 表示上, 返回修补后的文档.
+type patchFunc func(doc any) (any, error)
+
+func registerPatch[Req, Resource, Rsp any](r gin.IRoutes, path, mediaType string, parse func(Codec, []byte) (patchFunc, error),
+	load PatchLoader[Req, Resource], fn PatchHandler[Req, Resource, Rsp], opts []RouteOption) {
+	resPlan := buildBindingPlan(reflect.TypeFor[Resource]())
+	_, engine := engineOf(r)
+	engine.mu.RLock()
+	ev := engine.validator
+	engine.mu.RUnlock()
+	ev.prepare(resPlan)
+	register(r, http.MethodPatch, path, func(ctx context.Context, req *Req) (*Rsp, error) {
+		x, ok := exchangeOf(ctx)
+		if !ok {
+			return nil, errNoExchange
+		}
+		if !isContentType(contentType(x.Request()), mediaType) {
+			return nil, &PatchError{Status: http.StatusUnsupportedMediaType, Msg: "Content-Type must be " + mediaType}
+		}
+		body, err := io.ReadAll(x.Request().Body)
+		if err != nil {
+			if status, msg := limitError(err); status > 0 {
+				return nil, &PatchError{Status: status, Msg: msg}
+			}
+			return nil, err
+		}
+		codec := codecOf(x)
+		apply, err := parse(codec, body)
+		if err != nil {
+			return nil, &PatchError{Status: http.StatusBadRequest, Msg: "invalid patch document: " + err.Error()}
+		}
+		current, err := load(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil {
+			if err := resourcePrecondition(ctx, current); err != nil {
+				return nil, err
+			}
+		}
+		patched, err := applyPatch(ctx, codec, ev, resPlan, current, apply)
+		if err != nil {
+			return nil, err
+		}
+		return fn(ctx, req, patched)
+	}, append([]RouteOption{patchRoute()}, opts...)...)
+}
+
+// applyPatch 在 current 的 JSON 表示上执行 apply, 并解码、校验为新的 Resource;
+// Resource 实现 RequestValidator 时一并调用 Validate.
+func applyPatch[Resource any](ctx context.Context, codec Codec, ev *engineValidator, plan *bindingPlan, current *Resource, apply patchFunc) (*Resource, error) {
+	var doc any
+	if current != nil {
+		b, err := codec.Marshal(current)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = decodeJSONValue(codec, b); err != nil {
+			return nil, err
+		}
+	}
+	doc, err := apply(doc)
+	if err != nil {
+		return nil, err
+	}
+	b, err := codec.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var res Resource
+	seen := &unknownFieldReader{r: bytes.NewReader(b)}
+	dec := codec.NewDecoder(seen)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&res); err != nil {
+		err = unknownFieldError(err, reflect.TypeFor[Resource](), seen.buf.Bytes())
+		return nil, &PatchError{Status: http.StatusUnprocessableEntity, Msg: "patched resource is invalid: " + err.Error()}
+	}
+	if plan.hasBinding {
+		if err := ev.validateStruct(&res); err != nil {
+			msg := err.Error()
+			if isValidationError(err) {
+				msg = ev.formatError(err, plan.fieldNameMap)
+			}
+			return nil, &PatchError{Status: http.StatusUnprocessableEntity, Msg: msg}
+		}
+	}
+	if plan.validateHook {
+		if err := runValidateHook(ctx, &res); err != nil {
+			err = errors.Unwrap(err)
+			msg := err.Error()
+			var ew *ErrWrap
+			if errors.As(err, &ew) {
+				msg = ew.Msg
+			} else if isValidationError(err) {
+				msg = ev.formatError(err, plan.fieldNameMap)
+			}
+			return nil, &PatchError{Status: http.StatusUnprocessableEntity, Msg: msg}
+		}
+	}
+	return &res, nil
+}
+
+// decodeJSONValue 把 data 解码为通用 JSON 值, 数字保留为 json.Number 以免丢失精度.
+func decodeJSONValue(codec Codec, data []byte) (any, error) {
+	dec := codec.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// --- JSON Merge Patch (RFC 7396) ---
+
+func parseMergePatch(codec Codec, body []byte) (patchFunc, error) {
+	patch, err := decodeJSONValue(codec, body)
+	if err != nil {
+		return nil, err
+	}
+	return func(doc any) (any, error) { return mergePatch(doc, patch), nil }, nil
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// --- JSON Patch (RFC 6902) ---
+
+// jsonPatchOp 是解析后的操作, value 在 add / replace / test 之外为 nil.
+type jsonPatchOp struct {
+	op         string
+	path, from []string
+	rawPath    string
+	rawFrom    string
+	value      any
+}
+
+func parseJSONPatch(codec Codec, body []byte) (patchFunc, error) {
+	var raw []struct {
+		Op    string          `json:"op"`
+		Path  *string         `json:"path"`
+		From  *string         `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := codec.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	ops := make([]jsonPatchOp, len(raw))
+	for i, r := range raw {
+		op := &ops[i]
+		op.op = r.Op
+		if r.Path == nil {
+			return nil, fmt.Errorf("operation %d: missing path", i)
+		}
+		var err error
+		op.rawPath = *r.Path
+		if op.path, err = parsePointer(*r.Path); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		switch r.Op {
+		case "add", "replace", "test":
+			if r.Value == nil {
+				return nil, fmt.Errorf("operation %d: missing value", i)
+			}
+			if op.value, err = decodeJSONValue(codec, r.Value); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case "move", "copy":
+			if r.From == nil {
+				return nil, fmt.Errorf("operation %d: missing from", i)
+			}
+			op.rawFrom = *r.From
+			if op.from, err = parsePointer(*r.From); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operation %d: unknown op %q", i, r.Op)
+		}
+	}
+	return func(doc any) (any, error) {
+		var err error
+		for i := range ops {
+			if doc, err = ops[i].apply(doc); err != nil {
+				return nil, err
+			}
+		}
+		return doc, nil
+	}, nil
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// parsePointer 解析 JSON Pointer (RFC 6901), "" 表示整个文档.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return []string{}, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = pointerUnescaper.Replace(t)
+	}
+	return tokens, nil
+}
+
+func (op *jsonPatchOp) apply(doc any) (any, error) {
+	switch op.op {
+	case "add":
+		return op.add(doc, op.path, op.value)
+	case "remove":
+		doc, _, err := op.remove(doc, op.path)
+		return doc, err
+	case "replace":
+		if len(op.path) == 0 {
+			return op.value, nil
+		}
+		return updateParent(doc, op.path, op.rawPath, func(parent any, key string) (any, error) {
+			switch c := parent.(type) {
+			case map[string]any:
+				if _, ok := c[key]; !ok {
+					return nil, pathNotFound(op.rawPath)
+				}
+				c[key] = op.value
+				return c, nil
+			case []any:
+				i, err := arrayIndex(key, len(c)-1, op.rawPath)
+				if err != nil {
+					return nil, err
+				}
+				c[i] = op.value
+				return c, nil
+			}
+			return nil, pathNotFound(op.rawPath)
+		})
+	case "move":
+		if op.rawPath == op.rawFrom {
+			return doc, nil
+		}
+		if strings.HasPrefix(op.rawPath, op.rawFrom+"/") {
+			return nil, &PatchError{Status: http.StatusUnprocessableEntity, Msg: fmt.Sprintf("patch cannot move %q into its own child %q", op.rawFrom, op.rawPath)}
+		}
+		doc, v, err := op.remove(doc, op.from)
+		if err != nil {
+			return nil, err
+		}
+		return op.add(doc, op.path, v)
+	case "copy":
+		v, err := lookupPointer(doc, op.from, op.rawFrom)
+		if err != nil {
+			return nil, err
+		}
+		return op.add(doc, op.path, deepCopyJSON(v))
+	case "test":
+		v, err := lookupPointer(doc, op.path, op.rawPath)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(v, op.value) {
+			return nil, &PatchError{Status: http.StatusConflict, Msg: fmt.Sprintf("patch test failed at %q", op.rawPath)}
+		}
+		return doc, nil
+	}
+	return doc, nil
+}
+
+func (op *jsonPatchOp) add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, op.rawPath, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			c[key] = value
+			return c, nil
+		case []any:
+			i := len(c)
+			if key != "-" {
+				var err error
+				if i, err = arrayIndex(key, len(c), op.rawPath); err != nil {
+					return nil, err
+				}
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, pathNotFound(op.rawPath)
+	})
+}
+
+// remove 删除 path 处的值并返回它; move 复用时 path 为 from.
+func (op *jsonPatchOp) remove(doc any, path []string) (any, any, error) {
+	raw := op.rawPath
+	if op.op == "move" {
+		raw = op.rawFrom
+	}
+	if len(path) == 0 {
+		return nil, nil, &PatchError{Status: http.StatusUnprocessableEntity, Msg: "patch cannot remove the whole document"}
+	}
+	var removed any
+	doc, err := updateParent(doc, path, raw, func(parent any, key string) (any, error) {
+		switch c := parent.(type) {
+		case map[string]any:
+			v, ok := c[key]
+			if !ok {
+				return nil, pathNotFound(raw)
+			}
+			removed = v
+			delete(c, key)
+			return c, nil
+		case []any:
+			i, err := arrayIndex(key, len(c)-1, raw)
+			if err != nil {
+				return nil, err
+			}
+			removed = c[i]
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, pathNotFound(raw)
+	})
+	return doc, removed, err
+}
+
+// updateParent 找到 path 的父容器并以 fn 的结果替换它, 返回更新后的 doc.
+func updateParent(doc any, path []string, raw string, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch c := doc.(type) {
+	case map[string]any:
+		child, ok := c[path[0]]
+		if !ok {
+			return nil, pathNotFound(raw)
+		}
+		v, err := updateParent(child, path[1:], raw, fn)
+		if err != nil {
+			return nil, err
+		}
+		c[path[0]] = v
+		return c, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(c)-1, raw)
+		if err != nil {
+			return nil, err
+		}
+		v, err := updateParent(c[i], path[1:], raw, fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = v
+		return c, nil
+	}
+	return nil, pathNotFound(raw)
+}
+
+func lookupPointer(doc any, path []string, raw string) (any, error) {
+	for _, key := range path {
+		switch c := doc.(type) {
+		case map[string]any:
+			v, ok := c[key]
+			if !ok {
+				return nil, pathNotFound(raw)
+			}
+			doc = v
+		case []any:
+			i, err := arrayIndex(key, len(c)-1, raw)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, pathNotFound(raw)
+		}
+	}
+	return doc, nil
+}
+
+// arrayIndex 解析数组下标, 要求 0 <= i <= max 且没有前导 0.
+func arrayIndex(key string, max int, raw string) (int, error) {
+	if key == "" || len(key) > 1 && key[0] == '0' || strings.TrimLeft(key, "0123456789") != "" {
+		return 0, pathNotFound(raw)
+	}
+	i, err := strconv.Atoi(key)
+	if err != nil || i > max {
+		return 0, pathNotFound(raw)
+	}
+	return i, nil
+}
+
+func pathNotFound(raw string) error {
+	return &PatchError{Status: http.StatusUnprocessableEntity, Msg: fmt.Sprintf("patch path %q does not exist", raw)}
+}
+
+func deepCopyJSON(v any) any {
+	switch c := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(c))
+		for k, v := range c {
+			m[k] = deepCopyJSON(v)
+		}
+		return m
+	case []any:
+		s := make([]any, len(c))
+		for i, v := range c {
+			s[i] = deepCopyJSON(v)
+		}
+		return s
+	}
+	return v
+}
+
+// jsonEqual 按 JSON 语义比较两个通用值, 数字按数值比较 (1 与 1.0 相等).
+func jsonEqual(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		p, ok1 := new(big.Rat).SetString(string(x))
+		q, ok2 := new(big.Rat).SetString(string(y))
+		return ok1 && ok2 && p.Cmp(q) == 0
+	}
+	return a == b
+}
@@ -0,0 +1,275 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type patchReq struct {
+	ID    int    `uri:"id"`
+	Trace string `form:"trace"`
+}
+
+type patchAddress struct {
+	City string `json:"city" binding:"required"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type patchUser struct {
+	Name    string        `json:"name" binding:"required,max=8"`
+	Age     int           `json:"age" binding:"gte=0"`
+	Tags    []string      `json:"tags"`
+	Address *patchAddress `json:"address,omitempty"`
+}
+
+type patchResult struct {
+	ID    int       `json:"id"`
+	Trace string    `json:"trace"`
+	User  patchUser `json:"user"`
+}
+
+func newPatchRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	load := func(ctx context.Context, req *patchReq) (*patchUser, error) {
+		if req.ID != 1 {
+			return nil, Error(404, "user not found").Status(http.StatusNotFound)
+		}
+		return &patchUser{Name: "alice", Age: 30, Tags: []string{"a", "b"}, Address: &patchAddress{City: "sh"}}, nil
+	}
+	save := func(ctx context.Context, req *patchReq, patched *patchUser) (*patchResult, error) {
+		return &patchResult{ID: req.ID, Trace: req.Trace, User: *patched}, nil
+	}
+	r := gin.New()
+	e := New(WithInvalidArgCode(4000))
+	MergePatch(e.Wrap(r), "/merge/:id", load, save)
+	JSONPatch(e.Wrap(r), "/json/:id", load, save)
+	return r
+}
+
+func doPatch(r http.Handler, path, contentType, body string) (*httptest.ResponseRecorder, patchResult, string) {
+	req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var rsp struct {
+		Code int         `json:"code"`
+		Msg  string      `json:"msg"`
+		Data patchResult `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &rsp)
+	return w, rsp.Data, rsp.Msg
+}
+
+func TestMergePatch(t *testing.T) {
+	r := newPatchRouter(t)
+
+	w, got, _ := doPatch(r, "/merge/1?trace=t1", MergePatchContentType, `{"age":31,"tags":null,"address":{"zip":"200000"}}`)
+	want := patchResult{ID: 1, Trace: "t1", User: patchUser{Name: "alice", Age: 31, Address: &patchAddress{City: "sh", Zip: "200000"}}}
+	if w.Code != http.StatusOK || !reflect.DeepEqual(got, want) {
+		t.Fatalf("merge = %d %+v", w.Code, got)
+	}
+
+	tests := []struct {
+		path, contentType, body string
+		status                  int
+		msg                     string
+	}{
+		{"/merge/1", "application/json", `{"age":1}`, http.StatusUnsupportedMediaType, "Content-Type must be application/merge-patch+json"},
+		{"/merge/1", MergePatchContentType, `{"age":`, http.StatusBadRequest, "invalid patch document: unexpected EOF"},
+		{"/merge/2", MergePatchContentType, `{"age":1}`, http.StatusNotFound, "user not found"},
+		{"/merge/1", MergePatchContentType, `{"nmae":"x"}`, http.StatusUnprocessableEntity, `patched resource is invalid: unknown field "nmae"`},
+		{"/merge/1", MergePatchContentType, `{"age":"x"}`, http.StatusUnprocessableEntity, ""},
+		{"/merge/1", MergePatchContentType, `{"name":null}`, http.StatusUnprocessableEntity, ""},
+		{"/merge/1", MergePatchContentType, `{"address":{"city":null}}`, http.StatusUnprocessableEntity, ""},
+		{"/merge/1", MergePatchContentType, `{"name":"too long name"}`, http.StatusUnprocessableEntity, ""},
+	}
+	for _, tt := range tests {
+		w, _, msg := doPatch(r, tt.path, tt.contentType, tt.body)
+		if w.Code != tt.status || tt.msg != "" && msg != tt.msg {
+			t.Fatalf("%s %s: %d %s", tt.path, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMergePatchPrecondition(t *testing.T) {
+	current := &articleRsp{ID: "1", Title: "old", Version: 3}
+	r := gin.New()
+	MergePatch(New().Wrap(r), "/articles/:id", func(ctx context.Context, req *articleReq) (*articleRsp, error) {
+		return current, nil
+	}, func(ctx context.Context, req *articleReq, patched *articleRsp) (*articleRsp, error) {
+		return patched, nil
+	})
+
+	for _, tt := range []struct {
+		ifMatch string
+		status  int
+	}{
+		{"", http.StatusOK},
+		{`"v2"`, http.StatusPreconditionFailed},
+		{`"v3"`, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPatch, "/articles/1", strings.NewReader(`{"title":"new"}`))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("If-Match %q = %d %s", tt.ifMatch, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	r := newPatchRouter(t)
+
+	w, got, _ := doPatch(r, "/json/1", JSONPatchContentType, `[
+		{"op":"test","path":"/age","value":30.0},
+		{"op":"replace","path":"/name","value":"bob"},
+		{"op":"add","path":"/tags/-","value":"c"},
+		{"op":"add","path":"/tags/0","value":"z"},
+		{"op":"remove","path":"/tags/1"},
+		{"op":"copy","from":"/address/city","path":"/address/zip"},
+		{"op":"move","from":"/tags/0","path":"/tags/-"}
+	]`)
+	want := patchResult{ID: 1, User: patchUser{Name: "bob", Age: 30, Tags: []string{"b", "c", "z"}, Address: &patchAddress{City: "sh", Zip: "sh"}}}
+	if w.Code != http.StatusOK || !reflect.DeepEqual(got, want) {
+		t.Fatalf("json patch = %d %+v", w.Code, got)
+	}
+
+	tests := []struct {
+		body   string
+		status int
+		msg    string
+	}{
+		{`{"op":"add"}`, http.StatusBadRequest, ""},
+		{`[{"op":"add","path":"/age"}]`, http.StatusBadRequest, "invalid patch document: operation 0: missing value"},
+		{`[{"op":"move","path":"/age"}]`, http.StatusBadRequest, "invalid patch document: operation 0: missing from"},
+		{`[{"op":"remove"}]`, http.StatusBadRequest, "invalid patch document: operation 0: missing path"},
+		{`[{"op":"frob","path":"/age"}]`, http.StatusBadRequest, `invalid patch document: operation 0: unknown op "frob"`},
+		{`[{"op":"remove","path":"age"}]`, http.StatusBadRequest, `invalid patch document: operation 0: invalid JSON pointer "age"`},
+		{`[{"op":"test","path":"/age","value":31}]`, http.StatusConflict, `patch test failed at "/age"`},
+		{`[{"op":"replace","path":"/missing","value":1}]`, http.StatusUnprocessableEntity, `patch path "/missing" does not exist`},
+		{`[{"op":"remove","path":"/tags/2"}]`, http.StatusUnprocessableEntity, `patch path "/tags/2" does not exist`},
+		{`[{"op":"add","path":"/tags/01","value":"x"}]`, http.StatusUnprocessableEntity, `patch path "/tags/01" does not exist`},
+		{`[{"op":"add","path":"/address/x/y","value":1}]`, http.StatusUnprocessableEntity, `patch path "/address/x/y" does not exist`},
+		{`[{"op":"move","from":"/address","path":"/address/city"}]`, http.StatusUnprocessableEntity, ""},
+		{`[{"op":"remove","path":""}]`, http.StatusUnprocessableEntity, "patch cannot remove the whole document"},
+		{`[{"op":"replace","path":"/age","value":-1}]`, http.StatusUnprocessableEntity, ""},
+		{`[{"op":"replace","path":"/name","value":"x"},{"op":"test","path":"/name","value":"y"}]`, http.StatusConflict, ""},
+	}
+	for _, tt := range tests {
+		w, _, msg := doPatch(r, "/json/1", JSONPatchContentType, tt.body)
+		if w.Code != tt.status || tt.msg != "" && msg != tt.msg {
+			t.Fatalf("%s: %d %s", tt.body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func applyPatchDoc(t *testing.T, parse func(Codec, []byte) (patchFunc, error), doc, patch string) (string, error) {
+	t.Helper()
+	v, err := decodeJSONValue(StdCodec, []byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply, err := parse(StdCodec, []byte(patch))
+	if err != nil {
+		return "", err
+	}
+	if v, err = apply(v); err != nil {
+		return "", err
+	}
+	b, _ := json.Marshal(v)
+	return string(b), nil
+}
+
+// RFC 7396 附录 A.
+func TestMergePatchRFCExamples(t *testing.T) {
+	tests := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := applyPatchDoc(t, parseMergePatch, tt.doc, tt.patch)
+		if err != nil || got != tt.want {
+			t.Errorf("%s + %s = %s %v, want %s", tt.doc, tt.patch, got, err, tt.want)
+		}
+	}
+}
+
+// RFC 6902 附录 A.
+func TestJSONPatchRFCExamples(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+		status           int
+	}{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`, 0},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, 0},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, 0},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, 0},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, 0},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, 0},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, 0},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`, 0},
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``, http.StatusConflict},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"child":{"grandchild":{}},"foo":"bar"}`, 0},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"baz":"qux","foo":"bar"}`, 0},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``, http.StatusUnprocessableEntity},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`, 0},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, ``, http.StatusConflict},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, 0},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`, 0},
+		{`{"foo":{"a":1,"b":[1.0]}}`, `[{"op":"test","path":"/foo","value":{"b":[1],"a":1e0}}]`, `{"foo":{"a":1,"b":[1.0]}}`, 0},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`, 0},
+		{`{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`, 0},
+	}
+	for _, tt := range tests {
+		got, err := applyPatchDoc(t, parseJSONPatch, tt.doc, tt.patch)
+		var pe *PatchError
+		switch {
+		case tt.status != 0:
+			if !errors.As(err, &pe) || pe.Status != tt.status {
+				t.Errorf("%s + %s: err = %v, want status %d", tt.doc, tt.patch, err, tt.status)
+			}
+		case err != nil || got != tt.want:
+			t.Errorf("%s + %s = %s %v, want %s", tt.doc, tt.patch, got, err, tt.want)
+		}
+	}
+}
+
+func TestPatchOperationJSON(t *testing.T) {
+	ops := []PatchOperation{
+		{Op: "replace", Path: "/a", Value: nil},
+		{Op: "remove", Path: "/b"},
+		{Op: "move", From: "/c", Path: "/d"},
+	}
+	b, err := json.Marshal(ops)
+	want := `[{"op":"replace","path":"/a","value":null},{"op":"remove","path":"/b"},{"op":"move","path":"/d","from":"/c"}]`
+	if err != nil || string(b) != want {
+		t.Fatalf("marshal = %s %v", b, err)
+	}
+}
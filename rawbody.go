@@ -0,0 +1,81 @@
+package ginx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// defaultRawBodyBytes 是 KeepRawBody(0) 时的原始请求体上限.
+const defaultRawBodyBytes = 1 << 20
+
+type rawBodyKey struct{}
+
+// KeepRawBody 让路由在绑定前把请求体完整读入内存, handler 与拦截器可用 RawBody(ctx) 取得
+// 与 Req 绑定所用完全相同的原始字节, 例如校验 webhook 的 HMAC 签名.
+// 请求体超出 maxBytes 时返回 413, 避免签名只覆盖部分内容; maxBytes <= 0 时上限为 1 MiB.
+func KeepRawBody(maxBytes int64) RouteOption {
+	if maxBytes <= 0 {
+		maxBytes = defaultRawBodyBytes
+	}
+	return func(c *routeConfig) { c.rawBodyBytes = maxBytes }
+}
+
+// VerifyRawBody 在读取原始请求体之后、绑定请求之前调用 fn, 例如校验 webhook 签名,
+// 未通过的请求不会进入绑定、校验与 handler. fn 返回的错误按 writeError 渲染, *ErrWrap 的状态码照常生效.
+// 路由没有 KeepRawBody 时按 KeepRawBody(0) 保留请求体; 多个 VerifyRawBody 按注册顺序执行.
+func VerifyRawBody(fn func(ctx context.Context, body []byte) error) RouteOption {
+	return func(c *routeConfig) { c.rawBodyChecks = append(c.rawBodyChecks, fn) }
+}
+
+// RawBody 返回 KeepRawBody 路由保留的原始请求体, 未开启时返回 false; 无 body 的请求返回空切片.
+// 返回的切片与绑定共享, 不要修改.
+func RawBody(ctx context.Context) ([]byte, bool) {
+	x, ok := exchangeOf(ctx)
+	if !ok {
+		return nil, false
+	}
+	v, ok := x.Get(rawBodyKey{})
+	if !ok {
+		return nil, false
+	}
+	return v.([]byte), true
+}
+
+// keepRawBody 读取至多 maxBytes 字节的请求体并保存, 再把请求体替换为可重读的副本.
+func keepRawBody(x exchange, maxBytes int64) error {
+	body := []byte{}
+	r := x.Request()
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength > maxBytes {
+			return &bodyTooLargeError{msg: fmt.Sprintf("request body exceeds %d bytes", maxBytes)}
+		}
+		b, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(b)) > maxBytes {
+			return &bodyTooLargeError{msg: fmt.Sprintf("request body exceeds %d bytes", maxBytes)}
+		}
+		body = b
+		r.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	x.Set(rawBodyKey{}, body)
+	return nil
+}
+
+// checkRawBody 依次执行 VerifyRawBody 注册的检查, 失败时写出错误并返回 false.
+func checkRawBody(x exchange, cfg resolved) bool {
+	ctx := acquireContext(x)
+	defer releaseContext(ctx)
+	body, _ := x.Get(rawBodyKey{})
+	for _, fn := range cfg.rawBodyChecks {
+		if err := fn(ctx, body.([]byte)); err != nil {
+			writeError(ctx, cfg, err)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package ginx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rawBodyReq struct {
+	Event string `json:"event"`
+}
+
+func TestKeepRawBody(t *testing.T) {
+	var seen string
+	intercept := func(ctx context.Context, req any, next func() (any, error)) (any, error) {
+		raw, _ := RawBody(ctx)
+		seen = string(raw)
+		return next()
+	}
+	handler := func(ctx context.Context, req *rawBodyReq) (*rawBodyReq, error) {
+		raw, ok := RawBody(ctx)
+		if !ok {
+			return &rawBodyReq{Event: req.Event + "|none"}, nil
+		}
+		return &rawBodyReq{Event: req.Event + "|" + string(raw)}, nil
+	}
+	r := gin.New()
+	POST(r, "/raw", handler, KeepRawBody(32), RouteInterceptor(intercept))
+	POST(r, "/limited", handler, KeepRawBody(32), MaxBodyBytes(8))
+	POST(r, "/plain", handler)
+	// VerifyRawBody 在绑定之前运行, 未设置 KeepRawBody 时自动保留请求体.
+	POST(r, "/verified", handler, VerifyRawBody(func(ctx context.Context, body []byte) error {
+		if !strings.Contains(string(body), "push") {
+			return Error(4010, "bad signature").Status(http.StatusUnauthorized)
+		}
+		return nil
+	}))
+
+	body := `{ "event":"push" }`
+	tests := []struct {
+		path, body string
+		status     int
+		want       string
+	}{
+		{"/raw", body, http.StatusOK, `"event":"push|{ \"event\":\"push\" }"`},
+		{"/raw", `{"event":"` + strings.Repeat("x", 32) + `"}`, http.StatusRequestEntityTooLarge, "request body exceeds 32 bytes"},
+		{"/limited", body, http.StatusRequestEntityTooLarge, "request body exceeds 8 bytes"},
+		{"/plain", body, http.StatusOK, `"event":"push|none"`},
+		{"/verified", body, http.StatusOK, `"event":"push|{ \"event\":\"push\" }"`},
+		{"/verified", `{"event":1}`, http.StatusUnauthorized, `"code":4010`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) {
+			t.Fatalf("%s %s: %d %s", tt.path, tt.body, w.Code, w.Body.String())
+		}
+	}
+	if seen != body {
+		t.Fatalf("interceptor saw %q", seen)
+	}
+}
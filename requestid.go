@@ -0,0 +1,92 @@
+package ginx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"resty.dev/v3"
+)
+
+// RequestIDHeader 是请求 ID 的请求头 / 响应头名.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLen = 128
+
+type requestIDKey struct{}
+
+// RequestID 返回 ctx 中的请求 ID; 未开启 WithRequestID 或不在请求链路中时返回空串.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithRequestID 返回携带请求 ID 的 context, 主要用于在非 HTTP 入口
+// (任务、消息消费等) 发起调用时把 ID 传给 codegen 生成的 client.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// PropagateRequestID 是 resty 请求中间件: 请求头未显式设置时, 把 ctx 中的请求 ID
+// 写入 X-Request-ID. codegen 生成的 NewClient 会自动注册.
+func PropagateRequestID(_ *resty.Client, r *resty.Request) error {
+	if id := RequestID(r.Context()); id != "" && r.Header.Get(RequestIDHeader) == "" {
+		r.Header.Set(RequestIDHeader, id)
+	}
+	return nil
+}
+
+// assignRequestID 依次取 X-Request-ID、traceparent 的 trace-id, 都没有时调用 gen 生成;
+// 结果写入请求 context 并回显到响应头.
+func assignRequestID(x exchange, gen func() string) {
+	r := x.Request()
+	id := sanitizeRequestID(r.Header.Get(RequestIDHeader))
+	if id == "" {
+		id = traceIDFromTraceparent(r.Header.Get("traceparent"))
+	}
+	if id == "" {
+		id = gen()
+	}
+	setHeader(x, RequestIDHeader, id)
+	x.SetRequest(r.WithContext(ContextWithRequestID(r.Context(), id)))
+}
+
+// sanitizeRequestID 只接受有限长度的可打印 token, 避免日志注入和响应头拆分.
+func sanitizeRequestID(id string) string {
+	if id == "" || len(id) > maxRequestIDLen {
+		return ""
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return ""
+		}
+	}
+	return id
+}
+
+// traceIDFromTraceparent 按 W3C Trace Context 取出 trace-id, 格式不合法或全零时返回空串.
+func traceIDFromTraceparent(tp string) string {
+	parts := strings.Split(strings.TrimSpace(tp), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || parts[0] == "ff" {
+		return ""
+	}
+	traceID := strings.ToLower(parts[1])
+	if _, err := hex.DecodeString(traceID); err != nil || strings.Trim(traceID, "0") == "" {
+		return ""
+	}
+	return traceID
+}
+
+func defaultRequestIDGenerator() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,134 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"resty.dev/v3"
+)
+
+func TestRequestID(t *testing.T) {
+	e := New(WithRequestID(func() string { return "generated-1" }))
+	r := gin.New()
+	var seen string
+	GET(e.Wrap(r), "/items/:id", func(ctx context.Context, req *intURIReq) (*simpleRsp, error) {
+		seen = RequestID(ctx)
+		switch req.ID {
+		case 404:
+			return nil, Error(40401, "not found").Status(http.StatusNotFound)
+		case 500:
+			return nil, errors.New("boom")
+		}
+		return &simpleRsp{Message: "ok"}, nil
+	})
+
+	tests := []struct {
+		name   string
+		target string
+		header map[string]string
+		want   string
+		status int
+	}{
+		{"generated", "/items/1", nil, "generated-1", 200},
+		{"incoming header", "/items/1", map[string]string{RequestIDHeader: "abc-123"}, "abc-123", 200},
+		{"invalid header falls back", "/items/1", map[string]string{RequestIDHeader: "bad\nid"}, "generated-1", 200},
+		{"traceparent", "/items/1", map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}, "4bf92f3577b34da6a3ce929d0e0e4736", 200},
+		{"zero traceparent", "/items/1", map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"}, "generated-1", 200},
+		{"ErrWrap", "/items/404", map[string]string{RequestIDHeader: "e-404"}, "e-404", 404},
+		{"internal error", "/items/500", map[string]string{RequestIDHeader: "e-500"}, "e-500", 500},
+		{"binding error", "/items/x", map[string]string{RequestIDHeader: "e-400"}, "e-400", 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get(RequestIDHeader); got != tt.want {
+				t.Fatalf("response header = %q, want %q", got, tt.want)
+			}
+			var body map[string]any
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body["request_id"] != tt.want {
+				t.Fatalf("body request_id = %v, want %q: %s", body["request_id"], tt.want, w.Body.String())
+			}
+			if tt.status == 200 && seen != tt.want {
+				t.Fatalf("RequestID(ctx) = %q, want %q", seen, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestIDDisabledByDefault(t *testing.T) {
+	e := New()
+	r := gin.New()
+	GET(e.Wrap(r), "/ok", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		if id := RequestID(ctx); id != "" {
+			t.Errorf("unexpected request id %q", id)
+		}
+		return &simpleRsp{}, nil
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if w.Header().Get(RequestIDHeader) != "" || w.Body.String() != `{"code":0,"msg":"","data":{"message":""}}` {
+		t.Fatalf("unexpected response: %v %s", w.Header(), w.Body.String())
+	}
+}
+
+func TestRequestIDDefaultGenerator(t *testing.T) {
+	e := New(WithRequestID(nil))
+	r := gin.New()
+	GET(e.Wrap(r), "/ok", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{}, nil
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if id := w.Header().Get(RequestIDHeader); len(id) != 32 {
+		t.Fatalf("generated id = %q", id)
+	}
+}
+
+func TestParseResponseRequestID(t *testing.T) {
+	err := ParseResponse(http.StatusNotFound, []byte(`{"code":40401,"msg":"not found","request_id":"abc"}`), nil)
+	var ew *ErrWrap
+	if !errors.As(err, &ew) || ew.RequestID != "abc" || ew.Code != 40401 {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+}
+
+func TestPropagateRequestID(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(RequestIDHeader)
+	}))
+	defer srv.Close()
+
+	c := resty.New().AddRequestMiddleware(PropagateRequestID)
+	defer c.Close()
+	ctx := ContextWithRequestID(context.Background(), "outbound-1")
+	if _, err := c.R().SetContext(ctx).Get(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if got != "outbound-1" {
+		t.Fatalf("X-Request-ID = %q", got)
+	}
+	if _, err := c.R().SetContext(ctx).SetHeader(RequestIDHeader, "explicit").Get(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if got != "explicit" {
+		t.Fatalf("explicit header overridden: %q", got)
+	}
+}
@@ -3,12 +3,17 @@ package ginx
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
 )
 
 // Response 非 JSON 响应接口. handler 若返回实现此接口的类型, ginx 跳过默认 JSON 包装,
 // 直接调用 WriteTo 让业务写入响应. 返回的 error 会被记录到 gin 的 Errors 中.
+// HTTPHandler 处理的请求没有 *gin.Context, 只能返回 FileResponse 等内置实现.
 type Response interface {
 	WriteTo(c *gin.Context) error
 }
@@ -19,10 +24,11 @@ type ResponseVariant interface {
 	GinxResponseVariant() (status int, body any)
 }
 
-// statusResponse 允许内置非 JSON 响应使用 codegen 生成的固定 2xx 状态.
-// FileRsp 不实现此接口，因为 http.ServeFile 需按 Range 条件动态决定 200/206.
-type statusResponse interface {
-	writeToStatus(c *gin.Context, status int) error
+// exchangeResponse 由内置非 JSON 响应实现, 使其不依赖 *gin.Context 也能写出 (见 HTTPHandler).
+// status 非 0 时使用 codegen 生成的固定 2xx 状态; FileRsp 与 RedirectRsp 忽略它,
+// 因为 http.ServeFile 需按 Range 条件动态决定 200/206, 重定向使用自身的状态码.
+type exchangeResponse interface {
+	writeResponse(x exchange, status int) error
 }
 
 // --- File ---
@@ -40,10 +46,32 @@ func FileResponse(filePath, fileName string) *FileRsp {
 
 // WriteTo 实现 Response.
 func (r *FileRsp) WriteTo(c *gin.Context) error {
-	c.FileAttachment(r.FilePath, r.FileName)
+	return r.writeResponse(ginExchangeOf(c), 0)
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// writeResponse 与 gin.Context.FileAttachment 一致.
+func (r *FileRsp) writeResponse(x exchange, _ int) error {
+	h := x.Writer().Header()
+	if isASCII(r.FileName) {
+		h.Set("Content-Disposition", `attachment; filename="`+quoteEscaper.Replace(r.FileName)+`"`)
+	} else {
+		h.Set("Content-Disposition", `attachment; filename*=UTF-8''`+url.QueryEscape(r.FileName))
+	}
+	http.ServeFile(x.Writer(), x.Request(), r.FilePath)
 	return nil
 }
 
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
 // --- Redirect ---
 
 // RedirectRsp 重定向响应.
@@ -60,7 +88,11 @@ func RedirectResponse(code int, location string) *RedirectRsp {
 
 // WriteTo 实现 Response.
 func (r *RedirectRsp) WriteTo(c *gin.Context) error {
-	c.Redirect(r.Code, r.Location)
+	return r.writeResponse(ginExchangeOf(c), 0)
+}
+
+func (r *RedirectRsp) writeResponse(x exchange, _ int) error {
+	renderTo(x, -1, render.Redirect{Code: r.Code, Location: r.Location, Request: x.Request()})
 	return nil
 }
 
@@ -83,16 +115,17 @@ func StringResponse(code int, body string, args ...any) *StringRsp {
 
 // WriteTo 实现 Response.
 func (r *StringRsp) WriteTo(c *gin.Context) error {
-	c.String(r.Code, "%s", r.Body)
-	return nil
+	return r.writeResponse(ginExchangeOf(c), 0)
 }
 
-func (r *StringRsp) writeToStatus(c *gin.Context, status int) error {
-	if c.Request.Method == http.MethodHead || status == http.StatusNoContent {
-		c.Status(status)
+func (r *StringRsp) writeResponse(x exchange, status int) error {
+	if status == 0 {
+		status = r.Code
+	} else if x.Request().Method == http.MethodHead || status == http.StatusNoContent {
+		x.Writer().WriteHeader(status)
 		return nil
 	}
-	c.String(status, "%s", r.Body)
+	renderTo(x, status, render.String{Format: "%s", Data: []any{r.Body}})
 	return nil
 }
 
@@ -113,16 +146,17 @@ func DataResponse(code int, contentType string, data []byte) *DataRsp {
 
 // WriteTo 实现 Response.
 func (r *DataRsp) WriteTo(c *gin.Context) error {
-	c.Data(r.Code, r.ContentType, r.Data)
-	return nil
+	return r.writeResponse(ginExchangeOf(c), 0)
 }
 
-func (r *DataRsp) writeToStatus(c *gin.Context, status int) error {
-	if c.Request.Method == http.MethodHead || status == http.StatusNoContent {
-		c.Status(status)
+func (r *DataRsp) writeResponse(x exchange, status int) error {
+	if status == 0 {
+		status = r.Code
+	} else if x.Request().Method == http.MethodHead || status == http.StatusNoContent {
+		x.Writer().WriteHeader(status)
 		return nil
 	}
-	c.Data(status, r.ContentType, r.Data)
+	renderTo(x, status, render.Data{ContentType: r.ContentType, Data: r.Data})
 	return nil
 }
 
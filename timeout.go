@@ -0,0 +1,95 @@
+package ginx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrTimeout 表示 handler 超过了 Timeout / IdleTimeout, 渲染为 timeoutStatus (默认 504)
+// 与 timeoutCode (默认 3). handler 也可以直接返回它.
+var ErrTimeout = errors.New("request timeout")
+
+// WithTimeout 设置所有非流式路由的默认 handler 超时; d <= 0 表示不限制.
+func WithTimeout(d time.Duration) EngineOption {
+	return func(e *Engine) { e.timeout = d }
+}
+
+// WithTimeoutStatus 覆盖超时响应的 HTTP 状态码; 默认 504, 常见的替代是 503.
+func WithTimeoutStatus(status int) EngineOption {
+	return func(e *Engine) { e.timeoutStatus = status }
+}
+
+// WithTimeoutCode 覆盖超时响应的业务 code; 默认 3.
+func WithTimeoutCode(code int) EngineOption {
+	return func(e *Engine) { e.timeoutCode = code }
+}
+
+// Timeout 覆盖当前路由的 handler 超时; d <= 0 表示该路由不限制.
+// handler 收到的 ctx 带有截止时间, 超时后 ctx 被取消; handler 因此返回
+// context 错误时响应超时错误. ginx 不会抢占仍在运行的 handler.
+// SSE / JSON Lines 路由忽略该选项, 请使用 IdleTimeout.
+func Timeout(d time.Duration) RouteOption {
+	return func(c *routeConfig) { c.timeout = &d }
+}
+
+// IdleTimeout 为 SSE / JSON Lines 路由设置两次发送之间的最大间隔, 超出时取消 ctx.
+// 首条记录之前超时返回标准超时错误, 之后则结束流. 非流式路由忽略该选项.
+func IdleTimeout(d time.Duration) RouteOption {
+	return func(c *routeConfig) { c.idleTimeout = d }
+}
+
+// streamingRoute 标记 SSE / JSON Lines 路由, 由注册函数自动添加.
+func streamingRoute() RouteOption {
+	return func(c *routeConfig) { c.streaming = true }
+}
+
+type idleTimerKey struct{}
+
+type idleTimer struct {
+	timer *time.Timer
+	d     time.Duration
+}
+
+// withHandlerDeadline 按路由配置为 handler ctx 加上截止时间或空闲计时器.
+func withHandlerDeadline(ctx context.Context, x exchange, cfg resolved) (context.Context, func()) {
+	switch {
+	case cfg.timeout > 0:
+		return context.WithTimeoutCause(ctx, cfg.timeout, ErrTimeout)
+	case cfg.idleTimeout > 0:
+		ctx, cancel := context.WithCancelCause(ctx)
+		t := time.AfterFunc(cfg.idleTimeout, func() { cancel(ErrTimeout) })
+		x.Set(idleTimerKey{}, &idleTimer{timer: t, d: cfg.idleTimeout})
+		return ctx, func() {
+			t.Stop()
+			cancel(nil)
+		}
+	}
+	return ctx, func() {}
+}
+
+// touchIdle 在流式发送成功后重置空闲计时器.
+func touchIdle(x exchange) {
+	if v, ok := x.Get(idleTimerKey{}); ok {
+		it := v.(*idleTimer)
+		it.timer.Reset(it.d)
+	}
+}
+
+// isTimeout 判断 handler 错误是否由路由超时引起: 显式返回 ErrTimeout,
+// 或 ctx 因超时被取消且 handler 返回了 context 错误.
+func isTimeout(ctx context.Context, err error) bool {
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+	return errors.Is(context.Cause(ctx), ErrTimeout) &&
+		(errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled))
+}
+
+func normalizeTimeoutStatus(status int) int {
+	if status < http.StatusBadRequest || status > 599 {
+		return http.StatusGatewayTimeout
+	}
+	return status
+}
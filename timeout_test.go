@@ -0,0 +1,123 @@
+package ginx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func waitOrDone(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("query: %w", ctx.Err())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	r := gin.New()
+	g := New(WithTimeout(20 * time.Millisecond)).Wrap(r)
+	GET(g, "/slow", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			return nil, errors.New("missing deadline")
+		}
+		return &simpleRsp{}, waitOrDone(ctx, time.Second)
+	})
+	GET(g, "/override", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return &simpleRsp{Message: "done"}, waitOrDone(ctx, 50*time.Millisecond)
+	}, Timeout(time.Second))
+	GET(g, "/business", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		<-ctx.Done()
+		return nil, Error(4004, "not found").Status(http.StatusNotFound)
+	})
+	GET(g, "/late", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		// 忽略 ctx, 超时之后才返回成功.
+		time.Sleep(40 * time.Millisecond)
+		return &simpleRsp{Message: "late"}, nil
+	})
+	GET(g, "/explicit", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return nil, ErrTimeout
+	}, Timeout(0))
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/slow", http.StatusGatewayTimeout, `"code":3,"msg":"request timeout"`},
+		{"/override", http.StatusOK, `"message":"done"`},
+		{"/business", http.StatusNotFound, `"code":4004`},
+		{"/late", http.StatusGatewayTimeout, `"code":3,"msg":"request timeout"`},
+		{"/explicit", http.StatusGatewayTimeout, `"code":3`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Fatalf("%s = %d %s, want %d %s", tt.path, w.Code, w.Body.String(), tt.status, tt.body)
+		}
+	}
+
+	custom := gin.New()
+	GET(New(WithTimeoutStatus(http.StatusServiceUnavailable), WithTimeoutCode(5030)).Wrap(custom), "/slow", func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		return nil, waitOrDone(ctx, time.Second)
+	}, Timeout(10*time.Millisecond))
+	w := httptest.NewRecorder()
+	custom.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"code":5030`) {
+		t.Fatalf("custom timeout = %d %s", w.Code, w.Body.String())
+	}
+
+	m := NewMethod(nil, func(ctx context.Context, req *simpleReq) (*simpleRsp, error) {
+		time.Sleep(40 * time.Millisecond)
+		return &simpleRsp{}, nil
+	}, Timeout(10*time.Millisecond))
+	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+	gc.Request = httptest.NewRequest(http.MethodPost, "/rpc", nil)
+	var ew *ErrWrap
+	if _, err := m.Call(gc, nil); !errors.As(err, &ew) || ew.Code != 3 || ew.HttpCode != http.StatusGatewayTimeout {
+		t.Fatalf("late method result = %v", err)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	r := gin.New()
+	// Engine 级 Timeout 不作用于流式路由.
+	g := New(WithTimeout(5 * time.Millisecond)).Wrap(r)
+	JSONLines(g, http.MethodGet, "/stream", func(ctx context.Context, req *simpleReq, send JSONLinesSender) error {
+		for i := 0; i < 3; i++ {
+			if err := waitOrDone(ctx, 20*time.Millisecond); err != nil {
+				return err
+			}
+			if err := send(map[string]int{"n": i}); err != nil {
+				return err
+			}
+		}
+		// 第 4 条之前停顿超过空闲上限.
+		return waitOrDone(ctx, time.Second)
+	}, IdleTimeout(60*time.Millisecond))
+	SSE(g, "/silent", func(ctx context.Context, req *simpleReq, send Sender) error {
+		return waitOrDone(ctx, time.Second)
+	}, IdleTimeout(10*time.Millisecond))
+
+	w := httptest.NewRecorder()
+	start := time.Now()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if got := strings.Count(w.Body.String(), "\n"); got != 3 || time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("stream = %q after %v", w.Body.String(), time.Since(start))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/silent", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("idle before first event = %d %s", w.Code, w.Body.String())
+	}
+}
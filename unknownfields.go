@@ -0,0 +1,207 @@
+package ginx
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// UnknownFieldError 表示 JSON 请求体包含 Req 未声明的字段.
+// Path 为外部 JSON 路径, 如 "nmae"、"profile.zip"、"items[1].qty".
+type UnknownFieldError struct {
+	Path string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return "unknown field " + strconv.Quote(e.Path)
+}
+
+// WithDisallowUnknownFields 开启后 JSON body 出现 Req 未声明的字段时按绑定错误返回 400,
+// 避免客户端字段名拼写错误被静默忽略. 默认 false; 可用路由选项 DisallowUnknownFields 覆盖.
+func WithDisallowUnknownFields(b bool) EngineOption {
+	return func(e *Engine) { e.disallowUnknown = b }
+}
+
+// DisallowUnknownFields 覆盖当前路由是否拒绝 JSON body 中的未知字段.
+func DisallowUnknownFields(b bool) RouteOption {
+	return func(c *routeConfig) { c.disallowUnknown = &b }
+}
+
+// unknownFieldReader 记录解码器已读取的字节, 出错时据此定位未知字段的完整路径.
+type unknownFieldReader struct {
+	r   io.Reader
+	buf bytes.Buffer
+}
+
+func (r *unknownFieldReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.buf.Write(p[:n])
+	return n, err
+}
+
+// unknownFieldError 把解码器的 unknown field 错误转换为带 JSON 路径的 *UnknownFieldError;
+// 其它错误或无法定位时原样返回.
+func unknownFieldError(err error, t reflect.Type, data []byte) error {
+	if !strings.Contains(err.Error(), "unknown field") {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if path, ok := findUnknownField(dec, t, ""); ok {
+		return &UnknownFieldError{Path: path}
+	}
+	return err
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// findUnknownField 按 encoding/json 的字段匹配规则 (含大小写不敏感匹配) 遍历 dec 中的下一个值,
+// 返回第一个 t 未声明字段的路径. 语法错误或未找到时 ok 为 false.
+func findUnknownField(dec *json.Decoder, t reflect.Type, path string) (string, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return "", skipValue(dec)
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		tok, err := dec.Token()
+		if err != nil || tok != json.Delim('{') {
+			return "", false
+		}
+		fields := jsonFieldsOf(t)
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return "", false
+			}
+			key, _ := tok.(string)
+			name := key
+			if path != "" {
+				name = path + "." + key
+			}
+			ft, ok := fields.lookup(key)
+			if !ok {
+				return name, true
+			}
+			if p, found := findUnknownField(dec, ft, name); found {
+				return p, true
+			}
+		}
+		_, _ = dec.Token()
+		return "", false
+	case reflect.Slice, reflect.Array:
+		tok, err := dec.Token()
+		if err != nil || tok != json.Delim('[') {
+			return "", false
+		}
+		for i := 0; dec.More(); i++ {
+			if p, found := findUnknownField(dec, t.Elem(), path+"["+strconv.Itoa(i)+"]"); found {
+				return p, true
+			}
+		}
+		_, _ = dec.Token()
+		return "", false
+	case reflect.Map:
+		tok, err := dec.Token()
+		if err != nil || tok != json.Delim('{') {
+			return "", false
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return "", false
+			}
+			key, _ := tok.(string)
+			name := key
+			if path != "" {
+				name = path + "." + key
+			}
+			if p, found := findUnknownField(dec, t.Elem(), name); found {
+				return p, true
+			}
+		}
+		_, _ = dec.Token()
+		return "", false
+	}
+	skipValue(dec)
+	return "", false
+}
+
+func skipValue(dec *json.Decoder) bool {
+	var raw json.RawMessage
+	return dec.Decode(&raw) == nil
+}
+
+// jsonFields 是结构体可接收的 JSON 字段, exact 精确匹配, folded 按小写匹配.
+type jsonFields struct {
+	exact  map[string]reflect.Type
+	folded map[string]reflect.Type
+}
+
+func (f *jsonFields) lookup(key string) (reflect.Type, bool) {
+	if t, ok := f.exact[key]; ok {
+		return t, true
+	}
+	t, ok := f.folded[strings.ToLower(key)]
+	return t, ok
+}
+
+var jsonFieldsCache sync.Map // reflect.Type -> *jsonFields
+
+func jsonFieldsOf(t reflect.Type) *jsonFields {
+	if v, ok := jsonFieldsCache.Load(t); ok {
+		return v.(*jsonFields)
+	}
+	f := &jsonFields{exact: make(map[string]reflect.Type), folded: make(map[string]reflect.Type)}
+	collectJSONFields(t, f, map[reflect.Type]bool{})
+	jsonFieldsCache.Store(t, f)
+	return f
+}
+
+// collectJSONFields 收集 t 的 JSON 字段名; 匿名结构体字段未写 json 名时提升其字段.
+// 只用于判断字段是否存在, 不处理同名字段的优先级.
+func collectJSONFields(t reflect.Type, f *jsonFields, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectJSONFields(ft, f, seen)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if _, ok := f.exact[name]; !ok {
+			f.exact[name] = sf.Type
+		}
+		if lower := strings.ToLower(name); f.folded[lower] == nil {
+			f.folded[lower] = sf.Type
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type unknownItem struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+type unknownBase struct {
+	Tenant string `json:"tenant"`
+}
+
+type unknownReq struct {
+	unknownBase
+	ID      int                `uri:"id"`
+	Name    string             `json:"name" binding:"required"`
+	Items   []unknownItem      `json:"items"`
+	Profile *struct{ Zip int } `json:"profile"`
+	Labels  map[string]string  `json:"labels"`
+	Extra   any                `json:"extra"`
+	At      time.Time          `json:"at"`
+	Secret  string             `json:"-"`
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	r := gin.New()
+	e := New(WithDisallowUnknownFields(true), WithInvalidArgCode(4000))
+	handler := func(ctx context.Context, req *unknownReq) (*unknownReq, error) { return req, nil }
+	POST(e.Wrap(r), "/strict/:id", handler)
+	POST(e.Wrap(r), "/loose/:id", handler, DisallowUnknownFields(false))
+	POST(r, "/default/:id", handler, DisallowUnknownFields(true))
+
+	tests := []struct {
+		path, body string
+		status     int
+		msg        string
+	}{
+		{"/strict/1", `{"name":"a","tenant":"t","items":[{"sku":"x","qty":1}],"profile":{"zip":1},"labels":{"any":"v"},"extra":{"free":1},"at":"2026-01-02T00:00:00Z"}`, http.StatusOK, ""},
+		{"/strict/1", `{"NAME":"a","Items":[{"SKU":"x"}],"ID":2}`, http.StatusOK, ""},
+		{"/strict/1", `{"name":"a","nmae":"b"}`, http.StatusBadRequest, `unknown field "nmae"`},
+		{"/strict/1", `{"name":"a","items":[{"sku":"x"},{"sku":"y","qyt":2}]}`, http.StatusBadRequest, `unknown field "items[1].qyt"`},
+		{"/strict/1", `{"profile":{"zip":1,"city":"x"},"name":"a"}`, http.StatusBadRequest, `unknown field "profile.city"`},
+		{"/strict/1", `{"name":"a","Secret":"s"}`, http.StatusBadRequest, `unknown field "Secret"`},
+		{"/strict/1", `{"name":"a","x":1`, http.StatusBadRequest, "unexpected EOF"},
+		{"/loose/1", `{"name":"a","nmae":"b"}`, http.StatusOK, ""},
+		{"/default/1", `{"name":"a","nmae":"b"}`, http.StatusBadRequest, `unknown field "nmae"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != tt.status || body.Msg != tt.msg {
+			t.Fatalf("%s %s: %d %s", tt.path, tt.body, w.Code, w.Body.String())
+		}
+		if tt.status == http.StatusBadRequest && tt.path == "/strict/1" && body.Code != 4000 {
+			t.Fatalf("code = %d", body.Code)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ginx
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// RequestValidator 由需要跨字段校验的 Req 实现. ginx 在 binding tag 校验通过后调用 Validate,
+// 返回错误时请求按校验失败结束, handler 不会执行:
+//
+//   - *ErrWrap 按其 Code / Msg 渲染, 未指定 HTTP 状态码时为 400
+//   - 其它错误 (如 FieldErrors, validator.ValidationErrors) 按 invalidArgCode 与 400 渲染
+//
+// 配置了 WithValidationErrorHandler 时所有错误都交给它处理.
+//
+//	func (r *ListOrdersReq) Validate(ctx context.Context) error {
+//		if r.End.Before(r.Start) {
+//			return ginx.FieldErrors{{Field: "end", Message: "must be after start"}}
+//		}
+//		return nil
+//	}
+type RequestValidator interface {
+	Validate(ctx context.Context) error
+}
+
+var requestValidatorType = reflect.TypeFor[RequestValidator]()
+
+// FieldError 是 Validate 返回的单个字段错误, Field 使用对外字段名.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors 是 Validate 返回的字段错误集合, Error 形如 "end must be after start; ...".
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + " " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// validateHookError 标记来自 RequestValidator 的错误, 使其按校验阶段渲染.
+type validateHookError struct{ err error }
+
+func (e *validateHookError) Error() string { return e.err.Error() }
+func (e *validateHookError) Unwrap() error { return e.err }
+
+// runValidateHook 调用 req 的 Validate, 非 nil 错误包装为 *validateHookError.
+func runValidateHook(ctx context.Context, req any) error {
+	v, ok := req.(RequestValidator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(ctx); err != nil {
+		return &validateHookError{err: err}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rangeReq struct {
+	Start int    `form:"start" binding:"gte=0"`
+	End   int    `form:"end"`
+	Email string `form:"email"`
+	Phone string `form:"phone"`
+	Mode  string `form:"mode"`
+}
+
+func (r *rangeReq) Validate(ctx context.Context) error {
+	if _, ok := GinContext(ctx); !ok {
+		return errors.New("missing gin context")
+	}
+	switch {
+	case r.End < r.Start:
+		return FieldErrors{{Field: "end", Message: "must not be before start"}}
+	case (r.Email == "") == (r.Phone == ""):
+		return FieldErrors{{Field: "email", Message: "or phone is required"}, {Field: "phone", Message: "or email is required"}}
+	case r.Mode == "locked":
+		return Error(4230, "range is locked").Status(http.StatusLocked)
+	case r.Mode == "coded":
+		return Error(4001, "bad mode")
+	case r.Mode == "plain":
+		return errors.New("plain failure")
+	case r.Mode == "deadline":
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("missing deadline")
+		}
+	}
+	return nil
+}
+
+func TestRequestValidator(t *testing.T) {
+	var calls int
+	handler := func(ctx context.Context, req *rangeReq) (*rangeReq, error) {
+		calls++
+		return req, nil
+	}
+	r := gin.New()
+	GET(New(WithInvalidArgCode(4000)).Wrap(r), "/range", handler)
+	custom := New(WithValidationErrorHandler(func(ctx context.Context, err error) (int, any) {
+		var fe FieldErrors
+		if errors.As(err, &fe) {
+			return http.StatusUnprocessableEntity, map[string]any{"errors": fe}
+		}
+		return 0, nil
+	}))
+	GET(custom.Wrap(r), "/custom", handler)
+	GET(New(WithInvalidArgCode(4000)).Wrap(r), "/timed", handler, Timeout(time.Second))
+
+	tests := []struct {
+		path   string
+		status int
+		code   int
+		body   string
+	}{
+		{"/range?start=1&end=2&email=a", http.StatusOK, 0, ""},
+		{"/range?start=-1&end=2&email=a", http.StatusBadRequest, 4000, "start must be greater than or equal to 0"},
+		{"/range?start=3&end=2&email=a", http.StatusBadRequest, 4000, "end must not be before start"},
+		{"/range?email=a&phone=b", http.StatusBadRequest, 4000, "email or phone is required; phone or email is required"},
+		{"/range?email=a&mode=locked", http.StatusLocked, 4230, "range is locked"},
+		{"/range?email=a&mode=coded", http.StatusBadRequest, 4001, "bad mode"},
+		{"/range?email=a&mode=plain", http.StatusBadRequest, 4000, "plain failure"},
+		{"/custom?start=3&end=2&email=a", http.StatusUnprocessableEntity, 0, `{"errors":[{"field":"end","message":"must not be before start"}]}`},
+		{"/custom?email=a&mode=coded", http.StatusBadRequest, 4001, "bad mode"},
+		{"/timed?email=a&mode=deadline", http.StatusOK, 0, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status {
+			t.Fatalf("%s: %d %s", tt.path, w.Code, w.Body.String())
+		}
+		if strings.HasPrefix(tt.body, "{") {
+			if strings.TrimSpace(w.Body.String()) != tt.body {
+				t.Fatalf("%s: body %s", tt.path, w.Body.String())
+			}
+			continue
+		}
+		var body struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Code != tt.code || body.Msg != tt.body {
+			t.Fatalf("%s: body %s", tt.path, w.Body.String())
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("handler calls = %d, want 2", calls)
+	}
+}
+
+func TestRequestValidatorMethod(t *testing.T) {
+	m := NewMethod(New(WithInvalidArgCode(4000)), func(ctx context.Context, req *rangeReq) (*rangeReq, error) { return req, nil })
+	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+	gc.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+
+	_, err := m.Call(gc, []byte(`{"Start":3,"End":2,"Email":"a"}`))
+	var ce *CallError
+	if !errors.As(err, &ce) || ce.Stage != StageValidation || ce.Code != 4000 || ce.Msg != "end must not be before start" {
+		t.Fatalf("err = %#v", err)
+	}
+	_, err = m.Call(gc, []byte(`{"Email":"a","Mode":"locked"}`))
+	var ew *ErrWrap
+	if !errors.As(err, &ew) || ew.Code != 4230 {
+		t.Fatalf("err = %#v", err)
+	}
+}
+
+type rangeResource struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func (r *rangeResource) Validate(ctx context.Context) error {
+	if r.End < r.Start {
+		return FieldErrors{{Field: "end", Message: "must not be before start"}}
+	}
+	return nil
+}
+
+func TestRequestValidatorPatch(t *testing.T) {
+	r := gin.New()
+	MergePatch(r, "/range",
+		func(ctx context.Context, req *struct{}) (*rangeResource, error) {
+			return &rangeResource{Start: 1, End: 5}, nil
+		},
+		func(ctx context.Context, req *struct{}, patched *rangeResource) (*rangeResource, error) {
+			return patched, nil
+		})
+
+	for body, status := range map[string]int{`{"end":3}`: http.StatusOK, `{"end":0}`: http.StatusUnprocessableEntity} {
+		req := httptest.NewRequest(http.MethodPatch, "/range", strings.NewReader(body))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status || status != http.StatusOK && !strings.Contains(w.Body.String(), "end must not be before start") {
+			t.Fatalf("%s: %d %s", body, w.Code, w.Body.String())
+		}
+	}
+}
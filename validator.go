@@ -0,0 +1,217 @@
+package ginx
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// engineValidator 是 Engine 私有的 validator 及其自定义规则文案.
+// nil 表示沿用 gin 全局的 binding.Validator.
+type engineValidator struct {
+	v        *validator.Validate
+	mu       sync.RWMutex
+	messages map[string]string // tag -> 文案
+	optional sync.Map          // reflect.Type -> struct{}, 已注册的 Optional 类型
+}
+
+func newEngineValidator(v *validator.Validate) *engineValidator {
+	registerBuiltinRules(v)
+	return &engineValidator{v: v, messages: make(map[string]string)}
+}
+
+// NewValidator 返回与 gin 默认规则一致 (读取 `binding` tag) 的 validator, 并把错误中的字段名
+// 解析为 json > form > uri > header > cookie tag 名, 供 WithValidator 使用.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(tagFieldName)
+	return v
+}
+
+// tagFieldName 按 json > form > uri > header > cookie 的优先级返回字段对外名称.
+func tagFieldName(f reflect.StructField) string {
+	for _, key := range [...]string{"json", "form", "uri", "header", "cookie"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return ""
+}
+
+// WithValidator 让 Engine 使用独立的 validator 校验 binding tag, 不再读写全局 binding.Validator,
+// 使不同 Engine 可以注册不同的自定义规则. v 为 nil 时恢复为 binding.Validator.
+// 需要字段名按 json 等 tag 展示时, 用 NewValidator 创建 v 或自行 RegisterTagNameFunc.
+func WithValidator(v *validator.Validate) EngineOption {
+	return func(e *Engine) {
+		e.validator = nil
+		if v != nil {
+			e.validator = newEngineValidator(v)
+		}
+	}
+}
+
+// RegisterValidation 在 Engine 私有 validator 上注册自定义规则 tag, message 为校验失败时
+// 跟在字段名后的文案, 其中 {param} 替换为规则参数, 例如 "must be a multiple of {param}".
+// 未配置 WithValidator 时先创建 NewValidator(). 只对此后注册的路由生效, 应在注册路由前调用.
+func (e *Engine) RegisterValidation(tag string, fn validator.Func, message string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.validator == nil {
+		e.validator = newEngineValidator(NewValidator())
+	}
+	if err := e.validator.v.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+	e.validator.mu.Lock()
+	e.validator.messages[tag] = message
+	e.validator.mu.Unlock()
+	return nil
+}
+
+// prepare 在路由注册时为 plan 涉及的 Optional 类型注册取值函数.
+func (ev *engineValidator) prepare(plan *bindingPlan) {
+	if ev == nil {
+		return
+	}
+	for _, t := range plan.optionalTypes {
+		if _, loaded := ev.optional.LoadOrStore(t, struct{}{}); loaded {
+			continue
+		}
+		ev.v.RegisterCustomTypeFunc(optionalValidationValue, reflect.Zero(t).Interface())
+	}
+}
+
+// validateStruct 校验 obj 的 binding tag.
+func (ev *engineValidator) validateStruct(obj any) error {
+	if ev == nil {
+		return binding.Validator.ValidateStruct(obj)
+	}
+	return ev.v.Struct(obj)
+}
+
+// formatError 格式化校验错误, 自定义规则使用 RegisterValidation 登记的文案.
+func (ev *engineValidator) formatError(err error, fieldNameMap map[string]string) string {
+	namer := func(fe validator.FieldError) string {
+		// 注册了 TagNameFunc 时 Field 已是对外名称
+		if fe.Field() != fe.StructField() {
+			return fe.Field()
+		}
+		if tagName, ok := fieldNameMap[fe.Field()]; ok && tagName != "" {
+			return tagName
+		}
+		return fe.Field()
+	}
+	if ev == nil {
+		return formatValidationError(err, namer, nil)
+	}
+	ev.mu.RLock()
+	defer ev.mu.RUnlock()
+	return formatValidationError(err, namer, ev.messages)
+}
+
+var (
+	patterns      sync.Map // name -> *regexp.Regexp
+	builtinRuleOn sync.Map // *validator.Validate -> struct{}
+)
+
+// RegisterPattern 登记 `binding:"pattern=<name>"` 使用的正则, 由 oapi-ginx 生成代码在 init 中调用,
+// name 为正则的稳定哈希. 正则按 regexp 语法编译, 非锚定匹配; 无法编译时 panic.
+func RegisterPattern(name, expr string) {
+	patterns.Store(name, regexp.MustCompile(expr))
+}
+
+// registerBuiltinRules 在 v 上注册 ginx 内置的 pattern / multiple_of 规则, 每个 v 只注册一次.
+func registerBuiltinRules(v *validator.Validate) {
+	if _, loaded := builtinRuleOn.LoadOrStore(v, struct{}{}); loaded {
+		return
+	}
+	_ = v.RegisterValidation("pattern", validatePattern)
+	_ = v.RegisterValidation("multiple_of", validateMultipleOf)
+}
+
+// registerGlobalRules 在路由注册时为 gin 全局 validator 注册内置规则;
+// binding.Validator 被替换为其它实现时不做处理.
+func registerGlobalRules() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		registerBuiltinRules(v)
+	}
+}
+
+func validatePattern(fl validator.FieldLevel) bool {
+	re, ok := patterns.Load(fl.Param())
+	if !ok {
+		panic("ginx: pattern " + fl.Param() + " is not registered")
+	}
+	text, ok := fieldText(fl.Field())
+	if !ok {
+		panic(fmt.Sprintf("Bad field type %s", fl.Field().Type()))
+	}
+	return re.(*regexp.Regexp).MatchString(text)
+}
+
+// validateMultipleOf 用有理数精确判断, 0.1 的倍数等十进制小数不受浮点误差影响.
+func validateMultipleOf(fl validator.FieldLevel) bool {
+	div, ok := new(big.Rat).SetString(fl.Param())
+	if !ok || div.Sign() == 0 {
+		panic("ginx: invalid multiple_of parameter " + fl.Param())
+	}
+	field := fl.Field()
+	val := new(big.Rat)
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val.SetInt64(field.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		val.SetInt(new(big.Int).SetUint64(field.Uint()))
+	case reflect.Float32, reflect.Float64:
+		f := field.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return false
+		}
+		val.SetString(strconv.FormatFloat(f, 'g', -1, field.Type().Bits()))
+	default:
+		text, ok := fieldText(field)
+		if !ok {
+			panic(fmt.Sprintf("Bad field type %s", field.Type()))
+		}
+		if _, ok := val.SetString(text); !ok {
+			return false
+		}
+	}
+	return val.Quo(val, div).IsInt()
+}
+
+// fieldText 返回字符串字段或 TextMarshaler / Stringer (如 type-mapping 映射的 decimal 类型) 的文本.
+func fieldText(field reflect.Value) (string, bool) {
+	if field.Kind() == reflect.String {
+		return field.String(), true
+	}
+	if !field.CanInterface() {
+		return "", false
+	}
+	switch v := field.Interface().(type) {
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		return string(b), err == nil
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// patternExpr 返回 pattern 规则登记的正则文本, 未登记时返回 name.
+func patternExpr(name string) string {
+	if re, ok := patterns.Load(name); ok {
+		return re.(*regexp.Regexp).String()
+	}
+	return name
+}
@@ -0,0 +1,160 @@
+package ginx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type lotReq struct {
+	Size  int           `json:"size" binding:"lot=10"`
+	Extra Optional[int] `json:"extra,omitzero" binding:"omitempty,lot=5"`
+	Items []lotItem     `json:"items" binding:"dive"`
+}
+
+type lotItem struct {
+	Qty int `json:"qty" binding:"lot=2"`
+}
+
+func TestEngineRegisterValidation(t *testing.T) {
+	multiple := func(fl validator.FieldLevel) bool {
+		n, _ := strconv.ParseInt(fl.Param(), 10, 64)
+		return n > 0 && fl.Field().Int()%n == 0
+	}
+	atMost := func(fl validator.FieldLevel) bool {
+		n, _ := strconv.ParseInt(fl.Param(), 10, 64)
+		return fl.Field().Int() <= n
+	}
+	strict := New(WithInvalidArgCode(4000))
+	if err := strict.RegisterValidation("lot", multiple, "must be a multiple of {param}"); err != nil {
+		t.Fatal(err)
+	}
+	loose := New(WithInvalidArgCode(4000), WithValidator(NewValidator()))
+	if err := loose.RegisterValidation("lot", atMost, "must be at most {param}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := loose.RegisterValidation("", atMost, ""); err == nil {
+		t.Fatal("empty tag should be rejected")
+	}
+
+	handler := func(ctx context.Context, req *lotReq) (*lotReq, error) { return req, nil }
+	r := gin.New()
+	POST(strict.Wrap(r), "/strict", handler)
+	POST(loose.Wrap(r), "/loose", handler)
+
+	tests := []struct {
+		path, body string
+		status     int
+		msg        string
+	}{
+		{"/strict", `{"size":20,"items":[{"qty":4}]}`, http.StatusOK, ""},
+		{"/strict", `{"size":7}`, http.StatusBadRequest, "size must be a multiple of 10"},
+		{"/strict", `{"size":10,"extra":3}`, http.StatusBadRequest, "extra must be a multiple of 5"},
+		{"/strict", `{"size":10,"items":[{"qty":3}]}`, http.StatusBadRequest, "qty must be a multiple of 2"},
+		{"/loose", `{"size":7,"extra":3}`, http.StatusOK, ""},
+		{"/loose", `{"size":20}`, http.StatusBadRequest, "size must be at most 10"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("%s %s: %d %s", tt.path, tt.body, w.Code, w.Body.String())
+		}
+		if tt.msg == "" {
+			continue
+		}
+		var body struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		if body.Code != 4000 || body.Msg != tt.msg {
+			t.Fatalf("%s %s: body %s", tt.path, tt.body, w.Body.String())
+		}
+	}
+
+}
+
+func TestEngineValidatorMethodAndPatch(t *testing.T) {
+	e := New(WithInvalidArgCode(4000))
+	if err := e.RegisterValidation("lot", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%10 == 0
+	}, "must be a multiple of {param}"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMethod(e, func(ctx context.Context, req *lotReq) (*lotReq, error) { return req, nil })
+	gc, _ := gin.CreateTestContext(httptest.NewRecorder())
+	gc.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	if _, err := m.Call(gc, []byte(`{"size":7}`)); err == nil || !strings.Contains(err.Error(), "size must be a multiple of 10") {
+		t.Fatalf("err = %v", err)
+	}
+
+	r := gin.New()
+	MergePatch(e.Wrap(r), "/lot",
+		func(ctx context.Context, req *struct{}) (*lotReq, error) { return &lotReq{Size: 10}, nil },
+		func(ctx context.Context, req *struct{}, patched *lotReq) (*lotReq, error) { return patched, nil })
+	for body, status := range map[string]int{`{"size":30}`: http.StatusOK, `{"size":31}`: http.StatusUnprocessableEntity} {
+		req := httptest.NewRequest(http.MethodPatch, "/lot", strings.NewReader(body))
+		req.Header.Set("Content-Type", MergePatchContentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status || status != http.StatusOK && !strings.Contains(w.Body.String(), "size must be a multiple of 10") {
+			t.Fatalf("%s: %d %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+type skuReq struct {
+	SKU   string   `form:"sku" binding:"omitempty,pattern=sku0test"`
+	Qty   int      `form:"qty" binding:"multiple_of=5"`
+	Price *float64 `form:"price" binding:"omitempty,multiple_of=0.01"`
+}
+
+func TestBuiltinRules(t *testing.T) {
+	RegisterPattern("sku0test", `^[A-Z]{3}-\d{4}$`)
+	handler := func(ctx context.Context, req *skuReq) (*skuReq, error) { return req, nil }
+	r := gin.New()
+	GET(New(WithInvalidArgCode(4000)).Wrap(r), "/global", handler)
+	GET(New(WithInvalidArgCode(4000), WithValidator(NewValidator())).Wrap(r), "/engine", handler)
+
+	tests := []struct {
+		query  string
+		status int
+		msg    string
+	}{
+		{"sku=ABC-1234&qty=15&price=19.99", http.StatusOK, ""},
+		{"qty=0&price=0.3", http.StatusOK, ""},
+		{"sku=abc-1234&qty=5", http.StatusBadRequest, `sku must match pattern ^[A-Z]{3}-\d{4}$`},
+		{"qty=7", http.StatusBadRequest, "qty must be a multiple of 5"},
+		{"qty=5&price=1.005", http.StatusBadRequest, "price must be a multiple of 0.01"},
+	}
+	for _, path := range []string{"/global", "/engine"} {
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path+"?"+tt.query, nil))
+			if w.Code != tt.status {
+				t.Fatalf("%s?%s: %d %s", path, tt.query, w.Code, w.Body.String())
+			}
+			if tt.msg == "" {
+				continue
+			}
+			var body struct {
+				Msg string `json:"msg"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			if body.Msg != tt.msg {
+				t.Fatalf("%s?%s: body %s", path, tt.query, w.Body.String())
+			}
+		}
+	}
+}
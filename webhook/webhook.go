@@ -0,0 +1,509 @@
+// Package webhook 校验与签发 webhook 请求签名, 支持两种方案:
+//
+//   - HMACSHA256: X-Webhook-Signature: sha256=<hex> 与 X-Webhook-Timestamp: <unix 秒>,
+//     签名内容为 "<timestamp>.<body>", 密钥为原始字符串
+//   - StandardWebhooks: 遵循 Standard Webhooks 规范的 webhook-id / webhook-timestamp /
+//     webhook-signature: v1,<base64> 请求头, 签名内容为 "<id>.<timestamp>.<body>", 密钥为 whsec_<base64>
+//
+// 签名头可以携带多个以空格或逗号分隔的签名, 便于发送方轮换密钥; 接收方同样可以配置多个密钥.
+// 时间戳超出容忍窗口 (默认 5 分钟) 的请求被拒绝, 窗口内重复投递的同一消息由 NonceStore 拦截.
+//
+// 接收端:
+//
+//	v, err := webhook.NewVerifier(webhook.HMACSHA256, []string{secret})
+//	ginx.POST(r, "/webhooks/order", HandleOrder,
+//		webhook.VerifySignature(webhook.HMACSHA256, v), ginx.RouteInterceptor(webhook.Interceptor(webhook.HMACSHA256, v)))
+//
+// 发送端 (投递或测试):
+//
+//	s, err := webhook.NewSigner(webhook.HMACSHA256, secret)
+//	err = s.Sign(req.Header, body)
+//
+// oapi-ginx 为带 x-ginx-webhook-signature 扩展的 operation 自动生成以上路由选项.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chendefine/ginx"
+)
+
+// Scheme 是签名方案.
+type Scheme string
+
+const (
+	HMACSHA256       Scheme = "hmac-sha256"       // sha256=<hex> 签名头 + 时间戳头
+	StandardWebhooks Scheme = "standard-webhooks" // Standard Webhooks 规范
+)
+
+// 各方案的默认请求头.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+
+	StandardIDHeader        = "webhook-id"
+	StandardTimestampHeader = "webhook-timestamp"
+	StandardSignatureHeader = "webhook-signature"
+)
+
+// DefaultTolerance 是时间戳与当前时间允许的最大偏差.
+const DefaultTolerance = 5 * time.Minute
+
+// 校验失败的原因. VerifySignature 把它们渲染为 401.
+var (
+	ErrMissingSignature = errors.New("missing webhook signature")
+	ErrInvalidTimestamp = errors.New("invalid webhook timestamp")
+	ErrTimestampExpired = errors.New("webhook timestamp outside tolerance")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrReplayed         = errors.New("webhook already received")
+)
+
+// errNotVerified 表示使用 Interceptor 的路由没有 VerifySignature, 属于配置错误.
+var errNotVerified = errors.New("webhook: route must use webhook.VerifySignature")
+
+// Headers 覆盖方案的默认请求头名, 为空的字段沿用默认值. ID 只用于 StandardWebhooks.
+type Headers struct {
+	ID        string
+	Timestamp string
+	Signature string
+}
+
+// Option 配置 Verifier 与 Signer.
+type Option func(*config)
+
+type config struct {
+	tolerance time.Duration
+	nonces    NonceStore
+	headers   Headers
+	now       func() time.Time
+	code      int
+}
+
+// WithTolerance 设置时间戳容忍窗口, d <= 0 时使用 DefaultTolerance.
+func WithTolerance(d time.Duration) Option {
+	return func(c *config) {
+		if d <= 0 {
+			d = DefaultTolerance
+		}
+		c.tolerance = d
+	}
+}
+
+// WithNonceStore 设置防重放存储, 默认为进程内的 MemoryNonceStore; nil 关闭防重放.
+func WithNonceStore(s NonceStore) Option {
+	return func(c *config) { c.nonces = s }
+}
+
+// WithHeaders 覆盖默认请求头名.
+func WithHeaders(h Headers) Option {
+	return func(c *config) { c.headers = h }
+}
+
+// WithClock 替换当前时间来源, 用于测试.
+func WithClock(now func() time.Time) Option {
+	return func(c *config) { c.now = now }
+}
+
+// WithErrorCode 设置 VerifySignature 校验失败时 ErrWrap 的业务 code, 默认 401.
+func WithErrorCode(code int) Option {
+	return func(c *config) { c.code = code }
+}
+
+func newConfig(scheme Scheme, opts []Option) (config, error) {
+	c := config{tolerance: DefaultTolerance, nonces: NewMemoryNonceStore(), now: time.Now, code: http.StatusUnauthorized}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	var def Headers
+	switch scheme {
+	case HMACSHA256:
+		def = Headers{Timestamp: TimestampHeader, Signature: SignatureHeader}
+	case StandardWebhooks:
+		def = Headers{ID: StandardIDHeader, Timestamp: StandardTimestampHeader, Signature: StandardSignatureHeader}
+	default:
+		return c, fmt.Errorf("webhook: unknown scheme %q", scheme)
+	}
+	if c.headers.ID == "" {
+		c.headers.ID = def.ID
+	}
+	if c.headers.Timestamp == "" {
+		c.headers.Timestamp = def.Timestamp
+	}
+	if c.headers.Signature == "" {
+		c.headers.Signature = def.Signature
+	}
+	return c, nil
+}
+
+// decodeSecret 返回方案使用的 HMAC 密钥: StandardWebhooks 为 whsec_ 之后的 base64 解码结果.
+func decodeSecret(scheme Scheme, secret string) ([]byte, error) {
+	if secret == "" {
+		return nil, errors.New("webhook: empty secret")
+	}
+	if scheme != StandardWebhooks {
+		return []byte(secret), nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return nil, fmt.Errorf("webhook: secret must be whsec_<base64>: %w", err)
+	}
+	return key, nil
+}
+
+// Verifier 校验入站 webhook 请求, 并发安全.
+type Verifier struct {
+	scheme Scheme
+	keys   [][]byte
+	cfg    config
+}
+
+// NewVerifier 创建 Verifier. secrets 中任一密钥签名有效即通过, 轮换密钥时可同时配置新旧两个.
+func NewVerifier(scheme Scheme, secrets []string, opts ...Option) (*Verifier, error) {
+	cfg, err := newConfig(scheme, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return nil, errors.New("webhook: at least one secret is required")
+	}
+	v := &Verifier{scheme: scheme, cfg: cfg}
+	for _, s := range secrets {
+		key, err := decodeSecret(scheme, s)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, key)
+	}
+	return v, nil
+}
+
+// Scheme 返回 Verifier 的签名方案.
+func (v *Verifier) Scheme() Scheme { return v.scheme }
+
+// Verify 校验请求头与原始请求体, 通过后在 NonceStore 中登记该消息.
+// 签名错误返回 Err* 之一, NonceStore 的错误原样返回.
+func (v *Verifier) Verify(ctx context.Context, h http.Header, body []byte) error {
+	_, err := v.verify(ctx, h, body)
+	return err
+}
+
+// verify 实现 Verify, 并返回登记的 nonce (未启用防重放时为空).
+func (v *Verifier) verify(ctx context.Context, h http.Header, body []byte) (string, error) {
+	id := h.Get(v.cfg.headers.ID)
+	tsText := h.Get(v.cfg.headers.Timestamp)
+	sigs := h.Get(v.cfg.headers.Signature)
+	if tsText == "" || sigs == "" || v.scheme == StandardWebhooks && id == "" {
+		return "", ErrMissingSignature
+	}
+	ts, err := strconv.ParseInt(tsText, 10, 64)
+	if err != nil {
+		return "", ErrInvalidTimestamp
+	}
+	now := v.cfg.now()
+	sent := time.Unix(ts, 0)
+	if d := now.Sub(sent); d > v.cfg.tolerance || d < -v.cfg.tolerance {
+		return "", ErrTimestampExpired
+	}
+
+	candidates := v.parseSignatures(sigs)
+	matched := false
+	for _, key := range v.keys {
+		mac := sign(key, v.scheme, id, tsText, body)
+		for _, c := range candidates {
+			if hmac.Equal(mac, c) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	if !matched {
+		return "", ErrInvalidSignature
+	}
+
+	if v.cfg.nonces == nil {
+		return "", nil
+	}
+	// 同一消息的多个签名对应同一 nonce, 只重放其中一个签名也会被识别.
+	nonce := string(v.scheme) + ":" + id
+	if v.scheme == HMACSHA256 {
+		sum := sha256.Sum256(signedContent(v.scheme, id, tsText, body))
+		nonce = string(v.scheme) + ":" + hex.EncodeToString(sum[:])
+	}
+	fresh, err := v.cfg.nonces.Claim(ctx, nonce, sent.Add(v.cfg.tolerance).Sub(now)+time.Second)
+	if err != nil {
+		return "", err
+	}
+	if !fresh {
+		return "", ErrReplayed
+	}
+	return nonce, nil
+}
+
+// parseSignatures 解析签名头中本方案版本的签名, 无法解码的条目被忽略.
+func (v *Verifier) parseSignatures(header string) [][]byte {
+	var out [][]byte
+	for _, f := range strings.FieldsFunc(header, func(r rune) bool { return r == ' ' || r == ',' && v.scheme == HMACSHA256 }) {
+		switch v.scheme {
+		case HMACSHA256:
+			if hexSig, ok := strings.CutPrefix(f, "sha256="); ok {
+				if b, err := hex.DecodeString(hexSig); err == nil {
+					out = append(out, b)
+				}
+			}
+		case StandardWebhooks:
+			if b64, ok := strings.CutPrefix(f, "v1,"); ok {
+				if b, err := base64.StdEncoding.DecodeString(b64); err == nil {
+					out = append(out, b)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func signedContent(scheme Scheme, id, ts string, body []byte) []byte {
+	var buf bytes.Buffer
+	if scheme == StandardWebhooks {
+		buf.WriteString(id)
+		buf.WriteByte('.')
+	}
+	buf.WriteString(ts)
+	buf.WriteByte('.')
+	buf.Write(body)
+	return buf.Bytes()
+}
+
+func sign(key []byte, scheme Scheme, id, ts string, body []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(signedContent(scheme, id, ts, body))
+	return mac.Sum(nil)
+}
+
+// claimKey 是 VerifySignature 在请求上保存登记信息的键, Interceptor 据此释放 nonce.
+const claimKey = "github.com/chendefine/ginx/webhook.claim"
+
+// claim 是一次校验登记的 nonce 及其所在的存储; 未启用防重放时 nonce 为空.
+type claim struct {
+	store NonceStore
+	nonce string
+}
+
+// VerifySignature 返回在绑定请求之前校验签名的路由选项, 未设置 ginx.KeepRawBody 时按 KeepRawBody(0) 保留请求体.
+// 校验失败渲染为 401 的 *ginx.ErrWrap, 请求不会进入绑定、校验与 handler.
+// scheme 与 v 的方案不一致或 v 为 nil 时 panic, 防止生成代码与实现配置的方案不符.
+func VerifySignature(scheme Scheme, v *Verifier) ginx.RouteOption {
+	checkVerifier(scheme, v)
+	return ginx.VerifyRawBody(func(ctx context.Context, body []byte) error {
+		nonce, err := v.verify(ctx, ginx.Request(ctx).Header, body)
+		if err != nil {
+			if isVerifyError(err) {
+				return ginx.Error(v.cfg.code, err.Error()).Status(http.StatusUnauthorized)
+			}
+			return err
+		}
+		ginx.Set(ctx, claimKey, claim{store: v.cfg.nonces, nonce: nonce})
+		return nil
+	})
+}
+
+// Interceptor 返回与 VerifySignature 配合使用的拦截器: handler 返回错误或 panic 时释放已登记的 nonce,
+// 发送方携带同一消息 id 的重试可以再次投递. 签名由 VerifySignature 在绑定前校验,
+// 请求未经过 VerifySignature 时拦截器返回错误而不是放行. 参数校验同 VerifySignature.
+func Interceptor(scheme Scheme, v *Verifier) ginx.Interceptor {
+	checkVerifier(scheme, v)
+	return func(ctx context.Context, req any, next func() (any, error)) (any, error) {
+		c, ok := ginx.GetValue[claim](ctx, claimKey)
+		if !ok {
+			return nil, errNotVerified
+		}
+		if c.nonce == "" {
+			return next()
+		}
+		done := false
+		defer func() {
+			if !done {
+				// 超时等情况下 ctx 已取消, 释放不应随之失败.
+				_ = c.store.Release(context.WithoutCancel(ctx), c.nonce)
+			}
+		}()
+		rsp, err := next()
+		done = err == nil
+		return rsp, err
+	}
+}
+
+func checkVerifier(scheme Scheme, v *Verifier) {
+	if v == nil {
+		panic("webhook: verifier must not be nil")
+	}
+	if v.scheme != scheme {
+		panic(fmt.Sprintf("webhook: verifier uses %q, route requires %q", v.scheme, scheme))
+	}
+}
+
+func isVerifyError(err error) bool {
+	for _, e := range [...]error{ErrMissingSignature, ErrInvalidTimestamp, ErrTimestampExpired, ErrInvalidSignature, ErrReplayed} {
+		if errors.Is(err, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// Signer 为出站 webhook 请求签名, 并发安全.
+type Signer struct {
+	scheme Scheme
+	key    []byte
+	cfg    config
+}
+
+// NewSigner 创建 Signer, 只使用 WithHeaders 与 WithClock 选项.
+func NewSigner(scheme Scheme, secret string, opts ...Option) (*Signer, error) {
+	cfg, err := newConfig(scheme, opts)
+	if err != nil {
+		return nil, err
+	}
+	key, err := decodeSecret(scheme, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{scheme: scheme, key: key, cfg: cfg}, nil
+}
+
+// Sign 为 body 设置时间戳与签名头. StandardWebhooks 方案在 h 没有消息 id 时生成一个 msg_<hex>,
+// 重试投递同一消息时应保留原 id.
+func (s *Signer) Sign(h http.Header, body []byte) error {
+	ts := strconv.FormatInt(s.cfg.now().Unix(), 10)
+	id := h.Get(s.cfg.headers.ID)
+	if s.scheme == StandardWebhooks && id == "" {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return err
+		}
+		id = "msg_" + hex.EncodeToString(b[:])
+		h.Set(s.cfg.headers.ID, id)
+	}
+	mac := sign(s.key, s.scheme, id, ts, body)
+	h.Set(s.cfg.headers.Timestamp, ts)
+	if s.scheme == StandardWebhooks {
+		h.Set(s.cfg.headers.Signature, "v1,"+base64.StdEncoding.EncodeToString(mac))
+	} else {
+		h.Set(s.cfg.headers.Signature, "sha256="+hex.EncodeToString(mac))
+	}
+	return nil
+}
+
+// SignRequest 读取 r 的请求体并签名, 随后把请求体替换为可重读的副本.
+func (s *Signer) SignRequest(r *http.Request) error {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		b, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			return err
+		}
+		body = b
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		r.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(b)), nil }
+	}
+	return s.Sign(r.Header, body)
+}
+
+// Transport 返回发出前为每个请求签名的 http.RoundTripper, 可用于 http.Client 或 resty 的
+// SetTransport; base 为 nil 时使用 http.DefaultTransport.
+func (s *Signer) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return signingTransport{signer: s, base: base}
+}
+
+type signingTransport struct {
+	signer *Signer
+	base   http.RoundTripper
+}
+
+func (t signingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	if err := t.signer.SignRequest(r); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(r)
+}
+
+// NonceStore 记录已接收的消息, 实现必须并发安全.
+//
+//   - Claim 原子地登记 nonce 并保留至少 ttl: 首次登记返回 true, 已存在且未过期时返回 false.
+//   - Release 删除登记, 用于 handler 处理失败后允许发送方重试同一消息.
+type NonceStore interface {
+	Claim(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, nonce string) error
+}
+
+// nonceSweepInterval 是 MemoryNonceStore 全量清理过期记录的最小间隔.
+const nonceSweepInterval = time.Minute
+
+// MemoryNonceStore 是进程内的 NonceStore. 多实例部署需要换成共享存储的实现.
+type MemoryNonceStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]time.Time // nonce -> 过期时间
+	lastSweep time.Time
+}
+
+// NewMemoryNonceStore 创建进程内存储.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{now: time.Now, entries: make(map[string]time.Time)}
+}
+
+// Claim 实现 NonceStore.
+func (s *MemoryNonceStore) Claim(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweepLocked(now)
+	if exp, ok := s.entries[nonce]; ok && now.Before(exp) {
+		return false, nil
+	}
+	s.entries[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// Release 实现 NonceStore.
+func (s *MemoryNonceStore) Release(_ context.Context, nonce string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, nonce)
+	return nil
+}
+
+// sweepLocked 每个 nonceSweepInterval 最多全量清理一次过期记录.
+func (s *MemoryNonceStore) sweepLocked(now time.Time) {
+	if now.Sub(s.lastSweep) < nonceSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for k, exp := range s.entries {
+		if !now.Before(exp) {
+			delete(s.entries, k)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chendefine/ginx"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+var (
+	testNow     = time.Unix(1_700_000_000, 0)
+	testClock   = WithClock(func() time.Time { return testNow })
+	testWhsec   = "whsec_" + base64.StdEncoding.EncodeToString([]byte("standard-secret"))
+	testWhsecV2 = "whsec_" + base64.StdEncoding.EncodeToString([]byte("rotated-secret"))
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"orderId":"o1"}`)
+	ctx := context.Background()
+	for _, scheme := range []Scheme{HMACSHA256, StandardWebhooks} {
+		secret, rotated := "s3cret", "n3w"
+		if scheme == StandardWebhooks {
+			secret, rotated = testWhsec, testWhsecV2
+		}
+		signer, err := NewSigner(scheme, secret, testClock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newSigner, _ := NewSigner(scheme, rotated, testClock)
+		v, err := NewVerifier(scheme, []string{rotated, secret}, testClock)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		h := http.Header{}
+		if err := signer.Sign(h, body); err != nil {
+			t.Fatal(err)
+		}
+		if err := v.Verify(ctx, h, body); err != nil {
+			t.Fatalf("%s: %v", scheme, err)
+		}
+		if err := v.Verify(ctx, h, body); !errors.Is(err, ErrReplayed) {
+			t.Fatalf("%s replay: %v", scheme, err)
+		}
+
+		// 轮换期间发送方同时携带新旧签名; 只重放其中一个签名仍被识别为重放.
+		n2 := []byte(`{"n":2}`)
+		oldH, newH := http.Header{}, http.Header{}
+		_ = signer.Sign(oldH, n2)
+		if scheme == StandardWebhooks {
+			newH.Set(StandardIDHeader, oldH.Get(StandardIDHeader))
+		}
+		_ = newSigner.Sign(newH, n2)
+		sigHeader, sep := v.cfg.headers.Signature, ", "
+		if scheme == StandardWebhooks {
+			sep = " "
+		}
+		multi := oldH.Clone()
+		multi.Set(sigHeader, "v0,ignored"+sep+oldH.Get(sigHeader)+sep+newH.Get(sigHeader))
+		if err := v.Verify(ctx, multi, n2); err != nil {
+			t.Fatalf("%s multi: %v", scheme, err)
+		}
+		if err := v.Verify(ctx, newH, n2); !errors.Is(err, ErrReplayed) {
+			t.Fatalf("%s partial replay: %v", scheme, err)
+		}
+
+		h = http.Header{}
+		_ = signer.Sign(h, body)
+		tests := []struct {
+			name   string
+			mutate func(http.Header) []byte
+			want   error
+		}{
+			{"tampered body", func(http.Header) []byte { return []byte(`{"orderId":"o2"}`) }, ErrInvalidSignature},
+			{"missing signature", func(h http.Header) []byte { h.Del(sigHeader); return body }, ErrMissingSignature},
+			{"bad timestamp", func(h http.Header) []byte { h.Set(v.cfg.headers.Timestamp, "soon"); return body }, ErrInvalidTimestamp},
+			{"shifted timestamp", func(h http.Header) []byte { h.Set(v.cfg.headers.Timestamp, "1700000001"); return body }, ErrInvalidSignature},
+		}
+		for _, tt := range tests {
+			hc := h.Clone()
+			b := tt.mutate(hc)
+			if err := v.Verify(ctx, hc, b); !errors.Is(err, tt.want) {
+				t.Fatalf("%s %s: %v", scheme, tt.name, err)
+			}
+		}
+
+		stale, _ := NewVerifier(scheme, []string{secret}, WithClock(func() time.Time { return testNow.Add(6 * time.Minute) }))
+		if err := stale.Verify(ctx, h, body); !errors.Is(err, ErrTimestampExpired) {
+			t.Fatalf("%s stale: %v", scheme, err)
+		}
+		wide, _ := NewVerifier(scheme, []string{secret}, WithTolerance(10*time.Minute), WithNonceStore(nil),
+			WithClock(func() time.Time { return testNow.Add(-6 * time.Minute) }))
+		for range 2 {
+			if err := wide.Verify(ctx, h, body); err != nil {
+				t.Fatalf("%s wide: %v", scheme, err)
+			}
+		}
+	}
+}
+
+func TestNewVerifierErrors(t *testing.T) {
+	if _, err := NewVerifier("md5", []string{"s"}); err == nil {
+		t.Fatal("unknown scheme should fail")
+	}
+	if _, err := NewVerifier(HMACSHA256, nil); err == nil {
+		t.Fatal("missing secret should fail")
+	}
+	if _, err := NewSigner(StandardWebhooks, "whsec_!!"); err == nil {
+		t.Fatal("invalid whsec should fail")
+	}
+	v, _ := NewVerifier(HMACSHA256, []string{"s"})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("scheme mismatch should panic")
+		}
+	}()
+	VerifySignature(StandardWebhooks, v)
+}
+
+func TestInterceptor(t *testing.T) {
+	type orderReq struct {
+		OrderID string `json:"orderId" binding:"required"`
+	}
+	v, _ := NewVerifier(HMACSHA256, []string{"s3cret"}, WithErrorCode(4010))
+	signer, _ := NewSigner(HMACSHA256, "s3cret")
+	handler := func(ctx context.Context, req *orderReq) (*orderReq, error) { return req, nil }
+	r := gin.New()
+	ginx.POST(r, "/hook", handler, VerifySignature(HMACSHA256, v), ginx.RouteInterceptor(Interceptor(HMACSHA256, v)))
+	ginx.POST(r, "/misconfigured", handler, ginx.RouteInterceptor(Interceptor(HMACSHA256, v)))
+
+	send := func(path, body string, signed bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if signed {
+			if err := signer.SignRequest(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) (code int, msg string) {
+		var body struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return body.Code, body.Msg
+	}
+
+	if w := send("/hook", `{"orderId":"o1"}`, true); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"orderId":"o1"`) {
+		t.Fatalf("signed: %d %s", w.Code, w.Body.String())
+	}
+	w := send("/hook", `{"orderId":"o1"}`, false)
+	if code, msg := decode(w); w.Code != http.StatusUnauthorized || code != 4010 || msg != ErrMissingSignature.Error() {
+		t.Fatalf("unsigned: %d %s", w.Code, w.Body.String())
+	}
+	// 签名先于绑定校验: 未签名的非法请求体得到 401 而不是 400.
+	if w := send("/hook", `{}`, false); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unsigned invalid body: %d %s", w.Code, w.Body.String())
+	}
+	if w := send("/hook", `{}`, true); w.Code != http.StatusBadRequest {
+		t.Fatalf("signed invalid body: %d %s", w.Code, w.Body.String())
+	}
+	if w := send("/misconfigured", `{"orderId":"o1"}`, true); w.Code != http.StatusInternalServerError {
+		t.Fatalf("misconfigured: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestInterceptorRetryAfterFailure(t *testing.T) {
+	type eventReq struct {
+		ID string `json:"id"`
+	}
+	v, _ := NewVerifier(StandardWebhooks, []string{testWhsec})
+	signer, _ := NewSigner(StandardWebhooks, testWhsec)
+	calls := 0
+	r := gin.New()
+	ginx.POST(r, "/hook", func(ctx context.Context, req *eventReq) (*eventReq, error) {
+		if calls++; calls == 1 {
+			return nil, errors.New("db down")
+		}
+		return req, nil
+	}, ginx.KeepRawBody(0), VerifySignature(StandardWebhooks, v), ginx.RouteInterceptor(Interceptor(StandardWebhooks, v)))
+
+	body := []byte(`{"id":"evt_1"}`)
+	h := http.Header{"Content-Type": {"application/json"}}
+	if err := signer.Sign(h, body); err != nil {
+		t.Fatal(err)
+	}
+	// 发送方在 500 后以相同的 webhook-id 与签名重试.
+	for i, want := range []int{http.StatusInternalServerError, http.StatusOK, http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(string(body)))
+		req.Header = h.Clone()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("delivery %d: %d %s, want %d", i+1, w.Code, w.Body.String(), want)
+		}
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	now := testNow
+	s := NewMemoryNonceStore()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	if ok, _ := s.Claim(ctx, "a", time.Minute); !ok {
+		t.Fatal("first claim should succeed")
+	}
+	if ok, _ := s.Claim(ctx, "a", time.Minute); ok {
+		t.Fatal("second claim should fail")
+	}
+	now = now.Add(2 * time.Minute)
+	if ok, _ := s.Claim(ctx, "a", time.Minute); !ok {
+		t.Fatal("expired nonce should be claimable")
+	}
+	if len(s.entries) != 1 {
+		t.Fatalf("entries = %d, want expired ones swept", len(s.entries))
+	}
+	_ = s.Release(ctx, "a")
+	if ok, _ := s.Claim(ctx, "a", time.Minute); !ok {
+		t.Fatal("released nonce should be claimable")
+	}
+}